@@ -124,3 +124,48 @@ func captureStdout(t *testing.T, fn func()) string {
 	require.NoError(t, err)
 	return string(out)
 }
+
+type testParentParam struct {
+	ParamBase `use:"parent" desc:"runnable parent command"`
+}
+
+type testChildParam struct {
+	ParamBase `use:"parent child" desc:"child command"`
+}
+
+type testChildReceiver struct {
+	called bool
+}
+
+func (r *testChildReceiver) ChildCommand(ctx context.Context, p *testChildParam) error {
+	r.called = true
+	return nil
+}
+
+type testParentReceiver struct {
+	called bool
+}
+
+func (r *testParentReceiver) ParentCommand(ctx context.Context, p *testParentParam) error {
+	r.called = true
+	return nil
+}
+
+func TestMergeFunctionCommandsRunnableParentAfterChild(t *testing.T) {
+	host := &struct{ *CmdState }{CmdState: NewCmdState("host", &configs.Config{})}
+	child := &testChildReceiver{}
+	parent := &testParentReceiver{}
+	root := &cobra.Command{SilenceUsage: true, SilenceErrors: true}
+
+	host.MergeFunctionCommandsFrom(root, host, child)
+	host.MergeFunctionCommandsFrom(root, host, parent)
+	require.Len(t, root.Commands(), 1)
+
+	root.SetArgs([]string{"parent", "child"})
+	require.NoError(t, root.Execute())
+	require.True(t, child.called)
+
+	root.SetArgs([]string{"parent"})
+	require.NoError(t, root.Execute())
+	require.True(t, parent.called)
+}
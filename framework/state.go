@@ -142,10 +142,23 @@ func (s *CmdState) MergeFunctionCommandsFrom(cmd *cobra.Command, host State, rec
 			}
 			target = node
 		}
+		adoptPlaceholder(target, item.cmd)
 		target.AddCommand(item.cmd)
 	}
 }
 
+// adoptPlaceholder moves sub commands of the placeholder node with the same name into cmd,
+// so that a runnable command can also have sub commands registered before it.
+func adoptPlaceholder(target *cobra.Command, cmd *cobra.Command) {
+	for _, child := range target.Commands() {
+		if child.Name() != cmd.Name() || child.Runnable() {
+			continue
+		}
+		target.RemoveCommand(child)
+		cmd.AddCommand(child.Commands()...)
+	}
+}
+
 func (s *CmdState) MergeCobraCommands(base *cobra.Command, cmds ...*cobra.Command) {
 	for _, cmd := range cmds {
 		target, _, err := base.Find([]string{cmd.Use})
@@ -6,6 +6,7 @@ package gen
 import (
 	"fmt"
 	"math/rand"
+	"sort"
 
 	"gopkg.in/yaml.v3"
 )
@@ -53,6 +54,40 @@ func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
 	return nil
 }
 
+// MarshalYAML is the inverse of UnmarshalYAML: Args are flattened next to
+// `type`/`seed` (sorted for stable output) so a marshaled spec decodes back
+// into the same Spec.
+func (s Spec) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	appendKV := func(key string, value any) error {
+		var v yaml.Node
+		if err := v.Encode(value); err != nil {
+			return err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &v)
+		return nil
+	}
+	if err := appendKV("type", s.Type); err != nil {
+		return nil, err
+	}
+	if s.Seed != nil {
+		if err := appendKV("seed", *s.Seed); err != nil {
+			return nil, err
+		}
+	}
+	keys := make([]string, 0, len(s.Args))
+	for k := range s.Args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := appendKV(k, s.Args[k]); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
 // Generator produces a typed slice (e.g. []int64, [][]float32) from a Spec.
 type Generator interface {
 	Generate(spec *Spec, r *rand.Rand) (any, error)
@@ -0,0 +1,29 @@
+package gen
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSpecYAMLRoundTrip(t *testing.T) {
+	seed := int64(42)
+	spec := &Spec{Type: "random_float_vector", Seed: &seed, Args: map[string]any{"dim": int64(8), "count": 3}}
+
+	bs, err := yaml.Marshal(spec)
+	require.NoError(t, err)
+	require.Equal(t, "type: random_float_vector\nseed: 42\ncount: 3\ndim: 8\n", string(bs))
+
+	var decoded Spec
+	require.NoError(t, yaml.Unmarshal(bs, &decoded))
+	require.Equal(t, spec.Type, decoded.Type)
+	require.Equal(t, seed, *decoded.Seed)
+
+	expected, err := Resolve(spec, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	actual, err := Resolve(&decoded, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+}
@@ -0,0 +1,675 @@
+package ops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/milvus-io/birdwatcher/internal/ops/source"
+	"github.com/milvus-io/milvus/client/v2/column"
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+)
+
+// ImportFileParams streams rows from a local Parquet/JSONL/CSV/NumPy file
+// and inserts them in batches. Source columns are matched to collection
+// fields by name unless `mapping` (field -> column) says otherwise.
+type ImportFileParams struct {
+	Collection  string            `yaml:"collection"`
+	Partition   string            `yaml:"partition,omitempty"`
+	Path        string            `yaml:"path"`
+	Format      string            `yaml:"format,omitempty"`
+	Mapping     map[string]string `yaml:"mapping,omitempty"`
+	BatchSize   int               `yaml:"batch_size,omitempty"`
+	Concurrency int               `yaml:"concurrency,omitempty"`
+	Limit       int64             `yaml:"limit,omitempty"`
+}
+
+type ImportFileResult struct {
+	Count   int64    `yaml:"count"`
+	Batches int64    `yaml:"batches"`
+	Ignored []string `yaml:"ignored,omitempty"`
+}
+
+// importTarget binds one collection field to the source column feeding it.
+type importTarget struct {
+	field  *entity.Field
+	column string
+}
+
+type importBatch struct {
+	seq  int64
+	cols []column.Column
+}
+
+func (p *ImportFileParams) Execute(ctx context.Context, rc *RunContext) (any, error) {
+	if rc.Client == nil {
+		return nil, errNoClient("import_file")
+	}
+	if p.Collection == "" {
+		return nil, fmt.Errorf("import_file: `collection` required")
+	}
+	if p.Path == "" {
+		return nil, fmt.Errorf("import_file: `path` required")
+	}
+	batchSize := p.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	concurrency := p.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	coll, err := rc.Client.DescribeCollection(ctx, milvusclient.NewDescribeCollectionOption(p.Collection))
+	if err != nil {
+		return nil, fmt.Errorf("describe collection: %w", err)
+	}
+
+	reader, err := source.Open(p.Path, p.Format)
+	if err != nil {
+		return nil, fmt.Errorf("import_file: %w", err)
+	}
+	defer reader.Close()
+
+	// peek the first batch so column names are known for every format,
+	// jsonl discovers its columns while reading
+	first, err := reader.Next(batchSize)
+	if errors.Is(err, io.EOF) {
+		fmt.Fprintf(rc.Out(), "%s is empty, nothing imported\n", p.Path)
+		return ImportFileResult{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", p.Path, err)
+	}
+
+	columns := reader.Columns()
+	if source.IsSchemaless(reader) {
+		// columns seen in the first batch may miss fields which are only set
+		// in later rows, feed every field and let missing cells be null
+		columns = append(schemaImportColumns(coll, p.Mapping), columns...)
+	}
+	targets, ignored, err := resolveImportTargets(coll, columns, p.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	if len(ignored) > 0 {
+		fmt.Fprintf(rc.Out(), "source columns not mapped to any field, ignored: %s\n", strings.Join(ignored, ", "))
+	}
+
+	total := reader.Total()
+	if p.Limit > 0 && (total < 0 || total > p.Limit) {
+		total = p.Limit
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		inserted atomic.Int64
+		batches  atomic.Int64
+		mu       sync.Mutex
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	ch := make(chan importBatch, concurrency)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range ch {
+				opt := milvusclient.NewColumnBasedInsertOption(p.Collection, b.cols...)
+				if p.Partition != "" {
+					opt = opt.WithPartition(p.Partition)
+				}
+				res, err := rc.Client.Insert(ctx, opt)
+				if err != nil {
+					fail(fmt.Errorf("insert batch %d: %w", b.seq, err))
+					continue
+				}
+				n := inserted.Add(res.InsertCount)
+				batches.Add(1)
+				mu.Lock()
+				printImportProgress(rc.Out(), p.Collection, n, total)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var (
+		read int64
+		seq  int64
+	)
+	rows := first
+	for ctx.Err() == nil {
+		if p.Limit > 0 && read+int64(len(rows)) > p.Limit {
+			rows = rows[:p.Limit-read]
+		}
+		if len(rows) == 0 {
+			break
+		}
+		cols, err := buildImportColumns(targets, rows)
+		if err != nil {
+			fail(fmt.Errorf("rows %d-%d: %w", read, read+int64(len(rows))-1, err))
+			break
+		}
+		select {
+		case ch <- importBatch{seq: seq, cols: cols}:
+		case <-ctx.Done():
+		}
+		seq++
+		read += int64(len(rows))
+		if p.Limit > 0 && read >= p.Limit {
+			break
+		}
+		rows, err = reader.Next(batchSize)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			fail(fmt.Errorf("read %s: %w", p.Path, err))
+			break
+		}
+	}
+	close(ch)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, fmt.Errorf("import_file: %w (%d rows inserted before failure)", firstErr, inserted.Load())
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(rc.Out(), "imported %d rows from %s into %q in %d batches\n", inserted.Load(), p.Path, p.Collection, batches.Load())
+	return ImportFileResult{Count: inserted.Load(), Batches: batches.Load(), Ignored: ignored}, nil
+}
+
+func printImportProgress(out io.Writer, collection string, done, total int64) {
+	if total > 0 {
+		fmt.Fprintf(out, "%q: %d/%d rows (%.1f%%)\n", collection, done, total, float64(done)*100/float64(total))
+		return
+	}
+	fmt.Fprintf(out, "%q: %d rows\n", collection, done)
+}
+
+// importNullable reports whether cells of the field may be null, null cells
+// of a field with default value are filled by the server.
+func importNullable(f *entity.Field) bool {
+	return f.Nullable || f.DefaultValue != nil
+}
+
+// schemaImportColumns lists the source columns feeding the schema fields,
+// used for sources whose columns are only known while reading.
+func schemaImportColumns(coll *entity.Collection, mapping map[string]string) []string {
+	var columns []string
+	for _, f := range coll.Schema.Fields {
+		if (f.PrimaryKey && f.AutoID) || f.IsDynamic {
+			continue
+		}
+		col := f.Name
+		if m, ok := mapping[f.Name]; ok {
+			col = m
+		}
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+// resolveImportTargets matches schema fields to source columns. AutoID
+// primary keys and the dynamic field are never fed from the source;
+// nullable fields and fields with default value may be absent, every
+// other field must be present.
+func resolveImportTargets(coll *entity.Collection, columns []string, mapping map[string]string) ([]importTarget, []string, error) {
+	available := make(map[string]bool, len(columns))
+	var distinct []string
+	for _, c := range columns {
+		if !available[c] {
+			distinct = append(distinct, c)
+		}
+		available[c] = true
+	}
+	columns = distinct
+	for field := range mapping {
+		found := false
+		for _, f := range coll.Schema.Fields {
+			if f.Name == field {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("mapping refers to unknown field %q", field)
+		}
+	}
+
+	used := map[string]bool{}
+	var targets []importTarget
+	for _, f := range coll.Schema.Fields {
+		if (f.PrimaryKey && f.AutoID) || f.IsDynamic {
+			continue
+		}
+		col := f.Name
+		if m, ok := mapping[f.Name]; ok {
+			col = m
+		}
+		if !available[col] {
+			if importNullable(f) {
+				continue
+			}
+			return nil, nil, fmt.Errorf("field %q: source column %q not found (columns: %s)", f.Name, col, strings.Join(columns, ", "))
+		}
+		used[col] = true
+		targets = append(targets, importTarget{field: f, column: col})
+	}
+
+	var ignored []string
+	for _, c := range columns {
+		if !used[c] {
+			ignored = append(ignored, c)
+		}
+	}
+	return targets, ignored, nil
+}
+
+// buildImportColumns converts a batch of loosely typed rows into typed
+// columns following each target field's data type.
+func buildImportColumns(targets []importTarget, rows []source.Row) ([]column.Column, error) {
+	cols := make([]column.Column, 0, len(targets))
+	for _, t := range targets {
+		col, err := buildImportColumn(t, rows)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", t.field.Name, err)
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+func buildImportColumn(t importTarget, rows []source.Row) (column.Column, error) {
+	name := t.field.Name
+	// null cells are only accepted for scalar fields, vectors are always required
+	isNull := importNullCell(t.field)
+	nullable := isNull != nil
+	switch t.field.DataType {
+	case entity.FieldTypeBool:
+		data, valid, err := convertRows(rows, t.column, isNull, looseBool)
+		if err != nil {
+			return nil, err
+		}
+		if nullable {
+			return column.NewNullableColumnBool(name, data, valid)
+		}
+		return column.NewColumnBool(name, data), nil
+	case entity.FieldTypeInt8:
+		data, valid, err := convertRows(rows, t.column, isNull, func(v any) (int8, error) {
+			n, err := looseIntN(v, 8)
+			return int8(n), err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if nullable {
+			return column.NewNullableColumnInt8(name, data, valid)
+		}
+		return column.NewColumnInt8(name, data), nil
+	case entity.FieldTypeInt16:
+		data, valid, err := convertRows(rows, t.column, isNull, func(v any) (int16, error) {
+			n, err := looseIntN(v, 16)
+			return int16(n), err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if nullable {
+			return column.NewNullableColumnInt16(name, data, valid)
+		}
+		return column.NewColumnInt16(name, data), nil
+	case entity.FieldTypeInt32:
+		data, valid, err := convertRows(rows, t.column, isNull, func(v any) (int32, error) {
+			n, err := looseIntN(v, 32)
+			return int32(n), err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if nullable {
+			return column.NewNullableColumnInt32(name, data, valid)
+		}
+		return column.NewColumnInt32(name, data), nil
+	case entity.FieldTypeInt64:
+		data, valid, err := convertRows(rows, t.column, isNull, looseInt64)
+		if err != nil {
+			return nil, err
+		}
+		if nullable {
+			return column.NewNullableColumnInt64(name, data, valid)
+		}
+		return column.NewColumnInt64(name, data), nil
+	case entity.FieldTypeFloat:
+		data, valid, err := convertRows(rows, t.column, isNull, func(v any) (float32, error) {
+			f, err := looseFloat64(v)
+			return float32(f), err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if nullable {
+			return column.NewNullableColumnFloat(name, data, valid)
+		}
+		return column.NewColumnFloat(name, data), nil
+	case entity.FieldTypeDouble:
+		data, valid, err := convertRows(rows, t.column, isNull, looseFloat64)
+		if err != nil {
+			return nil, err
+		}
+		if nullable {
+			return column.NewNullableColumnDouble(name, data, valid)
+		}
+		return column.NewColumnDouble(name, data), nil
+	case entity.FieldTypeVarChar, entity.FieldTypeString:
+		data, valid, err := convertRows(rows, t.column, isNull, looseString)
+		if err != nil {
+			return nil, err
+		}
+		if nullable {
+			return column.NewNullableColumnVarChar(name, data, valid)
+		}
+		return column.NewColumnVarChar(name, data), nil
+	case entity.FieldTypeJSON:
+		data, valid, err := convertRows(rows, t.column, isNull, looseJSON)
+		if err != nil {
+			return nil, err
+		}
+		if nullable {
+			return column.NewNullableColumnJSONBytes(name, data, valid)
+		}
+		return column.NewColumnJSONBytes(name, data), nil
+	case entity.FieldTypeFloatVector:
+		data, _, err := convertRows(rows, t.column, nil, looseFloatVector)
+		if err != nil {
+			return nil, err
+		}
+		dim, err := vectorDim(t.field, len(data[0]))
+		if err != nil {
+			return nil, err
+		}
+		return column.NewColumnFloatVector(name, dim, data), nil
+	case entity.FieldTypeBinaryVector:
+		data, _, err := convertRows(rows, t.column, nil, looseBinaryVector)
+		if err != nil {
+			return nil, err
+		}
+		dim, err := vectorDim(t.field, len(data[0])*8)
+		if err != nil {
+			return nil, err
+		}
+		return column.NewColumnBinaryVector(name, dim, data), nil
+	case entity.FieldTypeInt8Vector:
+		data, _, err := convertRows(rows, t.column, nil, looseInt8Vector)
+		if err != nil {
+			return nil, err
+		}
+		dim, err := vectorDim(t.field, len(data[0]))
+		if err != nil {
+			return nil, err
+		}
+		return column.NewColumnInt8Vector(name, dim, data), nil
+	}
+	return nil, fmt.Errorf("data type %s is not supported by import_file", t.field.DataType.String())
+}
+
+func vectorDim(f *entity.Field, actual int) (int, error) {
+	raw, ok := f.TypeParams["dim"]
+	if !ok {
+		return actual, nil
+	}
+	dim, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("bad dim %q in schema", raw)
+	}
+	if dim != actual {
+		return 0, fmt.Errorf("vector dim mismatch, schema %d, source %d", dim, actual)
+	}
+	return dim, nil
+}
+
+// importNullCell returns the check of null cells for fields accepting null, nil otherwise.
+// CSV cells are always strings, an empty cell is null unless the field is a string.
+func importNullCell(f *entity.Field) func(any) bool {
+	switch {
+	case !importNullable(f):
+		return nil
+	case f.DataType == entity.FieldTypeVarChar, f.DataType == entity.FieldTypeString:
+		return func(v any) bool { return v == nil }
+	}
+	return func(v any) bool {
+		s, ok := v.(string)
+		return v == nil || (ok && s == "")
+	}
+}
+
+// convertRows converts the cells of col, null cells are only accepted when
+// isNull is provided and are marked in the returned valid data, which is nil otherwise.
+func convertRows[T any](rows []source.Row, col string, isNull func(any) bool, conv func(any) (T, error)) ([]T, []bool, error) {
+	out := make([]T, len(rows))
+	var valid []bool
+	if isNull != nil {
+		valid = make([]bool, len(rows))
+	}
+	for i, row := range rows {
+		v := row[col]
+		if isNull != nil && isNull(v) {
+			continue
+		}
+		if v == nil {
+			return nil, nil, fmt.Errorf("row %d: column %q is empty", i, col)
+		}
+		val, err := conv(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("row %d: %w", i, err)
+		}
+		out[i] = val
+		if valid != nil {
+			valid[i] = true
+		}
+	}
+	return out, valid, nil
+}
+
+// The loose* converters accept whatever the source readers produce:
+// native Go numbers (parquet/npy), json.Number (jsonl) and strings (csv).
+
+func looseInt64(v any) (int64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		return n.Int64()
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return toInt64(v)
+}
+
+// looseIntN converts v to an integer in range of a signed int of the bits,
+// so narrowing it never silently wraps.
+func looseIntN(v any, bits int) (int64, error) {
+	n, err := looseInt64(v)
+	if err != nil {
+		return 0, err
+	}
+	minValue, maxValue := int64(-1)<<(bits-1), int64(1)<<(bits-1)-1
+	if n < minValue || n > maxValue {
+		return 0, fmt.Errorf("value %d out of int%d range", n, bits)
+	}
+	return n, nil
+}
+
+func looseFloat64(v any) (float64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		return n.Float64()
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(n), 64)
+	case int8:
+		return float64(n), nil
+	case int16:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case uint8:
+		return float64(n), nil
+	case uint16:
+		return float64(n), nil
+	case uint32:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	}
+	return toFloat64(v)
+}
+
+func looseBool(v any) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		return strconv.ParseBool(strings.TrimSpace(b))
+	}
+	n, err := looseInt64(v)
+	if err != nil {
+		return false, fmt.Errorf("expected bool got %T", v)
+	}
+	return n != 0, nil
+}
+
+func looseString(v any) (string, error) {
+	switch s := v.(type) {
+	case string:
+		return s, nil
+	case []byte:
+		return string(s), nil
+	case json.Number:
+		return s.String(), nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+func looseJSON(v any) ([]byte, error) {
+	switch s := v.(type) {
+	case string:
+		if !json.Valid([]byte(s)) {
+			return nil, fmt.Errorf("invalid json %q", s)
+		}
+		return []byte(s), nil
+	case []byte:
+		if !json.Valid(s) {
+			return nil, fmt.Errorf("invalid json %q", string(s))
+		}
+		return s, nil
+	}
+	return json.Marshal(v)
+}
+
+// looseElements unpacks list-like values; csv cells carry vectors as JSON
+// array literals.
+func looseElements(v any) ([]any, error) {
+	switch l := v.(type) {
+	case []any:
+		return l, nil
+	case string:
+		dec := json.NewDecoder(strings.NewReader(l))
+		dec.UseNumber()
+		var out []any
+		if err := dec.Decode(&out); err != nil {
+			return nil, fmt.Errorf("parse vector %q: %w", l, err)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("expected vector got %T", v)
+}
+
+func looseFloatVector(v any) ([]float32, error) {
+	switch l := v.(type) {
+	case []float32:
+		return l, nil
+	case []float64:
+		out := make([]float32, len(l))
+		for i, f := range l {
+			out[i] = float32(f)
+		}
+		return out, nil
+	}
+	elems, err := looseElements(v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float32, len(elems))
+	for i, e := range elems {
+		f, err := looseFloat64(e)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		out[i] = float32(f)
+	}
+	return out, nil
+}
+
+func looseBinaryVector(v any) ([]byte, error) {
+	if b, ok := v.([]byte); ok {
+		return b, nil
+	}
+	elems, err := looseElements(v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(elems))
+	for i, e := range elems {
+		n, err := looseInt64(e)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		out[i] = byte(n)
+	}
+	return out, nil
+}
+
+func looseInt8Vector(v any) ([]int8, error) {
+	if l, ok := v.([]int8); ok {
+		return l, nil
+	}
+	elems, err := looseElements(v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]int8, len(elems))
+	for i, e := range elems {
+		n, err := looseIntN(e, 8)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		out[i] = int8(n)
+	}
+	return out, nil
+}
+
+func init() {
+	Register("import_file", func() Op { return &ImportFileParams{} })
+}
@@ -0,0 +1,210 @@
+package ops
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/birdwatcher/internal/ops/source"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/client/v2/entity"
+)
+
+func testImportCollection() *entity.Collection {
+	return &entity.Collection{Schema: &entity.Schema{Fields: []*entity.Field{
+		{Name: "id", DataType: entity.FieldTypeInt64, PrimaryKey: true},
+		{Name: "auto", DataType: entity.FieldTypeInt64, PrimaryKey: true, AutoID: true},
+		{Name: "vec", DataType: entity.FieldTypeFloatVector, TypeParams: map[string]string{"dim": "2"}},
+		{Name: "tag", DataType: entity.FieldTypeVarChar, Nullable: true},
+		{Name: "score", DataType: entity.FieldTypeFloat, DefaultValue: &schemapb.ValueField{Data: &schemapb.ValueField_FloatData{FloatData: 1}}},
+		{Name: "$meta", DataType: entity.FieldTypeJSON, IsDynamic: true},
+	}}}
+}
+
+func TestResolveImportTargets(t *testing.T) {
+	coll := testImportCollection()
+	cases := []struct {
+		name    string
+		columns []string
+		mapping map[string]string
+		targets map[string]string
+		ignored []string
+		wantErr bool
+	}{
+		{
+			name:    "match by name",
+			columns: []string{"id", "vec", "tag", "score", "extra"},
+			targets: map[string]string{"id": "id", "vec": "vec", "tag": "tag", "score": "score"},
+			ignored: []string{"extra"},
+		},
+		{
+			name:    "nullable and default absent",
+			columns: []string{"id", "vec"},
+			targets: map[string]string{"id": "id", "vec": "vec"},
+		},
+		{
+			name:    "mapping",
+			columns: []string{"pk", "embedding"},
+			mapping: map[string]string{"id": "pk", "vec": "embedding"},
+			targets: map[string]string{"id": "pk", "vec": "embedding"},
+		},
+		{
+			name:    "schema columns",
+			columns: append(schemaImportColumns(coll, map[string]string{"vec": "embedding"}), "id", "other"),
+			mapping: map[string]string{"vec": "embedding"},
+			targets: map[string]string{"id": "id", "vec": "embedding", "tag": "tag", "score": "score"},
+			ignored: []string{"other"},
+		},
+		{
+			name:    "required absent",
+			columns: []string{"id"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown mapping field",
+			columns: []string{"id", "vec"},
+			mapping: map[string]string{"missing": "id"},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			targets, ignored, err := resolveImportTargets(coll, c.columns, c.mapping)
+			if c.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			got := make(map[string]string)
+			for _, target := range targets {
+				got[target.field.Name] = target.column
+			}
+			assert.Equal(t, c.targets, got)
+			assert.Equal(t, c.ignored, ignored)
+		})
+	}
+}
+
+func TestConvertRows(t *testing.T) {
+	cases := []struct {
+		name     string
+		rows     []source.Row
+		field    *entity.Field
+		conv     func(any) (any, error)
+		expected []any
+		valid    []bool
+		wantErr  bool
+	}{
+		{
+			name:     "int64 from loose values",
+			rows:     []source.Row{{"c": json.Number("9007199254740993")}, {"c": "2"}, {"c": int32(3)}, {"c": true}},
+			conv:     func(v any) (any, error) { return looseInt64(v) },
+			expected: []any{int64(9007199254740993), int64(2), int64(3), int64(1)},
+		},
+		{
+			name:     "float64 from loose values",
+			rows:     []source.Row{{"c": json.Number("0.5")}, {"c": " 1.5"}, {"c": uint16(2)}},
+			conv:     func(v any) (any, error) { return looseFloat64(v) },
+			expected: []any{0.5, 1.5, float64(2)},
+		},
+		{
+			name:     "bool from loose values",
+			rows:     []source.Row{{"c": "true"}, {"c": json.Number("0")}, {"c": false}},
+			conv:     func(v any) (any, error) { return looseBool(v) },
+			expected: []any{true, false, false},
+		},
+		{
+			name:     "string from loose values",
+			rows:     []source.Row{{"c": "a"}, {"c": []byte("b")}, {"c": json.Number("3")}},
+			conv:     func(v any) (any, error) { return looseString(v) },
+			expected: []any{"a", "b", "3"},
+		},
+		{
+			name:     "nullable",
+			rows:     []source.Row{{"c": "1"}, {"c": nil}, {}},
+			field:    &entity.Field{DataType: entity.FieldTypeInt64, Nullable: true},
+			conv:     func(v any) (any, error) { return looseInt64(v) },
+			expected: []any{int64(1), nil, nil},
+			valid:    []bool{true, false, false},
+		},
+		{
+			name:     "csv empty cell of nullable",
+			rows:     []source.Row{{"c": "1"}, {"c": ""}},
+			field:    &entity.Field{DataType: entity.FieldTypeInt64, Nullable: true},
+			conv:     func(v any) (any, error) { return looseInt64(v) },
+			expected: []any{int64(1), nil},
+			valid:    []bool{true, false},
+		},
+		{
+			name:     "csv empty cell of default value",
+			rows:     []source.Row{{"c": ""}, {"c": "0.5"}},
+			field:    &entity.Field{DataType: entity.FieldTypeFloat, DefaultValue: &schemapb.ValueField{Data: &schemapb.ValueField_FloatData{FloatData: 1}}},
+			conv:     func(v any) (any, error) { return looseFloat64(v) },
+			expected: []any{nil, 0.5},
+			valid:    []bool{false, true},
+		},
+		{
+			name:     "csv empty cell of nullable string",
+			rows:     []source.Row{{"c": ""}, {"c": nil}},
+			field:    &entity.Field{DataType: entity.FieldTypeVarChar, Nullable: true},
+			conv:     func(v any) (any, error) { return looseString(v) },
+			expected: []any{"", nil},
+			valid:    []bool{true, false},
+		},
+		{
+			name:    "csv empty cell not nullable",
+			rows:    []source.Row{{"c": ""}},
+			field:   &entity.Field{DataType: entity.FieldTypeInt64},
+			conv:    func(v any) (any, error) { return looseInt64(v) },
+			wantErr: true,
+		},
+		{
+			name:     "int8 in range",
+			rows:     []source.Row{{"c": "-128"}, {"c": json.Number("127")}},
+			conv:     func(v any) (any, error) { return looseIntN(v, 8) },
+			expected: []any{int64(-128), int64(127)},
+		},
+		{
+			name:    "int8 overflow",
+			rows:    []source.Row{{"c": "1"}, {"c": "128"}},
+			conv:    func(v any) (any, error) { return looseIntN(v, 8) },
+			wantErr: true,
+		},
+		{
+			name:    "int32 overflow",
+			rows:    []source.Row{{"c": json.Number("-2147483649")}},
+			conv:    func(v any) (any, error) { return looseIntN(v, 32) },
+			wantErr: true,
+		},
+		{
+			name:    "null not nullable",
+			rows:    []source.Row{{"c": "1"}, {"c": nil}},
+			conv:    func(v any) (any, error) { return looseInt64(v) },
+			wantErr: true,
+		},
+		{
+			name:    "bad value",
+			rows:    []source.Row{{"c": "x"}},
+			conv:    func(v any) (any, error) { return looseInt64(v) },
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var isNull func(any) bool
+			if c.field != nil {
+				isNull = importNullCell(c.field)
+			}
+			data, valid, err := convertRows(c.rows, "c", isNull, c.conv)
+			if c.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, data)
+			assert.Equal(t, c.valid, valid)
+		})
+	}
+}
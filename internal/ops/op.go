@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"reflect"
 
 	"github.com/milvus-io/milvus/client/v2/milvusclient"
 )
@@ -56,6 +57,21 @@ func New(name string) (Op, bool) {
 	return f(), true
 }
 
+// NameOf returns the registered name of op's concrete type. Used by the
+// session recorder to turn CLI-built ops back into scenario steps.
+func NameOf(op Op) (string, bool) {
+	if op == nil {
+		return "", false
+	}
+	tp := reflect.TypeOf(op)
+	for name, f := range registry {
+		if reflect.TypeOf(f()) == tp {
+			return name, true
+		}
+	}
+	return "", false
+}
+
 // RegisteredNames lists all known op names, useful for diagnostics.
 func RegisteredNames() []string {
 	out := make([]string, 0, len(registry))
@@ -0,0 +1,65 @@
+package source
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// csvReader reads a CSV file with a header row. Cells stay strings; vector
+// and JSON columns are expected to hold JSON literals such as "[0.1,0.2]".
+type csvReader struct {
+	f       *os.File
+	r       *csv.Reader
+	columns []string
+}
+
+func openCSV(path string) (Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(f)
+	r.ReuseRecord = false
+	header, err := r.Read()
+	if err != nil {
+		f.Close()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("csv %s: missing header row", path)
+		}
+		return nil, err
+	}
+	return &csvReader{f: f, r: r, columns: header}, nil
+}
+
+func (r *csvReader) Columns() []string { return r.columns }
+
+func (r *csvReader) Total() int64 { return -1 }
+
+func (r *csvReader) Next(n int) ([]Row, error) {
+	rows := make([]Row, 0, n)
+	for len(rows) < n {
+		record, err := r.r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(Row, len(r.columns))
+		for i, col := range r.columns {
+			if i < len(record) {
+				row[col] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, io.EOF
+	}
+	return rows, nil
+}
+
+func (r *csvReader) Close() error { return r.f.Close() }
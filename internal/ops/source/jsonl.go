@@ -0,0 +1,70 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// jsonlReader reads one JSON object per line. Numbers are kept as
+// json.Number so int64 primary keys survive without float rounding.
+type jsonlReader struct {
+	f       *os.File
+	scanner *bufio.Scanner
+	line    int
+	columns []string
+	seen    map[string]struct{}
+}
+
+func openJSONL(path string) (Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(f)
+	// vectors with large dims easily exceed the default 64KB token size
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	return &jsonlReader{f: f, scanner: scanner, seen: map[string]struct{}{}}, nil
+}
+
+func (r *jsonlReader) Columns() []string { return r.columns }
+
+func (r *jsonlReader) Total() int64 { return -1 }
+
+func (r *jsonlReader) schemaless() {}
+
+func (r *jsonlReader) Next(n int) ([]Row, error) {
+	rows := make([]Row, 0, n)
+	for len(rows) < n && r.scanner.Scan() {
+		r.line++
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.UseNumber()
+		row := Row{}
+		if err := dec.Decode(&row); err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		for k := range row {
+			if _, ok := r.seen[k]; !ok {
+				r.seen[k] = struct{}{}
+				r.columns = append(r.columns, k)
+			}
+		}
+		rows = append(rows, row)
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, io.EOF
+	}
+	return rows, nil
+}
+
+func (r *jsonlReader) Close() error { return r.f.Close() }
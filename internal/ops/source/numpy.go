@@ -0,0 +1,324 @@
+package source
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// numpyReader reads one or more .npy files in lockstep; each file is one
+// column named after its base name, e.g. `vector.npy` feeds column `vector`.
+type numpyReader struct {
+	columns []string
+	arrays  []*npyArray
+	total   int64
+	read    int64
+}
+
+func openNumpy(path string) (Reader, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.npy"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no .npy file found in %s", path)
+		}
+	}
+
+	r := &numpyReader{total: -1}
+	for _, f := range files {
+		arr, err := openNpyArray(f)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		if r.total >= 0 && arr.rows != r.total {
+			arr.Close()
+			r.Close()
+			return nil, fmt.Errorf("%s has %d rows, other files have %d", f, arr.rows, r.total)
+		}
+		r.total = arr.rows
+		r.columns = append(r.columns, strings.TrimSuffix(filepath.Base(f), filepath.Ext(f)))
+		r.arrays = append(r.arrays, arr)
+	}
+	return r, nil
+}
+
+func (r *numpyReader) Columns() []string { return r.columns }
+
+func (r *numpyReader) Total() int64 { return r.total }
+
+func (r *numpyReader) Next(n int) ([]Row, error) {
+	if r.read >= r.total {
+		return nil, io.EOF
+	}
+	cnt := int(min(int64(n), r.total-r.read))
+	rows := make([]Row, cnt)
+	for i := range rows {
+		rows[i] = make(Row, len(r.columns))
+	}
+	for c, arr := range r.arrays {
+		for i := 0; i < cnt; i++ {
+			v, err := arr.next()
+			if err != nil {
+				return nil, fmt.Errorf("column %s row %d: %w", r.columns[c], r.read+int64(i), err)
+			}
+			rows[i][r.columns[c]] = v
+		}
+	}
+	r.read += int64(cnt)
+	return rows, nil
+}
+
+func (r *numpyReader) Close() error {
+	var firstErr error
+	for _, arr := range r.arrays {
+		if err := arr.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+var (
+	npyMagic      = []byte("\x93NUMPY")
+	npyDescrExpr  = regexp.MustCompile(`'descr'\s*:\s*'([^']+)'`)
+	npyFortExpr   = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShapeExpr  = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+	npyDescrParts = regexp.MustCompile(`^([<>|=])([a-zA-Z])(\d+)$`)
+)
+
+// npyArray is a C-ordered .npy file read row by row.
+type npyArray struct {
+	f     *os.File
+	r     *bufio.Reader
+	order binary.ByteOrder
+	kind  byte // numpy type character: f, i, u, b, U, S
+	size  int  // item size in bytes
+	rows  int64
+	width int // elements per row, 0 for 1-d arrays
+	buf   []byte
+}
+
+func openNpyArray(path string) (*npyArray, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	arr, err := parseNpyHeader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	arr.f = f
+	return arr, nil
+}
+
+func parseNpyHeader(f *os.File) (*npyArray, error) {
+	r := bufio.NewReaderSize(f, 1024*1024)
+	prefix := make([]byte, 8)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, fmt.Errorf("read npy magic: %w", err)
+	}
+	if string(prefix[:6]) != string(npyMagic) {
+		return nil, fmt.Errorf("not a npy file")
+	}
+	var headerLen int
+	switch prefix[6] {
+	case 1:
+		lb := make([]byte, 2)
+		if _, err := io.ReadFull(r, lb); err != nil {
+			return nil, err
+		}
+		headerLen = int(binary.LittleEndian.Uint16(lb))
+	case 2, 3:
+		lb := make([]byte, 4)
+		if _, err := io.ReadFull(r, lb); err != nil {
+			return nil, err
+		}
+		headerLen = int(binary.LittleEndian.Uint32(lb))
+	default:
+		return nil, fmt.Errorf("unsupported npy version %d.%d", prefix[6], prefix[7])
+	}
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("read npy header: %w", err)
+	}
+
+	arr := &npyArray{r: r}
+	if err := arr.parseHeader(string(header)); err != nil {
+		return nil, err
+	}
+	return arr, nil
+}
+
+func (a *npyArray) parseHeader(header string) error {
+	m := npyDescrExpr.FindStringSubmatch(header)
+	if m == nil {
+		return fmt.Errorf("npy header missing descr: %s", header)
+	}
+	parts := npyDescrParts.FindStringSubmatch(m[1])
+	if parts == nil {
+		return fmt.Errorf("unsupported npy dtype %q", m[1])
+	}
+	a.order = binary.LittleEndian
+	if parts[1] == ">" {
+		a.order = binary.BigEndian
+	}
+	a.kind = parts[2][0]
+	a.size, _ = strconv.Atoi(parts[3])
+	switch a.kind {
+	case 'U':
+		// unicode width is counted in characters of 4 bytes each
+		a.size *= 4
+	case 'f', 'i', 'u', 'b', 'S':
+	default:
+		return fmt.Errorf("unsupported npy dtype %q", m[1])
+	}
+
+	if m := npyFortExpr.FindStringSubmatch(header); m != nil && m[1] == "True" {
+		return fmt.Errorf("fortran ordered npy arrays are not supported")
+	}
+
+	m = npyShapeExpr.FindStringSubmatch(header)
+	if m == nil {
+		return fmt.Errorf("npy header missing shape: %s", header)
+	}
+	var dims []int64
+	for _, d := range strings.Split(m[1], ",") {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		v, err := strconv.ParseInt(d, 10, 64)
+		if err != nil {
+			return fmt.Errorf("bad npy shape %q", m[1])
+		}
+		dims = append(dims, v)
+	}
+	switch len(dims) {
+	case 1:
+		a.rows = dims[0]
+	case 2:
+		a.rows, a.width = dims[0], int(dims[1])
+	default:
+		return fmt.Errorf("npy arrays must be 1-d or 2-d, got shape (%s)", m[1])
+	}
+
+	rowBytes := a.size * max(a.width, 1)
+	a.buf = make([]byte, rowBytes)
+	return nil
+}
+
+// next decodes the following row: a scalar for 1-d arrays, a typed slice
+// for 2-d arrays.
+func (a *npyArray) next() (any, error) {
+	if _, err := io.ReadFull(a.r, a.buf); err != nil {
+		return nil, err
+	}
+	if a.width == 0 {
+		return a.decode(a.buf)
+	}
+	switch {
+	case a.kind == 'f' && a.size == 4:
+		out := make([]float32, a.width)
+		for i := range out {
+			out[i] = math.Float32frombits(a.order.Uint32(a.buf[i*4:]))
+		}
+		return out, nil
+	case a.kind == 'u' && a.size == 1:
+		// uint8 rows are packed binary vectors
+		out := make([]byte, a.width)
+		copy(out, a.buf)
+		return out, nil
+	case a.kind == 'i' && a.size == 1:
+		out := make([]int8, a.width)
+		for i := range out {
+			out[i] = int8(a.buf[i])
+		}
+		return out, nil
+	}
+	out := make([]any, a.width)
+	for i := range out {
+		v, err := a.decode(a.buf[i*a.size : (i+1)*a.size])
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// decode converts a single element.
+func (a *npyArray) decode(b []byte) (any, error) {
+	switch a.kind {
+	case 'b':
+		return b[0] != 0, nil
+	case 'i':
+		switch a.size {
+		case 1:
+			return int8(b[0]), nil
+		case 2:
+			return int16(a.order.Uint16(b)), nil
+		case 4:
+			return int32(a.order.Uint32(b)), nil
+		case 8:
+			return int64(a.order.Uint64(b)), nil
+		}
+	case 'u':
+		switch a.size {
+		case 1:
+			return b[0], nil
+		case 2:
+			return a.order.Uint16(b), nil
+		case 4:
+			return a.order.Uint32(b), nil
+		case 8:
+			return a.order.Uint64(b), nil
+		}
+	case 'f':
+		switch a.size {
+		case 4:
+			return math.Float32frombits(a.order.Uint32(b)), nil
+		case 8:
+			return math.Float64frombits(a.order.Uint64(b)), nil
+		}
+	case 'S':
+		return strings.TrimRight(string(b), "\x00"), nil
+	case 'U':
+		// fixed width UTF-32 code points, zero padded
+		var sb strings.Builder
+		for i := 0; i+4 <= len(b); i += 4 {
+			cp := a.order.Uint32(b[i:])
+			if cp == 0 {
+				break
+			}
+			if !utf8.ValidRune(rune(cp)) {
+				return nil, fmt.Errorf("invalid unicode code point %d", cp)
+			}
+			sb.WriteRune(rune(cp))
+		}
+		return sb.String(), nil
+	}
+	return nil, fmt.Errorf("unsupported npy element %c%d", a.kind, a.size)
+}
+
+func (a *npyArray) Close() error {
+	return a.f.Close()
+}
@@ -0,0 +1,140 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/apache/arrow/go/v17/arrow"
+	"github.com/apache/arrow/go/v17/arrow/array"
+	"github.com/apache/arrow/go/v17/arrow/memory"
+	"github.com/apache/arrow/go/v17/parquet/file"
+	"github.com/apache/arrow/go/v17/parquet/pqarrow"
+)
+
+// parquetReader walks arrow record batches and converts cells on demand,
+// so only one batch is materialized at a time.
+type parquetReader struct {
+	f       *os.File
+	pq      *file.Reader
+	rr      pqarrow.RecordReader
+	columns []string
+	total   int64
+
+	rec arrow.Record
+	pos int64
+}
+
+func openParquet(path string) (Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	pq, err := file.NewParquetReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open parquet %s: %w", path, err)
+	}
+	fr, err := pqarrow.NewFileReader(pq, pqarrow.ArrowReadProperties{BatchSize: 1024}, memory.DefaultAllocator)
+	if err != nil {
+		pq.Close()
+		return nil, err
+	}
+	rr, err := fr.GetRecordReader(context.Background(), nil, nil)
+	if err != nil {
+		pq.Close()
+		return nil, err
+	}
+	schema := rr.Schema()
+	columns := make([]string, 0, schema.NumFields())
+	for _, field := range schema.Fields() {
+		columns = append(columns, field.Name)
+	}
+	return &parquetReader{f: f, pq: pq, rr: rr, columns: columns, total: pq.NumRows()}, nil
+}
+
+func (r *parquetReader) Columns() []string { return r.columns }
+
+func (r *parquetReader) Total() int64 { return r.total }
+
+func (r *parquetReader) Next(n int) ([]Row, error) {
+	rows := make([]Row, 0, n)
+	for len(rows) < n {
+		if r.rec == nil || r.pos >= r.rec.NumRows() {
+			if !r.rr.Next() {
+				if err := r.rr.Err(); err != nil && err != io.EOF {
+					return nil, err
+				}
+				break
+			}
+			r.rec = r.rr.Record()
+			r.pos = 0
+		}
+		row := make(Row, len(r.columns))
+		for c := 0; c < int(r.rec.NumCols()); c++ {
+			row[r.rec.ColumnName(c)] = arrowValue(r.rec.Column(c), int(r.pos))
+		}
+		rows = append(rows, row)
+		r.pos++
+	}
+	if len(rows) == 0 {
+		return nil, io.EOF
+	}
+	return rows, nil
+}
+
+func (r *parquetReader) Close() error {
+	r.rr.Release()
+	r.pq.Close()
+	return r.f.Close()
+}
+
+// arrowValue converts one arrow cell into a plain Go value. List columns
+// (the usual encoding of vectors) become []any of their element values.
+func arrowValue(arr arrow.Array, i int) any {
+	if arr.IsNull(i) {
+		return nil
+	}
+	switch a := arr.(type) {
+	case *array.Int8:
+		return a.Value(i)
+	case *array.Int16:
+		return a.Value(i)
+	case *array.Int32:
+		return a.Value(i)
+	case *array.Int64:
+		return a.Value(i)
+	case *array.Uint8:
+		return a.Value(i)
+	case *array.Uint16:
+		return a.Value(i)
+	case *array.Uint32:
+		return a.Value(i)
+	case *array.Uint64:
+		return a.Value(i)
+	case *array.Float32:
+		return a.Value(i)
+	case *array.Float64:
+		return a.Value(i)
+	case *array.Boolean:
+		return a.Value(i)
+	case *array.String:
+		return a.Value(i)
+	case *array.LargeString:
+		return a.Value(i)
+	case *array.Binary:
+		return a.Value(i)
+	case *array.FixedSizeBinary:
+		return a.Value(i)
+	case array.ListLike:
+		start, end := a.ValueOffsets(i)
+		values := a.ListValues()
+		out := make([]any, 0, end-start)
+		for j := start; j < end; j++ {
+			out = append(out, arrowValue(values, int(j)))
+		}
+		return out
+	}
+	return arr.GetOneForMarshal(i)
+}
@@ -0,0 +1,95 @@
+// Package source streams rows out of local data files (Parquet, JSON Lines,
+// CSV and NumPy) so the `import_file` op can replay an existing dataset into
+// a collection. Readers are row oriented and schema agnostic: values are
+// surfaced as plain Go values keyed by source column name, and the op maps
+// and converts them against the collection schema.
+package source
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Row is one record keyed by source column name.
+type Row map[string]any
+
+// Reader yields rows in file order.
+type Reader interface {
+	// Columns lists the column names discovered in the source.
+	Columns() []string
+	// Next returns up to n rows. It returns io.EOF once the source is
+	// exhausted and no rows are left.
+	Next(n int) ([]Row, error)
+	// Total returns the number of rows in the source, or -1 when it cannot
+	// be known without reading the whole file.
+	Total() int64
+	Close() error
+}
+
+// schemaless is implemented by readers without a fixed column set, whose
+// rows may carry any subset of the columns.
+type schemaless interface {
+	schemaless()
+}
+
+// IsSchemaless reports whether Columns of r only lists the columns seen so
+// far, so columns missing in the first rows may still show up later.
+func IsSchemaless(r Reader) bool {
+	_, ok := r.(schemaless)
+	return ok
+}
+
+const (
+	FormatParquet = "parquet"
+	FormatJSONL   = "jsonl"
+	FormatCSV     = "csv"
+	FormatNumpy   = "npy"
+)
+
+// Open opens path with the reader for format. An empty format is detected
+// from the file extension; a directory is treated as a set of `<column>.npy`
+// files, which is the layout Milvus bulk insert uses for NumPy data.
+func Open(path, format string) (Reader, error) {
+	if format == "" {
+		detected, err := DetectFormat(path)
+		if err != nil {
+			return nil, err
+		}
+		format = detected
+	}
+	switch strings.ToLower(format) {
+	case FormatParquet:
+		return openParquet(path)
+	case FormatJSONL, "json", "ndjson":
+		return openJSONL(path)
+	case FormatCSV:
+		return openCSV(path)
+	case FormatNumpy, "numpy":
+		return openNumpy(path)
+	}
+	return nil, fmt.Errorf("unsupported source format %q", format)
+}
+
+// DetectFormat guesses the source format from the path.
+func DetectFormat(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return FormatNumpy, nil
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".parquet":
+		return FormatParquet, nil
+	case ".jsonl", ".json", ".ndjson":
+		return FormatJSONL, nil
+	case ".csv":
+		return FormatCSV, nil
+	case ".npy":
+		return FormatNumpy, nil
+	}
+	return "", fmt.Errorf("cannot detect format of %q, please specify it explicitly", path)
+}
@@ -0,0 +1,169 @@
+package source
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apache/arrow/go/v17/arrow"
+	"github.com/apache/arrow/go/v17/arrow/array"
+	"github.com/apache/arrow/go/v17/arrow/memory"
+	"github.com/apache/arrow/go/v17/parquet"
+	"github.com/apache/arrow/go/v17/parquet/pqarrow"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, r Reader, batch int) []Row {
+	t.Helper()
+	var out []Row
+	for {
+		rows, err := r.Next(batch)
+		if err == io.EOF {
+			return out
+		}
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(rows), batch)
+		out = append(out, rows...)
+	}
+}
+
+func TestJSONLReader(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rows.jsonl")
+	content := "{\"id\": 9007199254740993, \"vec\": [0.5, 1]}\n\n{\"id\": 2, \"name\": \"b\"}\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	r, err := Open(path, "")
+	require.NoError(t, err)
+	defer r.Close()
+
+	rows := readAll(t, r, 1)
+	require.Len(t, rows, 2)
+	require.Equal(t, json.Number("9007199254740993"), rows[0]["id"])
+	require.Equal(t, "b", rows[1]["name"])
+	require.ElementsMatch(t, []string{"id", "vec", "name"}, r.Columns())
+	require.EqualValues(t, -1, r.Total())
+	require.True(t, IsSchemaless(r))
+}
+
+func TestCSVReader(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rows.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id,vec\n1,\"[1,2]\"\n2,\"[3,4]\"\n3,\"[5,6]\"\n"), 0o644))
+
+	r, err := Open(path, "")
+	require.NoError(t, err)
+	defer r.Close()
+
+	rows := readAll(t, r, 2)
+	require.Len(t, rows, 3)
+	require.Equal(t, []string{"id", "vec"}, r.Columns())
+	require.Equal(t, "3", rows[2]["id"])
+	require.Equal(t, "[5,6]", rows[2]["vec"])
+}
+
+func writeNpy(t *testing.T, path, descr, shape string, data []byte) {
+	t.Helper()
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': %s, }", descr, shape)
+	// pad so that magic + len + header is 64-byte aligned, ending with \n
+	for (10+len(header)+1)%64 != 0 {
+		header += " "
+	}
+	header += "\n"
+	buf := append([]byte("\x93NUMPY\x01\x00"), 0, 0)
+	binary.LittleEndian.PutUint16(buf[8:], uint16(len(header)))
+	buf = append(buf, header...)
+	buf = append(buf, data...)
+	require.NoError(t, os.WriteFile(path, buf, 0o644))
+}
+
+func TestNumpyReader(t *testing.T) {
+	dir := t.TempDir()
+
+	ids := make([]byte, 3*8)
+	for i := 0; i < 3; i++ {
+		binary.LittleEndian.PutUint64(ids[i*8:], uint64(100+i))
+	}
+	writeNpy(t, filepath.Join(dir, "id.npy"), "<i8", "(3,)", ids)
+
+	vecs := make([]byte, 3*2*4)
+	for i := 0; i < 6; i++ {
+		binary.LittleEndian.PutUint32(vecs[i*4:], math.Float32bits(float32(i)))
+	}
+	writeNpy(t, filepath.Join(dir, "vec.npy"), "<f4", "(3, 2)", vecs)
+
+	names := make([]byte, 3*2*4)
+	for i, s := range []string{"a", "bc", "d"} {
+		for j, c := range s {
+			binary.LittleEndian.PutUint32(names[(i*2+j)*4:], uint32(c))
+		}
+	}
+	writeNpy(t, filepath.Join(dir, "name.npy"), "<U2", "(3,)", names)
+
+	r, err := Open(dir, "")
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.Equal(t, []string{"id", "name", "vec"}, r.Columns())
+	require.EqualValues(t, 3, r.Total())
+	rows := readAll(t, r, 2)
+	require.Len(t, rows, 3)
+	require.Equal(t, int64(102), rows[2]["id"])
+	require.Equal(t, []float32{4, 5}, rows[2]["vec"])
+	require.Equal(t, "bc", rows[1]["name"])
+}
+
+func TestDetectFormat(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.txt")
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+	_, err := DetectFormat(path)
+	require.Error(t, err)
+
+	format, err := DetectFormat(dir)
+	require.NoError(t, err)
+	require.Equal(t, FormatNumpy, format)
+}
+
+func TestParquetReader(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rows.parquet")
+
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "vec", Type: arrow.ListOf(arrow.PrimitiveTypes.Float32)},
+	}, nil)
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	b.Field(0).(*array.Int64Builder).AppendValues([]int64{1, 2}, nil)
+	lb := b.Field(1).(*array.ListBuilder)
+	vb := lb.ValueBuilder().(*array.Float32Builder)
+	lb.Append(true)
+	vb.AppendValues([]float32{0.1, 0.2}, nil)
+	lb.Append(true)
+	vb.AppendValues([]float32{0.3, 0.4}, nil)
+	rec := b.NewRecord()
+	defer rec.Release()
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w, err := pqarrow.NewFileWriter(schema, f, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps())
+	require.NoError(t, err)
+	require.NoError(t, w.Write(rec))
+	require.NoError(t, w.Close())
+
+	r, err := Open(path, "")
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.EqualValues(t, 2, r.Total())
+	rows := readAll(t, r, 10)
+	require.Len(t, rows, 2)
+	require.Equal(t, int64(2), rows[1]["id"])
+	require.Equal(t, []any{float32(0.3), float32(0.4)}, rows[1]["vec"])
+}
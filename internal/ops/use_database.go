@@ -0,0 +1,30 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+)
+
+type UseDatabaseParams struct {
+	DB string `yaml:"db"`
+}
+
+func (p *UseDatabaseParams) Execute(ctx context.Context, rc *RunContext) (any, error) {
+	if rc.Client == nil {
+		return nil, errNoClient("use_database")
+	}
+	if p.DB == "" {
+		return nil, fmt.Errorf("use_database: `db` required")
+	}
+	if err := rc.Client.UseDatabase(ctx, milvusclient.NewUseDatabaseOption(p.DB)); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(rc.Out(), "using database %q\n", p.DB)
+	return map[string]any{"db": p.DB}, nil
+}
+
+func init() {
+	Register("use_database", func() Op { return &UseDatabaseParams{} })
+}
@@ -0,0 +1,202 @@
+// Package protodiff computes field level differences between two protobuf messages of the same type.
+package protodiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type ChangeKind string
+
+const (
+	Added    ChangeKind = "added"
+	Removed  ChangeKind = "removed"
+	Modified ChangeKind = "modified"
+)
+
+// Change is one field difference, Path is the dotted field path with list indexes and map keys in brackets.
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+	Path string     `json:"path,omitempty"`
+	Old  string     `json:"old,omitempty"`
+	New  string     `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s: %s", c.Path, c.New)
+	case Removed:
+		return fmt.Sprintf("- %s: %s", c.Path, c.Old)
+	default:
+		return fmt.Sprintf("~ %s: %s => %s", c.Path, c.Old, c.New)
+	}
+}
+
+// Diff returns the changes from a to b, either may be nil for a whole message added or removed.
+func Diff(a, b proto.Message) []Change {
+	d := &differ{}
+	switch {
+	case a == nil && b == nil:
+	case a == nil:
+		d.add(Added, "", nil, b.ProtoReflect())
+	case b == nil:
+		d.add(Removed, "", a.ProtoReflect(), nil)
+	default:
+		if a.ProtoReflect().Descriptor().FullName() != b.ProtoReflect().Descriptor().FullName() {
+			d.changes = append(d.changes, Change{
+				Kind: Modified,
+				Old:  string(a.ProtoReflect().Descriptor().FullName()),
+				New:  string(b.ProtoReflect().Descriptor().FullName()),
+			})
+			break
+		}
+		d.message("", a.ProtoReflect(), b.ProtoReflect())
+	}
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) message(path string, a, b protoreflect.Message) {
+	fields := a.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		fieldPath := join(path, string(fd.Name()))
+		switch {
+		case fd.IsList():
+			d.list(fieldPath, fd, a.Get(fd).List(), b.Get(fd).List())
+		case fd.IsMap():
+			d.mapField(fieldPath, fd, a.Get(fd).Map(), b.Get(fd).Map())
+		case fd.Message() != nil:
+			hasA, hasB := a.Has(fd), b.Has(fd)
+			switch {
+			case !hasA && !hasB:
+			case !hasA:
+				d.add(Added, fieldPath, nil, b.Get(fd).Message())
+			case !hasB:
+				d.add(Removed, fieldPath, a.Get(fd).Message(), nil)
+			default:
+				d.message(fieldPath, a.Get(fd).Message(), b.Get(fd).Message())
+			}
+		default:
+			d.scalar(fieldPath, fd, a.Get(fd), b.Get(fd))
+		}
+	}
+}
+
+func (d *differ) list(path string, fd protoreflect.FieldDescriptor, a, b protoreflect.List) {
+	for i := 0; i < a.Len() || i < b.Len(); i++ {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= a.Len():
+			d.changes = append(d.changes, Change{Kind: Added, Path: elemPath, New: formatValue(fd, b.Get(i))})
+		case i >= b.Len():
+			d.changes = append(d.changes, Change{Kind: Removed, Path: elemPath, Old: formatValue(fd, a.Get(i))})
+		case fd.Message() != nil:
+			d.message(elemPath, a.Get(i).Message(), b.Get(i).Message())
+		default:
+			d.scalar(elemPath, fd, a.Get(i), b.Get(i))
+		}
+	}
+}
+
+func (d *differ) mapField(path string, fd protoreflect.FieldDescriptor, a, b protoreflect.Map) {
+	keys := make(map[string]protoreflect.MapKey)
+	collect := func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys[k.String()] = k
+		return true
+	}
+	a.Range(collect)
+	b.Range(collect)
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	valueFd := fd.MapValue()
+	for _, k := range sorted {
+		key := keys[k]
+		entryPath := fmt.Sprintf("%s[%s]", path, k)
+		hasA, hasB := a.Has(key), b.Has(key)
+		switch {
+		case !hasA:
+			d.changes = append(d.changes, Change{Kind: Added, Path: entryPath, New: formatValue(valueFd, b.Get(key))})
+		case !hasB:
+			d.changes = append(d.changes, Change{Kind: Removed, Path: entryPath, Old: formatValue(valueFd, a.Get(key))})
+		case valueFd.Message() != nil:
+			d.message(entryPath, a.Get(key).Message(), b.Get(key).Message())
+		default:
+			d.scalar(entryPath, valueFd, a.Get(key), b.Get(key))
+		}
+	}
+}
+
+func (d *differ) scalar(path string, fd protoreflect.FieldDescriptor, a, b protoreflect.Value) {
+	if fd.Kind() == protoreflect.BytesKind {
+		if bytes.Equal(a.Bytes(), b.Bytes()) {
+			return
+		}
+	} else if a.Interface() == b.Interface() {
+		return
+	}
+	d.changes = append(d.changes, Change{Kind: Modified, Path: path, Old: formatValue(fd, a), New: formatValue(fd, b)})
+}
+
+func (d *differ) add(kind ChangeKind, path string, a, b protoreflect.Message) {
+	change := Change{Kind: kind, Path: path}
+	if a != nil {
+		change.Old = formatMessage(a)
+	}
+	if b != nil {
+		change.New = formatMessage(b)
+	}
+	d.changes = append(d.changes, change)
+}
+
+func formatValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch {
+	case fd.Message() != nil:
+		return formatMessage(v.Message())
+	case fd.Kind() == protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return fmt.Sprintf("%d", v.Enum())
+	case fd.Kind() == protoreflect.StringKind:
+		return fmt.Sprintf("%q", v.String())
+	case fd.Kind() == protoreflect.BytesKind:
+		return fmt.Sprintf("%x", v.Bytes())
+	default:
+		return v.String()
+	}
+}
+
+func formatMessage(m protoreflect.Message) string {
+	bs, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m.Interface())
+	if err != nil {
+		return fmt.Sprintf("<%s>", m.Descriptor().FullName())
+	}
+	// protojson randomizes whitespace, compact it for stable output.
+	buf := &bytes.Buffer{}
+	if err := json.Compact(buf, bs); err != nil {
+		return string(bs)
+	}
+	return buf.String()
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
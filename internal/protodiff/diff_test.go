@@ -0,0 +1,37 @@
+package protodiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestDiff(t *testing.T) {
+	a := &descriptorpb.DescriptorProto{
+		Name: proto.String("Segment"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{Name: proto.String("id"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()},
+			{Name: proto.String("state"), Number: proto.Int32(2)},
+		},
+		ReservedName: []string{"a"},
+	}
+	b := proto.Clone(a).(*descriptorpb.DescriptorProto)
+	b.Field[0].Type = descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	b.Field = b.Field[:1]
+	b.ReservedName = append(b.ReservedName, "b")
+	b.Options = &descriptorpb.MessageOptions{Deprecated: proto.Bool(true)}
+
+	changes := Diff(a, b)
+	assert.Equal(t, []Change{
+		{Kind: Modified, Path: "field[0].type", Old: "TYPE_INT64", New: "TYPE_STRING"},
+		{Kind: Removed, Path: "field[1]", Old: `{"name":"state","number":2}`},
+		{Kind: Added, Path: "options", New: `{"deprecated":true}`},
+		{Kind: Added, Path: "reserved_name[1]", New: `"b"`},
+	}, changes)
+
+	assert.Empty(t, Diff(a, proto.Clone(a)))
+	assert.Len(t, Diff(nil, a), 1)
+	assert.Equal(t, Removed, Diff(a, nil)[0].Kind)
+}
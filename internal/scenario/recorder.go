@@ -0,0 +1,116 @@
+package scenario
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/milvus-io/birdwatcher/internal/ops"
+)
+
+// Recorder captures ops executed outside of a scenario (e.g. interactive
+// milvusctl commands) and turns them into a replayable Scenario. The seed
+// is the one the caller used for RunContext.Rand while recording, so
+// generator output is identical when the scenario is replayed.
+type Recorder struct {
+	mu    sync.Mutex
+	name  string
+	seed  int64
+	steps []Step
+}
+
+// NewRecorder returns a recorder for a session whose RunContext.Rand was
+// seeded with seed.
+func NewRecorder(name string, seed int64) *Recorder {
+	return &Recorder{name: name, seed: seed}
+}
+
+// Seed returns the seed the recorded session runs with.
+func (r *Recorder) Seed() int64 { return r.seed }
+
+// Len returns the number of recorded steps.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.steps)
+}
+
+// Record appends op as a step. Ops that failed are kept with
+// `on_error: continue` so the replay consumes the rng the same way the
+// original session did.
+func (r *Recorder) Record(op ops.Op, execErr error) error {
+	name, ok := ops.NameOf(op)
+	if !ok {
+		return fmt.Errorf("op %T is not registered", op)
+	}
+	var params yaml.Node
+	if err := params.Encode(op); err != nil {
+		return fmt.Errorf("encode %s params: %w", name, err)
+	}
+	redactSecrets(&params)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	step := Step{
+		Name:   fmt.Sprintf("step_%d_%s", len(r.steps), name),
+		Op:     name,
+		Params: params,
+	}
+	if execErr != nil {
+		step.OnError = "continue"
+	}
+	r.steps = append(r.steps, step)
+	return nil
+}
+
+// Scenario returns the recorded session as a Scenario document.
+func (r *Recorder) Scenario() *Scenario {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seed := r.seed
+	steps := make([]Step, len(r.steps))
+	copy(steps, r.steps)
+	return &Scenario{
+		Name:        r.name,
+		Description: "recorded interactive session",
+		Seed:        &seed,
+		Steps:       steps,
+	}
+}
+
+// Save writes the recorded scenario as YAML to path.
+func (r *Recorder) Save(path string) error {
+	s := r.Scenario()
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("no op recorded")
+	}
+	bs, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshal scenario: %w", err)
+	}
+	return os.WriteFile(path, bs, 0o644)
+}
+
+// redactSecrets replaces non-empty credential params with an ${env.*}
+// reference, recorded files are meant to be handed to other people.
+func redactSecrets(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			if v.Kind == yaml.ScalarNode && v.Value != "" {
+				switch strings.ToLower(strings.ReplaceAll(k.Value, "_", "")) {
+				case "apikey":
+					v.Value, v.Tag, v.Style = "${env.MILVUS_API_KEY}", "!!str", 0
+				case "password":
+					v.Value, v.Tag, v.Style = "${env.MILVUS_PASSWORD}", "!!str", 0
+				}
+			}
+		}
+	}
+	for _, c := range node.Content {
+		redactSecrets(c)
+	}
+}
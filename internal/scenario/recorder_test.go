@@ -0,0 +1,69 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/birdwatcher/internal/ops"
+	"github.com/milvus-io/birdwatcher/internal/ops/gen"
+)
+
+type recordedOp struct {
+	Collection string           `yaml:"collection"`
+	APIKey     string           `yaml:"apiKey,omitempty"`
+	Vector     *gen.Spec        `yaml:"vector,omitempty"`
+	Columns    []ops.ColumnSpec `yaml:"columns,omitempty"`
+}
+
+func (p *recordedOp) Execute(ctx context.Context, rc *ops.RunContext) (any, error) {
+	return nil, nil
+}
+
+func init() {
+	ops.Register("test_recorded_op", func() ops.Op { return &recordedOp{} })
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	rec := NewRecorder("session", 7)
+	op := &recordedOp{
+		Collection: "c1",
+		APIKey:     "secret",
+		Vector:     &gen.Spec{Type: "random_float_vector", Args: map[string]any{"dim": int64(4), "count": int64(2)}},
+		Columns:    []ops.ColumnSpec{{Field: "id", Data: []int64{1, 2}}},
+	}
+	require.NoError(t, rec.Record(op, nil))
+	require.NoError(t, rec.Record(&recordedOp{Collection: "c2"}, errors.New("boom")))
+	require.Equal(t, 2, rec.Len())
+
+	path := filepath.Join(t.TempDir(), "recorded.yaml")
+	require.NoError(t, rec.Save(path))
+
+	sc, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "session", sc.Name)
+	require.NotNil(t, sc.Seed)
+	require.EqualValues(t, 7, *sc.Seed)
+	require.Len(t, sc.Steps, 2)
+	require.Equal(t, "test_recorded_op", sc.Steps[0].Op)
+	require.Empty(t, sc.Steps[0].OnError)
+	require.Equal(t, "continue", sc.Steps[1].OnError)
+
+	decoded := &recordedOp{}
+	require.NoError(t, sc.Steps[0].Params.Decode(decoded))
+	require.Equal(t, "c1", decoded.Collection)
+	require.Equal(t, "${env.MILVUS_API_KEY}", decoded.APIKey)
+	require.Equal(t, "random_float_vector", decoded.Vector.Type)
+	require.EqualValues(t, 4, decoded.Vector.Args["dim"])
+	require.Len(t, decoded.Columns, 1)
+	require.Equal(t, "id", decoded.Columns[0].Field)
+}
+
+func TestRecorderRejectsUnknownOp(t *testing.T) {
+	rec := NewRecorder("session", 1)
+	require.Error(t, rec.Record(nil, nil))
+	require.Error(t, rec.Save(filepath.Join(t.TempDir(), "empty.yaml")))
+}
@@ -0,0 +1,242 @@
+package scenario
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Step outcomes recorded in a Report.
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped" // failed, but on_error let the run continue
+	StatusNotRun  = "not_run" // never reached because an earlier step stopped the run
+)
+
+// Report is the structured outcome of one scenario run, meant for CI
+// consumption next to the human output written to RunContext.Out().
+type Report struct {
+	Scenario  string        `json:"scenario"`
+	Seed      *int64        `json:"seed,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration_ns"`
+	Passed    int           `json:"passed"`
+	Failed    int           `json:"failed"`
+	Skipped   int           `json:"skipped"`
+	NotRun    int           `json:"not_run"`
+	Steps     []StepReport  `json:"steps"`
+}
+
+// StepReport describes a single step execution.
+type StepReport struct {
+	Name     string        `json:"name"`
+	Op       string        `json:"op"`
+	Status   string        `json:"status"`
+	Duration time.Duration `json:"duration_ns"`
+	Result   string        `json:"result,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+func newReport(s *Scenario) *Report {
+	return &Report{
+		Scenario:  s.Name,
+		StartedAt: time.Now(),
+	}
+}
+
+func (r *Report) add(step StepReport) {
+	switch step.Status {
+	case StatusPassed:
+		r.Passed++
+	case StatusFailed:
+		r.Failed++
+	case StatusSkipped:
+		r.Skipped++
+	case StatusNotRun:
+		r.NotRun++
+	}
+	r.Steps = append(r.Steps, step)
+}
+
+// finish marks steps after index `from` as not run and freezes the total
+// duration.
+func (r *Report) finish(s *Scenario, from int) {
+	for _, step := range s.Steps[from:] {
+		r.add(StepReport{Name: step.Name, Op: step.Op, Status: StatusNotRun})
+	}
+	r.Duration = time.Since(r.StartedAt)
+}
+
+// Success reports whether no step failed the run.
+func (r *Report) Success() bool {
+	return r.Failed == 0
+}
+
+// maxResultSummary caps the per step result text, describe-like ops return
+// whole schemas which are not useful in a CI summary.
+const maxResultSummary = 512
+
+func summarizeResult(res any) string {
+	if res == nil {
+		return ""
+	}
+	bs, err := json.Marshal(res)
+	if err != nil {
+		return fmt.Sprintf("%v", res)
+	}
+	s := string(bs)
+	if len(s) > maxResultSummary {
+		s = s[:maxResultSummary] + "..."
+	}
+	return s
+}
+
+// Report output formats.
+const (
+	ReportFormatJSON  = "json"
+	ReportFormatJUnit = "junit"
+)
+
+// WriteFile writes the report to path. An empty format is inferred from
+// the extension: `.xml` means JUnit, anything else JSON.
+func (r *Report) WriteFile(path, format string) error {
+	if format == "" {
+		format = ReportFormatJSON
+		if strings.EqualFold(filepath.Ext(path), ".xml") {
+			format = ReportFormatJUnit
+		}
+	}
+	var write func(io.Writer) error
+	switch strings.ToLower(format) {
+	case ReportFormatJSON:
+		write = r.WriteJSON
+	case ReportFormatJUnit, "xml":
+		write = r.WriteJUnit
+	default:
+		return fmt.Errorf("unknown report format %q (json or junit)", format)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create report: %w", err)
+	}
+	defer f.Close()
+	if err := write(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as JUnit XML: one testsuite per scenario,
+// one testcase per step. Steps skipped through on_error and steps never
+// reached are both reported as <skipped>.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	name := r.Scenario
+	if name == "" {
+		name = "scenario"
+	}
+	suite := junitTestSuite{
+		Name:      name,
+		Tests:     len(r.Steps),
+		Failures:  r.Failed,
+		Skipped:   r.Skipped + r.NotRun,
+		Time:      junitSeconds(r.Duration),
+		Timestamp: r.StartedAt.Format(time.RFC3339),
+	}
+	if r.Seed != nil {
+		suite.Properties = append(suite.Properties, junitProperty{Name: "seed", Value: fmt.Sprint(*r.Seed)})
+	}
+	for _, step := range r.Steps {
+		tc := junitTestCase{
+			Name:      step.Name,
+			ClassName: name + "." + step.Op,
+			Time:      junitSeconds(step.Duration),
+			SystemOut: step.Result,
+		}
+		switch step.Status {
+		case StatusFailed:
+			tc.Failure = &junitMessage{Message: step.Error, Type: step.Op, Body: step.Error}
+		case StatusSkipped:
+			tc.Skipped = &junitMessage{Message: "error ignored due to on_error: " + step.Error}
+		case StatusNotRun:
+			tc.Skipped = &junitMessage{Message: "not run, scenario stopped at an earlier step"}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	doc := junitTestSuites{
+		Name:     name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
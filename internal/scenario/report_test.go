@@ -0,0 +1,76 @@
+package scenario
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportOutputs(t *testing.T) {
+	s := &Scenario{Name: "upgrade", Steps: []Step{
+		{Name: "create", Op: "create_collection"},
+		{Name: "insert", Op: "insert", OnError: "continue"},
+		{Name: "search", Op: "search"},
+		{Name: "drop", Op: "drop_collection"},
+	}}
+	seed := int64(3)
+	r := newReport(s)
+	r.Seed = &seed
+	r.add(StepReport{Name: "create", Op: "create_collection", Status: StatusPassed, Duration: time.Second, Result: summarizeResult(map[string]any{"name": "c"})})
+	r.add(StepReport{Name: "insert", Op: "insert", Status: StatusSkipped, Error: "boom"})
+	r.add(StepReport{Name: "search", Op: "search", Status: StatusFailed, Error: "no index"})
+	r.finish(s, 3)
+
+	require.False(t, r.Success())
+	require.Equal(t, 1, r.Passed)
+	require.Equal(t, 1, r.Failed)
+	require.Equal(t, 1, r.Skipped)
+	require.Equal(t, 1, r.NotRun)
+	require.Equal(t, `{"name":"c"}`, r.Steps[0].Result)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteJSON(&buf))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Len(t, decoded.Steps, 4)
+	require.Equal(t, StatusNotRun, decoded.Steps[3].Status)
+
+	buf.Reset()
+	require.NoError(t, r.WriteJUnit(&buf))
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	require.Len(t, suites.Suites, 1)
+	suite := suites.Suites[0]
+	require.Equal(t, 4, suite.Tests)
+	require.Equal(t, 1, suite.Failures)
+	require.Equal(t, 2, suite.Skipped)
+	require.Equal(t, "seed", suite.Properties[0].Name)
+	require.NotNil(t, suite.Cases[2].Failure)
+	require.Equal(t, "no index", suite.Cases[2].Failure.Message)
+	require.NotNil(t, suite.Cases[1].Skipped)
+	require.Equal(t, "1.000", suite.Cases[0].Time)
+}
+
+func TestReportWriteFile(t *testing.T) {
+	r := newReport(&Scenario{Name: "upgrade"})
+	dir := t.TempDir()
+
+	// unknown format is rejected before the file is created
+	path := filepath.Join(dir, "report.txt")
+	require.Error(t, r.WriteFile(path, "yaml"))
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+
+	path = filepath.Join(dir, "report.xml")
+	require.NoError(t, r.WriteFile(path, ""))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(data, &suites))
+}
@@ -25,6 +25,14 @@ type Runner struct {
 // `OwnsClient` and closes it at exit. If `rc.Client` is set on entry
 // (e.g. CLI is already connected), the runner does not close it.
 func (r *Runner) Run(ctx context.Context, s *Scenario, rc *ops.RunContext) error {
+	_, err := r.RunWithReport(ctx, s, rc)
+	return err
+}
+
+// RunWithReport is Run that also returns a per-step report. The report is
+// returned even when the run fails, covering the steps that never ran.
+func (r *Runner) RunWithReport(ctx context.Context, s *Scenario, rc *ops.RunContext) (*Report, error) {
+	report := newReport(s)
 	if rc.StepResults == nil {
 		rc.StepResults = map[string]any{}
 	}
@@ -42,6 +50,7 @@ func (r *Runner) Run(ctx context.Context, s *Scenario, rc *ops.RunContext) error
 			seed = *s.Seed
 		}
 		rc.Rand = rand.New(rand.NewSource(seed))
+		report.Seed = &seed
 	}
 	if rc.Stdout == nil {
 		rc.Stdout = r.writerOr(os.Stdout)
@@ -62,15 +71,26 @@ func (r *Runner) Run(ctx context.Context, s *Scenario, rc *ops.RunContext) error
 	for i, step := range s.Steps {
 		resolver := DefaultResolver(rc.Vars, rc.StepResults)
 		fmt.Fprintf(rc.Stdout, "\n=== step %d: %s (%s) ===\n", i, step.Name, step.Op)
+		started := time.Now()
+		// fail stops the run, skip records an error let through by on_error.
+		fail := func(err error) (*Report, error) {
+			report.add(StepReport{Name: step.Name, Op: step.Op, Status: StatusFailed, Duration: time.Since(started), Error: err.Error()})
+			report.finish(s, i+1)
+			return report, err
+		}
+		skip := func(err error) {
+			report.add(StepReport{Name: step.Name, Op: step.Op, Status: StatusSkipped, Duration: time.Since(started), Error: err.Error()})
+		}
 
 		op, ok := ops.New(step.Op)
 		if !ok {
 			err := fmt.Errorf("unknown op %q", step.Op)
 			if stopOnError && step.OnError != "ignore" && step.OnError != "continue" {
-				return err
+				return fail(err)
 			}
 			rc.StepResults[step.Name] = map[string]any{"error": err.Error()}
 			fmt.Fprintf(rc.Stdout, "step %s: %v (skipped due to on_error)\n", step.Name, err)
+			skip(err)
 			continue
 		}
 
@@ -79,19 +99,21 @@ func (r *Runner) Run(ctx context.Context, s *Scenario, rc *ops.RunContext) error
 		paramsNode := copyNode(&step.Params)
 		if err := resolver.Interpolate(paramsNode); err != nil {
 			if !skipOnErr(stopOnError, step.OnError) {
-				return fmt.Errorf("step %s: interpolate: %w", step.Name, err)
+				return fail(fmt.Errorf("step %s: interpolate: %w", step.Name, err))
 			}
 			rc.StepResults[step.Name] = map[string]any{"error": err.Error()}
 			fmt.Fprintf(rc.Stdout, "step %s: %v (skipped)\n", step.Name, err)
+			skip(err)
 			continue
 		}
 		if !isEmptyNode(paramsNode) {
 			if err := paramsNode.Decode(op); err != nil {
 				if !skipOnErr(stopOnError, step.OnError) {
-					return fmt.Errorf("step %s: decode params: %w", step.Name, err)
+					return fail(fmt.Errorf("step %s: decode params: %w", step.Name, err))
 				}
 				rc.StepResults[step.Name] = map[string]any{"error": err.Error()}
 				fmt.Fprintf(rc.Stdout, "step %s: %v (skipped)\n", step.Name, err)
+				skip(err)
 				continue
 			}
 		}
@@ -101,18 +123,22 @@ func (r *Runner) Run(ctx context.Context, s *Scenario, rc *ops.RunContext) error
 			if errors.Is(err, ops.ErrNotImplemented) && step.OnError == "ignore" {
 				fmt.Fprintf(rc.Stdout, "step %s: %v (ignored)\n", step.Name, err)
 				rc.StepResults[step.Name] = map[string]any{"error": err.Error()}
+				skip(err)
 				continue
 			}
 			if !skipOnErr(stopOnError, step.OnError) {
-				return fmt.Errorf("step %s (%s): %w", step.Name, step.Op, err)
+				return fail(fmt.Errorf("step %s (%s): %w", step.Name, step.Op, err))
 			}
 			rc.StepResults[step.Name] = map[string]any{"error": err.Error()}
 			fmt.Fprintf(rc.Stdout, "step %s: %v (ignored)\n", step.Name, err)
+			skip(err)
 			continue
 		}
 		rc.StepResults[step.Name] = res
+		report.add(StepReport{Name: step.Name, Op: step.Op, Status: StatusPassed, Duration: time.Since(started), Result: summarizeResult(res)})
 	}
-	return nil
+	report.finish(s, len(s.Steps))
+	return report, nil
 }
 
 // skipOnErr returns true when a step-level on_error setting allows the
@@ -21,6 +21,16 @@ type BuilderOptions struct {
 }
 
 func Build(opts BuilderOptions) (*entity.Schema, error) {
+	spec, err := BuildSpec(opts)
+	if err != nil {
+		return nil, err
+	}
+	return BuildSchema(*spec)
+}
+
+// BuildSpec turns the quick-setup options into a SchemaSpec, so flag-built
+// collections can be expressed (and recorded) as a create_collection op.
+func BuildSpec(opts BuilderOptions) (*SchemaSpec, error) {
 	if opts.Name == "" {
 		return nil, fmt.Errorf("collection name is required")
 	}
@@ -28,17 +38,17 @@ func Build(opts BuilderOptions) (*entity.Schema, error) {
 		return nil, fmt.Errorf("vector dim must be positive")
 	}
 
-	pkName := "id"
-	pkField := entity.NewField().WithName(pkName).WithIsPrimaryKey(true).WithIsAutoID(opts.AutoID)
+	pkField := FieldSpec{Name: "id", IsPrimaryKey: true, AutoID: opts.AutoID}
 	switch strings.ToLower(opts.PKType) {
 	case "", "int64":
-		pkField.WithDataType(entity.FieldTypeInt64)
+		pkField.Type = "int64"
 	case "varchar":
 		maxLen := opts.PKMaxLength
 		if maxLen == 0 {
 			maxLen = 256
 		}
-		pkField.WithDataType(entity.FieldTypeVarChar).WithMaxLength(maxLen)
+		pkField.Type = "varchar"
+		pkField.MaxLength = maxLen
 	default:
 		return nil, fmt.Errorf("unsupported pk type %q (use int64 or varchar)", opts.PKType)
 	}
@@ -51,80 +61,71 @@ func Build(opts BuilderOptions) (*entity.Schema, error) {
 	if err != nil {
 		return nil, err
 	}
-	vecField := entity.NewField().WithName(vecName).WithDataType(vecType).WithDim(opts.Dim)
 
-	s := entity.NewSchema().WithName(opts.Name).WithDynamicFieldEnabled(opts.DynamicField).
-		WithField(pkField).WithField(vecField)
+	spec := &SchemaSpec{
+		Name:               opts.Name,
+		EnableDynamicField: opts.DynamicField,
+		Fields:             []FieldSpec{pkField, {Name: vecName, Type: vecType, Dim: opts.Dim}},
+	}
 
 	for _, raw := range opts.ScalarFields {
 		field, err := parseScalarField(raw)
 		if err != nil {
 			return nil, fmt.Errorf("scalar field %q: %w", raw, err)
 		}
-		s.WithField(field)
+		spec.Fields = append(spec.Fields, field)
 	}
 
-	return s, nil
+	return spec, nil
 }
 
-func parseVectorType(v string) (entity.FieldType, error) {
+// parseVectorType normalizes vector type aliases to the names ParseFieldType
+// understands.
+func parseVectorType(v string) (string, error) {
 	switch strings.ToLower(v) {
 	case "", "float_vector", "float":
-		return entity.FieldTypeFloatVector, nil
+		return "float_vector", nil
 	case "binary_vector", "binary":
-		return entity.FieldTypeBinaryVector, nil
+		return "binary_vector", nil
 	case "float16_vector", "float16", "fp16":
-		return entity.FieldTypeFloat16Vector, nil
+		return "float16_vector", nil
 	case "bfloat16_vector", "bfloat16", "bf16":
-		return entity.FieldTypeBFloat16Vector, nil
+		return "bfloat16_vector", nil
 	case "int8_vector", "int8":
-		return entity.FieldTypeInt8Vector, nil
+		return "int8_vector", nil
 	default:
-		return 0, fmt.Errorf("unsupported vector type %q", v)
+		return "", fmt.Errorf("unsupported vector type %q", v)
 	}
 }
 
 // parseScalarField expects "name:type[:maxLen]" format. type is one of:
 // int8/int16/int32/int64/float/double/bool/varchar/json.
-func parseScalarField(raw string) (*entity.Field, error) {
+func parseScalarField(raw string) (FieldSpec, error) {
 	parts := strings.Split(raw, ":")
 	if len(parts) < 2 {
-		return nil, fmt.Errorf("expected name:type[:maxLen]")
+		return FieldSpec{}, fmt.Errorf("expected name:type[:maxLen]")
 	}
 	name := parts[0]
 	if name == "" {
-		return nil, fmt.Errorf("empty field name")
+		return FieldSpec{}, fmt.Errorf("empty field name")
 	}
-	f := entity.NewField().WithName(name)
-	switch strings.ToLower(parts[1]) {
-	case "int8":
-		f.WithDataType(entity.FieldTypeInt8)
-	case "int16":
-		f.WithDataType(entity.FieldTypeInt16)
-	case "int32":
-		f.WithDataType(entity.FieldTypeInt32)
-	case "int64":
-		f.WithDataType(entity.FieldTypeInt64)
-	case "float":
-		f.WithDataType(entity.FieldTypeFloat)
-	case "double":
-		f.WithDataType(entity.FieldTypeDouble)
-	case "bool":
-		f.WithDataType(entity.FieldTypeBool)
-	case "json":
-		f.WithDataType(entity.FieldTypeJSON)
+	f := FieldSpec{Name: name}
+	switch tp := strings.ToLower(parts[1]); tp {
+	case "int8", "int16", "int32", "int64", "float", "double", "bool", "json":
+		f.Type = tp
 	case "varchar":
 		maxLen := int64(256)
 		if len(parts) >= 3 {
 			n, err := strconv.ParseInt(parts[2], 10, 64)
 			if err != nil {
-				return nil, fmt.Errorf("invalid maxLen %q", parts[2])
+				return FieldSpec{}, fmt.Errorf("invalid maxLen %q", parts[2])
 			}
 			maxLen = n
 		}
-		f.WithDataType(entity.FieldTypeVarChar).WithMaxLength(maxLen)
+		f.Type = tp
+		f.MaxLength = maxLen
 	default:
-		return nil, fmt.Errorf("unsupported type %q", parts[1])
+		return FieldSpec{}, fmt.Errorf("unsupported type %q", parts[1])
 	}
 	return f, nil
 }
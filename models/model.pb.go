@@ -85,6 +85,7 @@ const (
 	PartType_Configurations       PartType = 4
 	PartType_AppMetrics           PartType = 5
 	PartType_LoadedSegments       PartType = 6
+	PartType_Manifest             PartType = 7
 )
 
 // Enum value maps for PartType.
@@ -97,6 +98,7 @@ var (
 		4: "Configurations",
 		5: "AppMetrics",
 		6: "LoadedSegments",
+		7: "Manifest",
 	}
 	PartType_value = map[string]int32{
 		"PartTypeNone":         0,
@@ -106,6 +108,7 @@ var (
 		"Configurations":       4,
 		"AppMetrics":           5,
 		"LoadedSegments":       6,
+		"Manifest":             7,
 	}
 )
 
@@ -454,7 +457,7 @@ var file_model_proto_rawDesc = []byte{
 	0x12, 0x09, 0x0a, 0x05, 0x4f, 0x70, 0x44, 0x65, 0x6c, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x4f,
 	0x70, 0x50, 0x75, 0x74, 0x10, 0x02, 0x12, 0x0f, 0x0a, 0x0b, 0x4f, 0x70, 0x50, 0x75, 0x74, 0x42,
 	0x65, 0x66, 0x6f, 0x72, 0x65, 0x10, 0x03, 0x12, 0x0e, 0x0a, 0x0a, 0x4f, 0x70, 0x50, 0x75, 0x74,
-	0x41, 0x66, 0x74, 0x65, 0x72, 0x10, 0x04, 0x2a, 0x91, 0x01, 0x0a, 0x08, 0x50, 0x61, 0x72, 0x74,
+	0x41, 0x66, 0x74, 0x65, 0x72, 0x10, 0x04, 0x2a, 0x9f, 0x01, 0x0a, 0x08, 0x50, 0x61, 0x72, 0x74,
 	0x54, 0x79, 0x70, 0x65, 0x12, 0x10, 0x0a, 0x0c, 0x50, 0x61, 0x72, 0x74, 0x54, 0x79, 0x70, 0x65,
 	0x4e, 0x6f, 0x6e, 0x65, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x45, 0x74, 0x63, 0x64, 0x42, 0x61,
 	0x63, 0x6b, 0x75, 0x70, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63,
@@ -463,10 +466,11 @@ var file_model_proto_rawDesc = []byte{
 	0x70, 0x10, 0x03, 0x12, 0x12, 0x0a, 0x0e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61,
 	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x10, 0x04, 0x12, 0x0e, 0x0a, 0x0a, 0x41, 0x70, 0x70, 0x4d, 0x65,
 	0x74, 0x72, 0x69, 0x63, 0x73, 0x10, 0x05, 0x12, 0x12, 0x0a, 0x0e, 0x4c, 0x6f, 0x61, 0x64, 0x65,
-	0x64, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x10, 0x06, 0x42, 0x29, 0x5a, 0x27, 0x67,
-	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x69, 0x6c, 0x76, 0x75, 0x73,
-	0x2d, 0x69, 0x6f, 0x2f, 0x62, 0x69, 0x72, 0x64, 0x77, 0x61, 0x74, 0x63, 0x68, 0x65, 0x72, 0x2f,
-	0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x64, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x10, 0x06, 0x12, 0x0c, 0x0a, 0x08, 0x4d,
+	0x61, 0x6e, 0x69, 0x66, 0x65, 0x73, 0x74, 0x10, 0x07, 0x42, 0x29, 0x5a, 0x27, 0x67, 0x69, 0x74,
+	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x69, 0x6c, 0x76, 0x75, 0x73, 0x2d, 0x69,
+	0x6f, 0x2f, 0x62, 0x69, 0x72, 0x64, 0x77, 0x61, 0x74, 0x63, 0x68, 0x65, 0x72, 0x2f, 0x6d, 0x6f,
+	0x64, 0x65, 0x6c, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
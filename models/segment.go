@@ -28,6 +28,7 @@ type Segment struct {
 	// lazy load func
 	loadOnce sync.Once
 	lazyLoad func(*Segment)
+	loadErr  error
 }
 
 func NewSegment(segment *datapb.SegmentInfo, key string,
@@ -52,6 +53,7 @@ func NewSegment(segment *datapb.SegmentInfo, key string,
 		binlogs, statslogs, deltalogs, bm25Statslogs, err := lazy()
 		if err != nil {
 			fmt.Println("lazy load binlog failed", err.Error())
+			s.loadErr = err
 			return
 		}
 		if len(bm25Statslogs) == 0 {
@@ -94,6 +96,17 @@ func newSegmentFromBase[segmentBase interface {
 	return s
 }
 
+// LoadBinlogs loads the field binlog meta if not loaded yet and returns the load error,
+// Get*logs only print the error and return empty logs, which looks the same as a segment without data.
+func (s *Segment) LoadBinlogs() error {
+	s.loadOnce.Do(func() {
+		if s.lazyLoad != nil {
+			s.lazyLoad(s)
+		}
+	})
+	return s.loadErr
+}
+
 func (s *Segment) GetBinlogs() []*FieldBinlog {
 	s.loadOnce.Do(func() {
 		if s.lazyLoad != nil {
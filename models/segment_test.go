@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus/pkg/v2/proto/datapb"
+)
+
+func TestSegmentLoadBinlogs(t *testing.T) {
+	calls := 0
+	segment := NewSegment(&datapb.SegmentInfo{ID: 1}, "", func() ([]*datapb.FieldBinlog, []*datapb.FieldBinlog, []*datapb.FieldBinlog, []*datapb.FieldBinlog, error) {
+		calls++
+		return nil, nil, nil, nil, errors.New("etcd unavailable")
+	})
+	assert.Error(t, segment.LoadBinlogs())
+	assert.Empty(t, segment.GetBinlogs())
+	assert.Error(t, segment.LoadBinlogs())
+	assert.Equal(t, 1, calls)
+
+	segment = NewSegment(&datapb.SegmentInfo{ID: 2}, "", func() ([]*datapb.FieldBinlog, []*datapb.FieldBinlog, []*datapb.FieldBinlog, []*datapb.FieldBinlog, error) {
+		return []*datapb.FieldBinlog{{FieldID: 100, Binlogs: []*datapb.Binlog{{LogID: 1}}}}, nil, nil, nil, nil
+	})
+	assert.NoError(t, segment.LoadBinlogs())
+	assert.Len(t, segment.GetBinlogs(), 1)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/minio/minio-go/v7"
@@ -23,6 +24,8 @@ type ObjectInfo struct {
 	Size  int64
 	IsDir bool
 	Err   error
+	// LastModified is zero if not provided by the store
+	LastModified time.Time
 }
 
 type ResolvedObjectStore struct {
@@ -122,10 +125,11 @@ func (s *minioObjectStore) List(ctx context.Context, prefix string, recursive bo
 		defer close(result)
 		for info := range source {
 			result <- ObjectInfo{
-				Key:   info.Key,
-				Size:  info.Size,
-				IsDir: strings.HasSuffix(info.Key, "/"),
-				Err:   info.Err,
+				Key:          info.Key,
+				Size:         info.Size,
+				IsDir:        strings.HasSuffix(info.Key, "/"),
+				Err:          info.Err,
+				LastModified: info.LastModified,
 			}
 		}
 	}()
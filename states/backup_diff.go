@@ -0,0 +1,302 @@
+package states
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+
+	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/internal/protodiff"
+	"github.com/milvus-io/birdwatcher/models"
+	"github.com/milvus-io/birdwatcher/states/etcd/common"
+	"github.com/milvus-io/birdwatcher/states/kv"
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+)
+
+const metaCategoryOther = "other"
+
+type DiffBackupParam struct {
+	framework.DataSetParam `use:"diff-backup [a] [b]" desc:"semantic diff between two backups, or between a backup and the connected instance"`
+	Categories             []string `name:"category" default:"" desc:"only compare these meta categories, e.g. segment,index,replica"`
+	IncludeSnapshots       bool     `name:"include-snapshots" default:"false" desc:"include rootcoord snapshot keys"`
+	Summary                bool     `name:"summary" default:"false" desc:"only print counts per category"`
+	from                   string
+	to                     string
+}
+
+func (p *DiffBackupParam) ParseArgs(args []string) error {
+	switch len(args) {
+	case 1:
+		p.from = args[0]
+	case 2:
+		p.from, p.to = args[0], args[1]
+	default:
+		return errors.New("usage: diff-backup <a> [b], compares with the connected instance if b is not provided")
+	}
+	return nil
+}
+
+// DiffBackupCommand decodes both sides with the meta model of each prefix and reports entity level changes.
+func (app *ApplicationState) DiffBackupCommand(ctx context.Context, p *DiffBackupParam) (*framework.PresetResultSet, error) {
+	from, err := loadBackupKVs(p.from)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load backup %s", p.from)
+	}
+	var to map[string][]byte
+	toName := p.to
+	if p.to != "" {
+		to, err = loadBackupKVs(p.to)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load backup %s", p.to)
+		}
+	} else {
+		cli, basePath, ok := app.connectedMeta()
+		if !ok {
+			return nil, errors.New("only one backup provided and no instance connected")
+		}
+		toName = "live(" + basePath + ")"
+		to, err = loadLiveKVs(ctx, cli, basePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := diffMetaKVs(from, to, metaDiffOption{
+		categories:       lo.Compact(p.Categories),
+		includeSnapshots: p.IncludeSnapshots,
+	})
+	result.From, result.To, result.summary = p.from, toName, p.Summary
+	return framework.NewPresetResultSet(result, framework.NameFormat(p.Format)), nil
+}
+
+// connectedMeta returns the meta kv of the connected instance or loaded backup.
+func (app *ApplicationState) connectedMeta() (kv.MetaKV, string, bool) {
+	for _, state := range app.states {
+		switch s := state.(type) {
+		case *InstanceState:
+			return s.client, s.basePath, true
+		case *embedEtcdMockState:
+			return s.client, path.Join(s.instanceName, metaPath), true
+		}
+	}
+	return nil, "", false
+}
+
+// loadBackupKVs reads all etcd entries of a backup file keyed by the key relative to the meta path.
+func loadBackupKVs(file string) (map[string][]byte, error) {
+	f, err := openBackupFile(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	rd := bufio.NewReader(r)
+	var header models.BackupHeader
+	if err := readFixLengthHeader(rd, &header); err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string][]byte)
+	var basePath string
+	collect := func(entry *commonpb.KeyDataPair) error {
+		entries[entry.GetKey()] = entry.GetData()
+		return nil
+	}
+	switch header.Version {
+	case 1:
+		basePath = path.Join(header.Instance, metaPath)
+		if err := readBackupEntries(rd, collect); err != nil {
+			return nil, err
+		}
+	case 2:
+		// etcd part is always the first part, metrics parts after it are not needed.
+		var ph models.PartHeader
+		if err := readFixLengthHeader(rd, &ph); err != nil {
+			return nil, err
+		}
+		if ph.PartType != models.PartType_EtcdBackup {
+			return nil, errors.Newf("first part is %s instead of etcd backup", ph.PartType.String())
+		}
+		meta := make(map[string]string)
+		if err := json.Unmarshal(ph.Extra, &meta); err != nil {
+			return nil, err
+		}
+		// incremental or filtered backup holds only part of the meta, diffing it would report the rest as removed
+		if meta["baseRev"] != "" {
+			return nil, errors.Newf("incremental backup based on revision %s is not a full snapshot, load the backup chain and diff the loaded instance instead", meta["baseRev"])
+		}
+		if meta["filtered"] == "true" {
+			return nil, errors.New("filtered backup is not a full snapshot")
+		}
+		basePath = path.Join(meta["instance"], meta["metaPath"])
+		if err := readBackupEntries(rd, collect); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Newf("backup version %d not supported", header.Version)
+	}
+	return relativeKVs(entries, basePath), nil
+}
+
+func loadLiveKVs(ctx context.Context, cli kv.MetaKV, basePath string) (map[string][]byte, error) {
+	entries := make(map[string][]byte)
+	err := cli.WalkWithPrefix(ctx, basePath+"/", 1000, func(k, v []byte) error {
+		entries[string(k)] = bytes.Clone(v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return relativeKVs(entries, basePath), nil
+}
+
+func relativeKVs(entries map[string][]byte, basePath string) map[string][]byte {
+	prefix := basePath + "/"
+	result := make(map[string][]byte, len(entries))
+	for key, value := range entries {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		result[strings.TrimPrefix(key, prefix)] = value
+	}
+	return result
+}
+
+type metaDiffOption struct {
+	categories       []string
+	includeSnapshots bool
+}
+
+type metaDiffEntry struct {
+	Key      string             `json:"key"`
+	Category string             `json:"category"`
+	Kind     string             `json:"kind"`
+	Changes  []protodiff.Change `json:"changes,omitempty"`
+}
+
+type categoryCount struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// MetaDiff is the result of diff-backup.
+type MetaDiff struct {
+	From    string                    `json:"from"`
+	To      string                    `json:"to"`
+	Counts  map[string]*categoryCount `json:"counts"`
+	Entries []metaDiffEntry           `json:"entries"`
+	summary bool
+}
+
+func diffMetaKVs(from, to map[string][]byte, opt metaDiffOption) *MetaDiff {
+	result := &MetaDiff{Counts: make(map[string]*categoryCount)}
+	keys := lo.Union(lo.Keys(from), lo.Keys(to))
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !opt.includeSnapshots && strings.HasPrefix(key, common.SnapshotPrefix+"/") {
+			continue
+		}
+		category := metaCategoryOther
+		if decoder, ok := common.LookupMetaDecoder(key); ok {
+			category = decoder.Category
+		}
+		if len(opt.categories) > 0 && !lo.Contains(opt.categories, category) {
+			continue
+		}
+		count, ok := result.Counts[category]
+		if !ok {
+			count = &categoryCount{}
+			result.Counts[category] = count
+		}
+
+		oldValue, inFrom := from[key]
+		newValue, inTo := to[key]
+		entry := metaDiffEntry{Key: key, Category: category}
+		switch {
+		case !inFrom:
+			entry.Kind = string(protodiff.Added)
+			count.Added++
+		case !inTo:
+			entry.Kind = string(protodiff.Removed)
+			count.Removed++
+		case bytes.Equal(oldValue, newValue):
+			count.Unchanged++
+			continue
+		default:
+			entry.Changes = diffMetaValue(key, oldValue, newValue)
+			if len(entry.Changes) == 0 {
+				// same content with different encoding
+				count.Unchanged++
+				continue
+			}
+			entry.Kind = string(protodiff.Modified)
+			count.Changed++
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+	return result
+}
+
+func diffMetaValue(key string, oldValue, newValue []byte) []protodiff.Change {
+	oldMsg, oldOK := common.DecodeMetaValue(key, oldValue)
+	newMsg, newOK := common.DecodeMetaValue(key, newValue)
+	if oldOK && newOK {
+		return protodiff.Diff(oldMsg, newMsg)
+	}
+	return []protodiff.Change{{Kind: protodiff.Modified, Old: fmt.Sprintf("%q", oldValue), New: fmt.Sprintf("%q", newValue)}}
+}
+
+func (d *MetaDiff) PrintAs(format framework.Format) string {
+	switch format {
+	case framework.FormatJSON:
+		return framework.MarshalJSON(d)
+	default:
+		sb := &strings.Builder{}
+		fmt.Fprintf(sb, "Comparing %s => %s\n", d.From, d.To)
+		categories := lo.Keys(d.Counts)
+		sort.Strings(categories)
+		fmt.Fprintf(sb, "%-20s %8s %8s %8s %10s\n", "Category", "Added", "Removed", "Changed", "Unchanged")
+		for _, category := range categories {
+			c := d.Counts[category]
+			fmt.Fprintf(sb, "%-20s %8d %8d %8d %10d\n", category, c.Added, c.Removed, c.Changed, c.Unchanged)
+		}
+		if d.summary {
+			return sb.String()
+		}
+		for _, entry := range d.Entries {
+			switch protodiff.ChangeKind(entry.Kind) {
+			case protodiff.Added:
+				fmt.Fprintf(sb, "\n+ [%s] %s\n", entry.Category, entry.Key)
+			case protodiff.Removed:
+				fmt.Fprintf(sb, "\n- [%s] %s\n", entry.Category, entry.Key)
+			default:
+				fmt.Fprintf(sb, "\n~ [%s] %s\n", entry.Category, entry.Key)
+				for _, change := range entry.Changes {
+					fmt.Fprintf(sb, "    %s\n", change)
+				}
+			}
+		}
+		return sb.String()
+	}
+}
+
+func (d *MetaDiff) Entities() any {
+	return d
+}
@@ -0,0 +1,68 @@
+package states
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/milvus-io/milvus/pkg/v2/proto/datapb"
+)
+
+func TestDiffMetaKVs(t *testing.T) {
+	segment := func(rows int64) []byte {
+		bs, err := proto.Marshal(&datapb.SegmentInfo{ID: 300, CollectionID: 100, NumOfRows: rows})
+		require.NoError(t, err)
+		return bs
+	}
+	from := map[string][]byte{
+		"datacoord-meta/s/100/200/300": segment(10),
+		"datacoord-meta/s/100/200/301": segment(10),
+		"session/id":                   []byte("1"),
+		"snapshots/root-coord/a_ts1":   []byte("x"),
+	}
+	to := map[string][]byte{
+		"datacoord-meta/s/100/200/300": segment(20),
+		"datacoord-meta/s/100/200/302": segment(10),
+		"session/id":                   []byte("1"),
+	}
+
+	result := diffMetaKVs(from, to, metaDiffOption{})
+	require.Len(t, result.Entries, 3)
+	assert.Equal(t, "datacoord-meta/s/100/200/300", result.Entries[0].Key)
+	assert.Equal(t, "modified", result.Entries[0].Kind)
+	require.Len(t, result.Entries[0].Changes, 1)
+	assert.Equal(t, "num_of_rows", result.Entries[0].Changes[0].Path)
+	assert.Equal(t, "removed", result.Entries[1].Kind)
+	assert.Equal(t, "added", result.Entries[2].Kind)
+	assert.Equal(t, categoryCount{Added: 1, Removed: 1, Changed: 1}, *result.Counts["segment"])
+	assert.Equal(t, categoryCount{Unchanged: 1}, *result.Counts[metaCategoryOther])
+
+	result = diffMetaKVs(from, to, metaDiffOption{categories: []string{"replica"}})
+	assert.Empty(t, result.Entries)
+}
+
+func TestLoadBackupKVs(t *testing.T) {
+	dir := t.TempDir()
+	fullFile := path.Join(dir, "full.bak.gz")
+	incFile := path.Join(dir, "inc.bak.gz")
+	filteredFile := path.Join(dir, "filtered.bak.gz")
+
+	writeTestEtcdBackup(t, fullFile, map[string]string{"cnt": "2", "rev": "10", "instance": "by-dev", "metaPath": "meta"},
+		map[string]string{"by-dev/meta/a": "1", "by-dev/meta/b": "1"}, nil)
+	writeTestEtcdBackup(t, incFile, map[string]string{"cnt": "1", "rev": "20", "baseRev": "10", "instance": "by-dev", "metaPath": "meta"},
+		map[string]string{"by-dev/meta/b": "2"}, []string{"by-dev/meta/a"})
+	writeTestEtcdBackup(t, filteredFile, map[string]string{"cnt": "1", "rev": "10", "filtered": "true", "instance": "by-dev", "metaPath": "meta"},
+		map[string]string{"by-dev/meta/a": "1"}, nil)
+
+	kvs, err := loadBackupKVs(fullFile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"a": []byte("1"), "b": []byte("1")}, kvs)
+
+	_, err = loadBackupKVs(incFile)
+	assert.Error(t, err)
+	_, err = loadBackupKVs(filteredFile)
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package states
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/milvus-io/birdwatcher/states/etcd/common"
+)
+
+// newBackupFilter returns the filter of backup entries, nil if all entries shall be kept.
+// Keys are kept when they belong to any of the collections and any of the meta categories.
+func newBackupFilter(basePath string, collectionIDs []int64, categories []string) (func(key, value []byte) bool, error) {
+	if len(collectionIDs) == 0 && len(categories) == 0 {
+		return nil, nil
+	}
+	valid := append(common.MetaCategories(), metaCategoryOther)
+	for _, category := range categories {
+		if !lo.Contains(valid, category) {
+			return nil, errors.Newf("unknown meta category %s, must be one of %v", category, valid)
+		}
+	}
+
+	prefix := basePath + "/"
+	return func(key, value []byte) bool {
+		relKey := strings.TrimPrefix(string(key), prefix)
+		if len(categories) > 0 {
+			category := metaCategoryOther
+			if decoder, ok := common.LookupMetaDecoder(relKey); ok {
+				category = decoder.Category
+			}
+			if !lo.Contains(categories, category) {
+				return false
+			}
+		}
+		if len(collectionIDs) > 0 {
+			return lo.ContainsBy(collectionIDs, func(collectionID int64) bool {
+				return metaReferencesCollection(relKey, value, collectionID)
+			})
+		}
+		return true
+	}, nil
+}
+
+// metaReferencesCollection checks whether the key path or the collection id field of decoded value references the collection.
+func metaReferencesCollection(relKey string, value []byte, collectionID int64) bool {
+	id := strconv.FormatInt(collectionID, 10)
+	// vchannel names, e.g. by-dev-rootcoord-dml_0_449v0, used by channel checkpoint and watch keys.
+	vchannelPart := fmt.Sprintf("_%sv", id)
+	for _, part := range strings.Split(relKey, "/") {
+		if part == id || strings.Contains(part, vchannelPart) {
+			return true
+		}
+	}
+
+	// alias, compaction and import metas only carry the collection id in value
+	msg, ok := common.DecodeMetaValue(relKey, value)
+	if !ok || msg == nil {
+		return false
+	}
+	return messageCollectionID(msg) == collectionID
+}
+
+func messageCollectionID(msg proto.Message) int64 {
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Kind() != protoreflect.Int64Kind || fd.IsList() {
+			continue
+		}
+		name := strings.ToLower(strings.ReplaceAll(string(fd.Name()), "_", ""))
+		if name == "collectionid" {
+			return m.Get(fd).Int()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,36 @@
+package states
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/milvus-io/milvus/pkg/v2/proto/etcdpb"
+)
+
+func TestBackupFilter(t *testing.T) {
+	filter, err := newBackupFilter("by-dev/meta", nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, filter)
+
+	_, err = newBackupFilter("by-dev/meta", nil, []string{"unknown"})
+	assert.Error(t, err)
+
+	alias, err := proto.Marshal(&etcdpb.AliasInfo{AliasName: "a", CollectionId: 449})
+	require.NoError(t, err)
+
+	filter, err = newBackupFilter("by-dev/meta", []int64{449}, nil)
+	require.NoError(t, err)
+	assert.True(t, filter([]byte("by-dev/meta/datacoord-meta/s/449/450/451"), nil))
+	assert.True(t, filter([]byte("by-dev/meta/datacoord-meta/channel-cp/by-dev-rootcoord-dml_0_449v0"), nil))
+	assert.True(t, filter([]byte("by-dev/meta/root-coord/aliases/1/a"), alias))
+	assert.False(t, filter([]byte("by-dev/meta/datacoord-meta/s/500/501/502"), nil))
+	assert.False(t, filter([]byte("by-dev/meta/session/querynode-1"), nil))
+
+	filter, err = newBackupFilter("by-dev/meta", []int64{449}, []string{"segment"})
+	require.NoError(t, err)
+	assert.True(t, filter([]byte("by-dev/meta/datacoord-meta/s/449/450/451"), nil))
+	assert.False(t, filter([]byte("by-dev/meta/datacoord-meta/channel-cp/by-dev-rootcoord-dml_0_449v0"), nil))
+}
@@ -0,0 +1,353 @@
+package states
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/models"
+	"github.com/milvus-io/birdwatcher/states/etcd/common"
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/internalpb"
+)
+
+// backupPartSummary is the manifest record of one part, the checksum covers
+// all frames from the part header to the stopper, length prefixes included.
+type backupPartSummary struct {
+	Type    string `json:"type"`
+	Records int64  `json:"records"`
+	Bytes   int64  `json:"bytes"`
+	SHA256  string `json:"sha256"`
+}
+
+// backupManifest is stored as the only frame of the trailing manifest part.
+type backupManifest struct {
+	Parts []backupPartSummary `json:"parts"`
+	// Prefixes counts etcd entries per meta prefix, relative to meta path.
+	Prefixes map[string]int64 `json:"prefixes"`
+}
+
+// backupRecordSize returns the number of frames of each record in the part,
+// a record starting with an empty frame is the stopper of the part.
+func backupRecordSize(partType models.PartType) int {
+	switch partType {
+	case models.PartType_MetricsBackup:
+		// [session] [metrics] [default metrics]
+		return 3
+	case models.PartType_Configurations, models.PartType_AppMetrics:
+		// [session] [data]
+		return 2
+	default:
+		return 1
+	}
+}
+
+// backupPartTracker follows frames of a v2 backup stream, it checksums every part,
+// counts etcd entries per prefix and checks each frame can be decoded.
+type backupPartTracker struct {
+	manifest backupManifest
+	// recorded is the manifest read from the stream, if any.
+	recorded *backupManifest
+
+	headerRead bool
+	inPart     bool
+	partType   models.PartType
+	recordSize int
+	frameIdx   int
+	deletes    bool
+	basePath   string
+	hash       hash.Hash
+	current    backupPartSummary
+}
+
+func newBackupPartTracker() *backupPartTracker {
+	return &backupPartTracker{manifest: backupManifest{Prefixes: make(map[string]int64)}}
+}
+
+// onFrame handles one frame, raw is the whole frame with length prefix and data is the payload.
+func (t *backupPartTracker) onFrame(raw, data []byte) error {
+	if !t.headerRead {
+		t.headerRead = true
+		header := &models.BackupHeader{}
+		if err := proto.Unmarshal(data, header); err != nil {
+			return errors.Wrap(err, "invalid backup header")
+		}
+		if header.GetVersion() != 2 {
+			return errors.Newf("backup version %d has no parts", header.GetVersion())
+		}
+		return nil
+	}
+
+	if !t.inPart {
+		ph := &models.PartHeader{}
+		if err := proto.Unmarshal(data, ph); err != nil {
+			return errors.Wrapf(err, "invalid header of part %d", len(t.manifest.Parts))
+		}
+		t.inPart = true
+		t.partType = ph.GetPartType()
+		t.recordSize = backupRecordSize(t.partType)
+		t.frameIdx = 0
+		t.deletes, t.basePath = false, ""
+		if t.partType == models.PartType_EtcdBackup {
+			meta := make(map[string]string)
+			if err := json.Unmarshal(ph.GetExtra(), &meta); err != nil {
+				return errors.Wrap(err, "invalid etcd part meta")
+			}
+			t.deletes = meta["deletes"] == "true"
+			t.basePath = path.Join(meta["instance"], meta["metaPath"])
+		}
+		t.hash = sha256.New()
+		t.hash.Write(raw)
+		t.current = backupPartSummary{Type: t.partType.String(), Bytes: int64(len(raw))}
+		return nil
+	}
+
+	t.hash.Write(raw)
+	t.current.Bytes += int64(len(raw))
+	if t.frameIdx == 0 && len(data) == 0 {
+		t.inPart = false
+		t.current.SHA256 = hex.EncodeToString(t.hash.Sum(nil))
+		// the manifest does not checksum itself
+		if t.partType != models.PartType_Manifest {
+			t.manifest.Parts = append(t.manifest.Parts, t.current)
+		}
+		return nil
+	}
+
+	if err := t.decodeFrame(data); err != nil {
+		return errors.Wrapf(err, "part %d(%s) record %d frame %d", len(t.manifest.Parts), t.current.Type, t.current.Records, t.frameIdx)
+	}
+	t.frameIdx++
+	if t.frameIdx == t.recordSize {
+		t.frameIdx = 0
+		t.current.Records++
+	}
+	return nil
+}
+
+func (t *backupPartTracker) decodeFrame(data []byte) error {
+	switch t.partType {
+	case models.PartType_EtcdBackup:
+		entry := &commonpb.KeyDataPair{}
+		if err := proto.Unmarshal(data, entry); err != nil {
+			return err
+		}
+		if !t.deletes {
+			t.manifest.Prefixes[backupKeyPrefix(t.basePath, entry.GetKey())]++
+		}
+	case models.PartType_MetricsBackup, models.PartType_AppMetrics:
+		if t.frameIdx == 0 {
+			return json.Unmarshal(data, &models.Session{})
+		}
+	case models.PartType_Configurations:
+		if t.frameIdx == 0 {
+			return json.Unmarshal(data, &models.Session{})
+		}
+		return proto.Unmarshal(data, &internalpb.ShowConfigurationsResponse{})
+	case models.PartType_Manifest:
+		manifest := &backupManifest{}
+		if err := json.Unmarshal(data, manifest); err != nil {
+			return err
+		}
+		t.recorded = manifest
+	}
+	return nil
+}
+
+// backupKeyPrefix returns the known meta prefix of the key, or the first path element for unknown keys.
+func backupKeyPrefix(basePath, key string) string {
+	relKey := strings.TrimPrefix(key, basePath+"/")
+	if decoder, ok := common.LookupMetaDecoder(relKey); ok {
+		if strings.HasPrefix(relKey, common.SnapshotPrefix+"/") {
+			return path.Join(common.SnapshotPrefix, decoder.Prefix)
+		}
+		return decoder.Prefix
+	}
+	first, _, _ := strings.Cut(relKey, "/")
+	return first
+}
+
+// backupChecksumWriter tracks the frames written through it, so that the manifest can be appended at the end.
+type backupChecksumWriter struct {
+	w       io.Writer
+	tracker *backupPartTracker
+	buf     []byte
+	err     error
+}
+
+func newBackupChecksumWriter(w io.Writer) *backupChecksumWriter {
+	return &backupChecksumWriter{w: w, tracker: newBackupPartTracker()}
+}
+
+func (cw *backupChecksumWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	cw.buf = append(cw.buf, p...)
+	consumed := 0
+	for len(cw.buf)-consumed >= 8 {
+		size := binary.LittleEndian.Uint64(cw.buf[consumed:])
+		if uint64(len(cw.buf)-consumed-8) < size {
+			break
+		}
+		raw := cw.buf[consumed : consumed+8+int(size)]
+		if err := cw.tracker.onFrame(raw, raw[8:]); err != nil && cw.err == nil {
+			cw.err = err
+		}
+		consumed += len(raw)
+	}
+	cw.buf = append(cw.buf[:0], cw.buf[consumed:]...)
+	return n, nil
+}
+
+// writeBackupManifest flushes pending parts and appends the manifest part.
+func writeBackupManifest(w *bufio.Writer, cw *backupChecksumWriter) error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if cw.err != nil {
+		return errors.Wrap(cw.err, "backup content is not valid")
+	}
+	if cw.tracker.inPart {
+		return errors.New("last part is not finished")
+	}
+	bs, err := proto.Marshal(&models.PartHeader{PartType: models.PartType_Manifest, PartLen: -1})
+	if err != nil {
+		return err
+	}
+	manifest, err := json.Marshal(cw.tracker.manifest)
+	if err != nil {
+		return err
+	}
+	writeBackupBytes(w, bs)
+	writeBackupBytes(w, manifest)
+	writeBackupBytes(w, nil)
+	return w.Flush()
+}
+
+type VerifyBackupParam struct {
+	framework.ParamBase `use:"verify-backup [file]" desc:"verify structure, checksums and decodability of a backup file"`
+	backupFile          string
+	BackupStorageParam
+}
+
+func (p *VerifyBackupParam) ParseArgs(args []string) error {
+	if len(args) != 1 {
+		return errors.New("verify-backup requires exactly one backup file")
+	}
+	p.backupFile = args[0]
+	return nil
+}
+
+// VerifyBackupCommand streams the backup file without restoring it.
+func (app *ApplicationState) VerifyBackupCommand(ctx context.Context, p *VerifyBackupParam) error {
+	f, err := p.openBackup(ctx, p.backupFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.Wrap(err, "not a gzip file")
+	}
+	defer r.Close()
+
+	tracker, err := scanBackup(bufio.NewReader(r))
+	for i, part := range tracker.manifest.Parts {
+		fmt.Printf("Part %d %-20s records: %-8d bytes: %-10s sha256: %s\n", i, part.Type, part.Records, hrSize(part.Bytes), part.SHA256)
+	}
+	if err != nil {
+		fmt.Println("❌ backup structure invalid:", err.Error())
+		return err
+	}
+
+	if tracker.recorded == nil {
+		fmt.Println("⚠️  no manifest found, backup was created by an older version, checksums not verified")
+		fmt.Println("✅ backup structure and content decodable")
+		return nil
+	}
+	problems := compareBackupManifest(tracker.recorded, &tracker.manifest)
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Println("❌", problem)
+		}
+		return errors.Newf("backup verification failed with %d problems", len(problems))
+	}
+	fmt.Printf("✅ backup verified, %d parts match manifest\n", len(tracker.manifest.Parts))
+	return nil
+}
+
+// scanBackup reads all frames of the stream, it returns the tracker even on error for partial report.
+func scanBackup(rd io.Reader) (*backupPartTracker, error) {
+	tracker := newBackupPartTracker()
+	for {
+		data, nb, err := readBackupBytes(rd)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return tracker, errors.Wrap(err, "backup truncated")
+		}
+		raw := make([]byte, 8+len(data))
+		binary.LittleEndian.PutUint64(raw, nb)
+		copy(raw[8:], data)
+		if err := tracker.onFrame(raw, data); err != nil {
+			return tracker, err
+		}
+	}
+	if !tracker.headerRead {
+		return tracker, errors.New("backup is empty")
+	}
+	if tracker.inPart {
+		return tracker, errors.Newf("backup truncated in part %d(%s)", len(tracker.manifest.Parts), tracker.current.Type)
+	}
+	return tracker, nil
+}
+
+func compareBackupManifest(recorded, actual *backupManifest) []string {
+	var problems []string
+	if len(recorded.Parts) != len(actual.Parts) {
+		problems = append(problems, fmt.Sprintf("manifest lists %d parts, found %d", len(recorded.Parts), len(actual.Parts)))
+	}
+	for i := 0; i < len(recorded.Parts) && i < len(actual.Parts); i++ {
+		expect, got := recorded.Parts[i], actual.Parts[i]
+		if expect != got {
+			problems = append(problems, fmt.Sprintf("part %d(%s) mismatch, manifest records %d sha256 %s, found %s records %d sha256 %s",
+				i, expect.Type, expect.Records, expect.SHA256, got.Type, got.Records, got.SHA256))
+		}
+	}
+	prefixes := make(map[string]struct{})
+	for prefix := range recorded.Prefixes {
+		prefixes[prefix] = struct{}{}
+	}
+	for prefix := range actual.Prefixes {
+		prefixes[prefix] = struct{}{}
+	}
+	keys := make([]string, 0, len(prefixes))
+	for prefix := range prefixes {
+		keys = append(keys, prefix)
+	}
+	sort.Strings(keys)
+	for _, prefix := range keys {
+		if recorded.Prefixes[prefix] != actual.Prefixes[prefix] {
+			problems = append(problems, fmt.Sprintf("prefix %s count mismatch, manifest %d, found %d", prefix, recorded.Prefixes[prefix], actual.Prefixes[prefix]))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,56 @@
+package states
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/milvus-io/birdwatcher/models"
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+)
+
+func TestBackupManifest(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cw := newBackupChecksumWriter(buf)
+	w := bufio.NewWriter(cw)
+	require.NoError(t, writeBackupHeader(w, 2))
+
+	extra, err := json.Marshal(map[string]string{"instance": "by-dev", "metaPath": "meta"})
+	require.NoError(t, err)
+	bs, err := proto.Marshal(&models.PartHeader{PartType: models.PartType_EtcdBackup, PartLen: -1, Extra: extra})
+	require.NoError(t, err)
+	writeBackupBytes(w, bs)
+	for _, key := range []string{"by-dev/meta/datacoord-meta/s/1/2/3", "by-dev/meta/datacoord-meta/s/1/2/4", "by-dev/meta/session/id"} {
+		bs, err := proto.Marshal(&commonpb.KeyDataPair{Key: key, Data: []byte("v")})
+		require.NoError(t, err)
+		writeBackupBytes(w, bs)
+	}
+	writeBackupBytes(w, nil)
+	require.NoError(t, writeBackupManifest(w, cw))
+
+	tracker, err := scanBackup(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.NotNil(t, tracker.recorded)
+	assert.Empty(t, compareBackupManifest(tracker.recorded, &tracker.manifest))
+	require.Len(t, tracker.manifest.Parts, 1)
+	assert.EqualValues(t, 3, tracker.manifest.Parts[0].Records)
+	assert.EqualValues(t, 2, tracker.manifest.Prefixes["datacoord-meta/s"])
+	assert.EqualValues(t, 1, tracker.manifest.Prefixes["session"])
+
+	// flip one byte of the last etcd entry value
+	data := bytes.Clone(buf.Bytes())
+	idx := bytes.LastIndex(data, []byte("v"))
+	data[idx] = 'x'
+	tracker, err = scanBackup(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.NotEmpty(t, compareBackupManifest(tracker.recorded, &tracker.manifest))
+
+	// truncated in etcd part
+	_, err = scanBackup(bytes.NewReader(buf.Bytes()[:len(buf.Bytes())/2]))
+	assert.Error(t, err)
+}
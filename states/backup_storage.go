@@ -0,0 +1,236 @@
+package states
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/milvus-io/birdwatcher/oss"
+)
+
+// backupUploadPartSize bounds the memory used by streaming multipart upload.
+const backupUploadPartSize = 16 << 20
+
+// BackupStorageParam is the object storage connection flags for backup locations like `s3://bucket/prefix`.
+type BackupStorageParam struct {
+	Endpoint           string `name:"endpoint" default:"" desc:"object storage endpoint, default derived from the scheme, required for minio://"`
+	CloudProvider      string `name:"cloudProvider" default:"" desc:"cloud provider, default inferred from the scheme"`
+	Region             string `name:"region" default:"" desc:"object storage region"`
+	AK                 string `name:"ak" default:"" desc:"access key, IAM is used if no key or role provided"`
+	SK                 string `name:"sk" default:"" desc:"secret key"`
+	IAMEndpoint        string `name:"iamEndpoint" default:"" desc:"IAM endpoint address"`
+	RoleARN            string `name:"roleArn" default:"" desc:"role to assume"`
+	ExternalID         string `name:"externalId" default:"" desc:"external id to assume role"`
+	AliyunRoleAuthMode string `name:"aliyunRoleAuthMode" default:"" desc:"aliyun role auth mode, ram or oidc"`
+	Insecure           bool   `name:"insecure" default:"false" desc:"connect without SSL"`
+}
+
+// backupLocation is a parsed `scheme://bucket/prefix` url.
+type backupLocation struct {
+	Scheme string
+	Bucket string
+	Key    string
+}
+
+// isRemoteBackup checks whether the backup path is an object storage url.
+func isRemoteBackup(raw string) bool {
+	return strings.Contains(raw, "://")
+}
+
+func parseBackupLocation(raw string) (backupLocation, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return backupLocation{}, errors.Wrap(err, "parse backup location")
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return backupLocation{}, errors.Newf("invalid backup location %s, expect scheme://bucket/prefix", raw)
+	}
+	return backupLocation{
+		Scheme: strings.ToLower(u.Scheme),
+		Bucket: u.Host,
+		Key:    strings.Trim(u.Path, "/"),
+	}, nil
+}
+
+func (p *BackupStorageParam) newClient(ctx context.Context, location backupLocation) (*minio.Client, error) {
+	provider := p.CloudProvider
+	if provider == "" {
+		provider = inferCloudProviderFromScheme(location.Scheme)
+	}
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		switch provider {
+		case oss.CloudProviderAWS:
+			if location.Scheme == "s3" {
+				endpoint = "s3.amazonaws.com"
+				if p.Region != "" {
+					endpoint = fmt.Sprintf("s3.%s.amazonaws.com", p.Region)
+				}
+			}
+		case oss.CloudProviderGCP:
+			endpoint = oss.GcsDefaultAddress
+		case oss.CloudProviderAliyun:
+			if p.Region != "" {
+				endpoint = fmt.Sprintf("oss-%s.aliyuncs.com", p.Region)
+			}
+		}
+	}
+	if provider == "" || endpoint == "" {
+		return nil, errors.Newf("cannot infer provider and endpoint of %s://, set --cloudProvider and --endpoint", location.Scheme)
+	}
+
+	param := oss.MinioClientParam{
+		Addr:               endpoint,
+		AK:                 p.AK,
+		SK:                 p.SK,
+		IAMEndpoint:        p.IAMEndpoint,
+		UseSSL:             !p.Insecure,
+		CloudProvider:      provider,
+		Region:             p.Region,
+		RoleARN:            p.RoleARN,
+		ExternalID:         p.ExternalID,
+		AliyunRoleAuthMode: p.AliyunRoleAuthMode,
+		BucketName:         location.Bucket,
+	}
+	if param.RoleARN == "" && param.AK == "" {
+		param.UseIAM = true
+	}
+	client, err := oss.NewMinioClient(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+	return client.Client, nil
+}
+
+// openBackup opens a local backup file or streams a backup object.
+func (p *BackupStorageParam) openBackup(ctx context.Context, raw string) (io.ReadCloser, error) {
+	if !isRemoteBackup(raw) {
+		f, err := openBackupFile(raw)
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	location, err := parseBackupLocation(raw)
+	if err != nil {
+		return nil, err
+	}
+	client, err := p.newClient(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := client.GetObject(ctx, location.Bucket, location.Key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject is lazy, stat to fail early for missing object.
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, errors.Wrapf(err, "failed to stat backup %s", raw)
+	}
+	return obj, nil
+}
+
+// backupTarget is where the backup content is written to.
+type backupTarget interface {
+	io.Writer
+	// Finish completes the backup, abort is the error failed the backup if any.
+	Finish(abort error) error
+	Location() string
+}
+
+// createBackupTarget creates the local backup file in working directory,
+// or starts a streaming upload under the target prefix.
+func (p *BackupStorageParam) createBackupTarget(ctx context.Context, target, component string) (backupTarget, error) {
+	if target == "" {
+		f, err := getBackupFile(component)
+		if err != nil {
+			return nil, err
+		}
+		return &localBackupTarget{f}, nil
+	}
+	if !isRemoteBackup(target) {
+		return nil, errors.Newf("invalid backup target %s, expect scheme://bucket/prefix", target)
+	}
+	location, err := parseBackupLocation(target)
+	if err != nil {
+		return nil, err
+	}
+	client, err := p.newClient(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	location.Key = path.Join(location.Key, backupFileName(component))
+
+	pr, pw := io.Pipe()
+	upload := &uploadBackupTarget{
+		pw:       pw,
+		done:     make(chan error, 1),
+		location: fmt.Sprintf("%s://%s/%s", location.Scheme, location.Bucket, location.Key),
+	}
+	go func() {
+		_, err := client.PutObject(ctx, location.Bucket, location.Key, pr, -1, minio.PutObjectOptions{
+			ContentType: "application/gzip",
+			PartSize:    backupUploadPartSize,
+		})
+		// unblock writer if upload failed early
+		pr.CloseWithError(err)
+		upload.done <- err
+	}()
+	return upload, nil
+}
+
+type localBackupTarget struct {
+	*os.File
+}
+
+// Finish closes the backup file, an aborted backup file is removed so no truncated backup is left.
+func (t *localBackupTarget) Finish(abort error) error {
+	err := t.File.Close()
+	if abort != nil {
+		if rerr := os.Remove(t.File.Name()); rerr != nil {
+			fmt.Printf("failed to remove aborted backup file %s, error: %v\n", t.File.Name(), rerr)
+		}
+		return abort
+	}
+	return err
+}
+
+func (t *localBackupTarget) Location() string {
+	return t.File.Name()
+}
+
+type uploadBackupTarget struct {
+	pw       *io.PipeWriter
+	done     chan error
+	location string
+}
+
+func (t *uploadBackupTarget) Write(p []byte) (int, error) {
+	return t.pw.Write(p)
+}
+
+// Finish waits the upload done, an aborted upload fails the multipart upload so no object is left.
+func (t *uploadBackupTarget) Finish(abort error) error {
+	if abort != nil {
+		t.pw.CloseWithError(abort)
+	} else {
+		t.pw.Close()
+	}
+	err := <-t.done
+	if abort != nil {
+		return abort
+	}
+	return err
+}
+
+func (t *uploadBackupTarget) Location() string {
+	return t.location
+}
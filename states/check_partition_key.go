@@ -6,40 +6,54 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strconv"
-	"strings"
+	"path"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/apache/arrow/go/v17/arrow"
+	"github.com/apache/arrow/go/v17/arrow/array"
+	"github.com/apache/arrow/go/v17/arrow/memory"
+	"github.com/apache/arrow/go/v17/parquet"
+	"github.com/apache/arrow/go/v17/parquet/compress"
+	"github.com/apache/arrow/go/v17/parquet/pqarrow"
 	"github.com/cockroachdb/errors"
-	"github.com/gosuri/uilive"
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/samber/lo"
+	"go.uber.org/atomic"
 
 	"github.com/milvus-io/birdwatcher/framework"
 	"github.com/milvus-io/birdwatcher/models"
 	"github.com/milvus-io/birdwatcher/oss"
 	"github.com/milvus-io/birdwatcher/states/etcd/common"
 	"github.com/milvus-io/birdwatcher/storage"
-	binlogv1 "github.com/milvus-io/birdwatcher/storage/binlog/v1"
 	storagecommon "github.com/milvus-io/birdwatcher/storage/common"
 	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/datapb"
 )
 
 type CheckPartitionKeyParam struct {
 	framework.ParamBase `use:"check-partiton-key" desc:"check partition key field file"`
 	Storage             string `name:"storage" default:"auto" desc:"storage service configuration mode"`
-	StopIfErr           bool   `name:"stopIfErr" default:"true"`
+	StopIfErr           bool   `name:"stopIfErr" default:"true" desc:"stop checking the collection once misplaced row found, ignored in parquet format"`
 	OutputPrimaryKey    bool   `name:"outputPK" default:"true" desc:"print error record primary key info in stdout mode"`
 	MinioAddress        string `name:"minioAddr" default:"" desc:"the minio address to override, leave empty to use milvus.yaml value"`
-	OutputFormat        string `name:"outputFmt" default:"stdout"`
+	SkipBucketCheck     bool   `name:"skipBucketCheck" default:"false" desc:"skip bucket exist check due to permission issue"`
+	OutputFormat        string `name:"outputFmt" default:"stdout" desc:"report format, stdout, json or parquet"`
+	WorkerNum           int64  `name:"workerNum" default:"4" desc:"worker num"`
+	OutputLimit         int64  `name:"outputLimit" default:"10" desc:"max sample pks per segment in stdout and json report"`
 
 	CollectionID int64 `name:"collection" default:"0" desc:"target collection to scan, default scan all partition key collections"`
 }
 
-var errQuickExit = errors.New("quick exit")
-
-// TODO refactor this command using new scan API
-
 func (s *InstanceState) CheckPartitionKeyCommand(ctx context.Context, p *CheckPartitionKeyParam) error {
+	switch p.OutputFormat {
+	case "stdout", "json", "parquet":
+	default:
+		return errors.Newf("unsupported output format %s", p.OutputFormat)
+	}
+
 	collections, err := common.ListCollections(ctx, s.client, s.basePath, func(collection *models.Collection) bool {
 		return p.CollectionID == 0 || collection.GetProto().ID == p.CollectionID
 	})
@@ -47,239 +61,510 @@ func (s *InstanceState) CheckPartitionKeyCommand(ctx context.Context, p *CheckPa
 		return err
 	}
 
-	var rootPath string
-
-	params := []oss.MinioConnectParam{}
+	params := []oss.MinioConnectParam{oss.WithSkipCheckBucket(p.SkipBucketCheck)}
 	if p.MinioAddress != "" {
 		params = append(params, oss.WithMinioAddr(p.MinioAddress))
 	}
-
 	resolvedStore, err := s.GetObjectStore(ctx, params...)
 	if err != nil {
 		return err
 	}
-	rootPath = resolvedStore.RootPath
 
-	type suspectCollection struct {
-		collection   *models.Collection
-		partitions   []*models.Partition
-		partIndex    map[int64]uint32 // partition id to hash index
-		idName       map[int64]string
-		partKeyField models.FieldSchema
-		pkField      models.FieldSchema
-		tsField      models.FieldSchema
-	}
-
-	var suspectCollections []*suspectCollection
-
-	for _, info := range collections {
-		collection := info.GetProto()
-		partKeyField, enablePartKey := lo.Find(collection.Schema.Fields, func(field *schemapb.FieldSchema) bool {
+	report := &partitionKeyReport{}
+	for _, collection := range collections {
+		schema := collection.GetProto().GetSchema()
+		partKeyField, ok := lo.Find(schema.GetFields(), func(field *schemapb.FieldSchema) bool {
 			return field.IsPartitionKey
 		})
-		if !enablePartKey {
+		if !ok {
 			continue
 		}
-		pkField, _ := lo.Find(collection.Schema.Fields, func(field *schemapb.FieldSchema) bool {
-			return field.IsPrimaryKey
-		})
-		tsField, _ := lo.Find(collection.Schema.Fields, func(field *schemapb.FieldSchema) bool {
-			return field.FieldID == 1
-		})
+		partitions, err := common.ListCollectionPartitions(ctx, s.client, s.basePath, collection.GetProto().ID)
+		if err != nil {
+			fmt.Printf("failed to list partitions of collection %d: %s\n", collection.GetProto().ID, err.Error())
+			continue
+		}
+		result, err := s.checkCollectionPartitionKey(ctx, p, resolvedStore, collection, partKeyField, partitions)
+		if err != nil {
+			return err
+		}
+		report.Collections = append(report.Collections, result)
+	}
 
-		partitions, err := common.ListCollectionPartitions(ctx, s.client, s.basePath, collection.ID)
+	switch p.OutputFormat {
+	case "stdout":
+		report.print(p.OutputPrimaryKey)
+		return nil
+	case "json":
+		fileName := path.Join(s.config.WorkspacePath, fmt.Sprintf("partition_key_report_%s.json", time.Now().Format("20060102150405")))
+		bs, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(fileName, bs, 0o644); err != nil {
+			return err
+		}
+		report.printSummary()
+		fmt.Printf("Report written to %s\n", fileName)
+	case "parquet":
+		fileName := path.Join(s.config.WorkspacePath, fmt.Sprintf("partition_key_report_%s.parquet", time.Now().Format("20060102150405")))
+		f, err := os.Create(fileName)
 		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := report.writeParquet(f); err != nil {
+			return err
+		}
+		report.printSummary()
+		fmt.Printf("Misplaced rows written to %s\n", fileName)
+	}
+	return nil
+}
+
+func (s *InstanceState) checkCollectionPartitionKey(ctx context.Context, p *CheckPartitionKeyParam, resolvedStore *oss.ResolvedObjectStore,
+	collection *models.Collection, partKeyField *schemapb.FieldSchema, partitions []*models.Partition,
+) (*partitionKeyCollectionReport, error) {
+	info := collection.GetProto()
+	pkField, ok := lo.Find(info.GetSchema().GetFields(), func(field *schemapb.FieldSchema) bool {
+		return field.IsPrimaryKey
+	})
+	if !ok {
+		return nil, errors.Newf("pk field not found in collection %d", info.ID)
+	}
+	checker := newPartitionKeyChecker(partitions)
+	result := &partitionKeyCollectionReport{
+		CollectionID:      info.ID,
+		CollectionName:    info.GetSchema().GetName(),
+		PartitionKeyField: partKeyField.GetName(),
+		PartitionNum:      len(partitions),
+	}
+
+	allSegments, err := common.ListSegments(ctx, s.client, s.basePath, func(segment *models.Segment) bool {
+		return segment.CollectionID == info.ID &&
+			segment.State != commonpb.SegmentState_Dropped &&
+			segment.State != commonpb.SegmentState_NotExist
+	})
+	if err != nil {
+		return nil, err
+	}
+	var segments []*models.Segment
+	for _, segment := range allSegments {
+		if segment.Level == datapb.SegmentLevel_L0 {
 			continue
 		}
+		// segment with unloadable binlog meta is reported as failed instead of looking empty
+		if err := segment.LoadBinlogs(); err != nil {
+			result.Segments = append(result.Segments, &partitionKeySegmentReport{
+				SegmentID:   segment.ID,
+				PartitionID: segment.PartitionID,
+				MisplacedTo: make(map[int64]int64),
+				Error:       fmt.Sprintf("failed to load binlog meta: %s", err.Error()),
+			})
+			continue
+		}
+		if len(segment.GetBinlogs()) > 0 {
+			segments = append(segments, segment)
+		}
+	}
+	l0Deletes, err := s.loadPartitionKeyL0Deletes(ctx, resolvedStore, collection, lo.Filter(allSegments, func(segment *models.Segment, _ int) bool {
+		return segment.Level == datapb.SegmentLevel_L0
+	}))
+	if err != nil {
+		return nil, err
+	}
 
-		partIdx := lo.SliceToMap(partitions, func(partition *models.Partition) (int64, uint32) {
-			splits := strings.Split(partition.GetProto().PartitionName, "_")
-			if len(splits) < 2 {
-				return -1, 0
-			}
-			index, err := strconv.ParseInt(splits[len(splits)-1], 10, 64)
-			if err != nil {
-				return -1, 0
-			}
-			if (index >= int64(len(partitions))) || (index < 0) {
-				return -1, 0
+	getObject := func(binlogPath string) (storagecommon.ReadSeeker, error) {
+		return resolvedStore.Store.Open(ctx, oss.ResolveObjectKey(resolvedStore.RootPath, binlogPath))
+	}
+	limit := p.OutputLimit
+	stopIfErr := p.StopIfErr
+	if p.OutputFormat == "parquet" {
+		// parquet report keeps all misplaced rows for follow-up cleanup,
+		// stopping at the first misplaced row would leave the report partial
+		limit = -1
+		stopIfErr = false
+	}
+
+	fmt.Printf("Start to check collection %s id = %d, partition number: %d, segment number: %d\n", result.CollectionName, info.ID, len(partitions), len(segments))
+	var stopped atomic.Bool
+	var mut sync.Mutex
+	var done int
+	taskCh := make(chan *models.Segment)
+	wg := sync.WaitGroup{}
+	for i := 0; i < int(max(p.WorkerNum, 1)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for segment := range taskCh {
+				if stopped.Load() {
+					continue
+				}
+				task := &partitionKeySegmentTask{
+					checker:   checker,
+					limit:     limit,
+					stopped:   &stopped,
+					stopIfErr: stopIfErr,
+					report: &partitionKeySegmentReport{
+						SegmentID:   segment.ID,
+						PartitionID: segment.PartitionID,
+						MisplacedTo: make(map[int64]int64),
+					},
+				}
+				err := s.scanPartitionKeySegment(ctx, resolvedStore, collection, segment, l0Deletes, pkField, partKeyField, getObject, task)
+				if err != nil {
+					task.report.Error = err.Error()
+				}
+				mut.Lock()
+				result.Segments = append(result.Segments, task.report)
+				done++
+				fmt.Printf("\rScan segment ... %d%%(%d/%d)", done*100/len(segments), done, len(segments))
+				mut.Unlock()
 			}
-			return partition.GetProto().PartitionID, uint32(index)
-		})
-		idName := lo.SliceToMap(partitions, func(partition *models.Partition) (int64, string) {
-			return partition.GetProto().PartitionID, partition.GetProto().PartitionName
-		})
+		}()
+	}
+	for _, segment := range segments {
+		taskCh <- segment
+	}
+	close(taskCh)
+	wg.Wait()
+	if len(segments) > 0 {
+		fmt.Println()
+	}
 
-		suspectCollections = append(suspectCollections, &suspectCollection{
-			collection:   info,
-			partitions:   partitions,
-			partIndex:    partIdx,
-			idName:       idName,
-			partKeyField: models.NewFieldSchemaFromBase(partKeyField),
-			pkField:      models.NewFieldSchemaFromBase(pkField),
-			tsField:      models.NewFieldSchemaFromBase(tsField),
-		})
+	result.summarize(partitions)
+	return result, nil
+}
+
+// allPartitionsID is the partition id of L0 segments holding deletes of all partitions, same as milvus common.AllPartitionsID.
+const allPartitionsID int64 = -1
+
+// partitionKeyL0Deletes is the deletes in a L0 segment, which apply to segments of the same channel and partition.
+type partitionKeyL0Deletes struct {
+	channel     string
+	partitionID int64
+	deleted     map[any]uint64 // pk => ts
+}
+
+func (d *partitionKeyL0Deletes) appliesTo(segment *models.Segment) bool {
+	return d.channel == segment.InsertChannel && (d.partitionID == allPartitionsID || d.partitionID == segment.PartitionID)
+}
+
+// mergePartitionKeyDeletes keeps the latest delete ts of each pk.
+func mergePartitionKeyDeletes(deleted map[any]uint64, pk any, ts uint64) {
+	if old, ok := deleted[pk]; !ok || ts > old {
+		deleted[pk] = ts
 	}
+}
 
-	for _, susCol := range suspectCollections {
-		collection := susCol.collection.GetProto()
-		partitions := susCol.partitions
-		partKeyField := susCol.partKeyField
-		partIdx := susCol.partIndex
-		pkField, _ := susCol.collection.GetPKField()
-		idField := lo.SliceToMap(collection.Schema.Fields, func(field *schemapb.FieldSchema) (int64, *schemapb.FieldSchema) {
-			return field.FieldID, field
+func (s *InstanceState) loadPartitionKeyL0Deletes(ctx context.Context, resolvedStore *oss.ResolvedObjectStore, collection *models.Collection, l0Segments []*models.Segment) ([]*partitionKeyL0Deletes, error) {
+	result := make([]*partitionKeyL0Deletes, 0, len(l0Segments))
+	for _, segment := range l0Segments {
+		deltaData, err := s.DownloadDeltalogs(ctx, resolvedStore.Store, resolvedStore.RootPath, collection, segment)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load deltalogs of L0 segment %d", segment.ID)
+		}
+		l0 := &partitionKeyL0Deletes{channel: segment.InsertChannel, partitionID: segment.PartitionID, deleted: make(map[any]uint64)}
+		deltaData.Range(func(pk storagecommon.PrimaryKey, ts uint64) bool {
+			mergePartitionKeyDeletes(l0.deleted, pk.GetValue(), ts)
+			return true
 		})
+		result = append(result, l0)
+	}
+	return result, nil
+}
+
+// scanPartitionKeySegment reads pk, timestamp and partition key columns of the segment and checks rows not deleted,
+// by either its own deltalogs or the L0 segments of the same channel and partition.
+func (s *InstanceState) scanPartitionKeySegment(ctx context.Context, resolvedStore *oss.ResolvedObjectStore, collection *models.Collection, segment *models.Segment,
+	l0Deletes []*partitionKeyL0Deletes, pkField, partKeyField *schemapb.FieldSchema, getObject func(string) (storagecommon.ReadSeeker, error), task *partitionKeySegmentTask,
+) error {
+	deltaData, err := s.DownloadDeltalogs(ctx, resolvedStore.Store, resolvedStore.RootPath, collection, segment)
+	if err != nil {
+		return err
+	}
+	deleted := make(map[any]uint64)
+	deltaData.Range(func(pk storagecommon.PrimaryKey, ts uint64) bool {
+		mergePartitionKeyDeletes(deleted, pk.GetValue(), ts)
+		return true
+	})
+	for _, l0 := range l0Deletes {
+		if !l0.appliesTo(segment) {
+			continue
+		}
+		for pk, ts := range l0.deleted {
+			mergePartitionKeyDeletes(deleted, pk, ts)
+		}
+	}
+	filter := storage.NewDeltalogFilter(deleted)
 
-		fmt.Printf("Start to check collection %s id = %d\n", collection.Schema.Name, collection.ID)
+	fields := lo.Uniq([]int64{pkField.FieldID, 1, partKeyField.FieldID})
+	reader, err := storage.NewSegmentReader(segment, fields, getObject)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
 
-		segments, err := common.ListSegments(ctx, s.client, s.basePath, func(segment *models.Segment) bool {
-			return segment.CollectionID == collection.ID
-		})
+	var pk storagecommon.PrimaryKey
+	switch pkField.DataType {
+	case schemapb.DataType_Int64:
+		pk = &storagecommon.Int64PrimaryKey{}
+	case schemapb.DataType_VarChar:
+		pk = &storagecommon.VarCharPrimaryKey{}
+	default:
+		return errors.Newf("unsupported primary key type %s", pkField.DataType.String())
+	}
+
+	for !task.stopped.Load() {
+		recordBatch, _, err := reader.Next(ctx)
 		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
 			return err
 		}
+		pkArr, tsArr, partKeyArr := recordBatch.Column(pkField.FieldID), recordBatch.Column(1), recordBatch.Column(partKeyField.FieldID)
+		for i := range recordBatch.Len() {
+			pkValue, ok := storage.DeserializeItem(pkArr, pkField.DataType, i)
+			if !ok {
+				return errors.Newf("failed to deserialize pk, %d-th item", i)
+			}
+			ts, ok := storage.DeserializeItem(tsArr, schemapb.DataType_Int64, i)
+			if !ok {
+				return errors.Newf("failed to deserialize timestamp, %d-th item", i)
+			}
+			pk.SetValue(pkValue)
+			if match, _ := filter.Match(pk, ts.(int64), nil); !match {
+				continue
+			}
+			partKey, ok := storage.DeserializeItem(partKeyArr, partKeyField.DataType, i)
+			if !ok {
+				return errors.Newf("failed to deserialize partition key, %d-th item", i)
+			}
+			if err := task.check(pkValue, partKey); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
 
-		var collectionErrs int
-		var found bool
+// partitionKeyChecker computes the partition a partition key value shall be written into.
+type partitionKeyChecker struct {
+	partitionNum uint32
+	// hash index to partition id
+	indexPartition map[uint32]int64
+}
 
-		fmt.Printf("Partition number: %d, Segment number %d\n", len(partitions), len(segments))
-		progressDisplay := uilive.New()
-		progressFmt := "Scan segment ... %d%%(%d/%d) %s\n"
-		progressDisplay.Start()
-		fmt.Fprintf(progressDisplay, progressFmt, 0, 0, len(segments), "")
+func newPartitionKeyChecker(partitions []*models.Partition) *partitionKeyChecker {
+	indexPartition := make(map[uint32]int64)
+	for partitionID, index := range partitionKeyIndexes(partitions) {
+		indexPartition[index] = partitionID
+	}
+	return &partitionKeyChecker{
+		partitionNum:   uint32(len(partitions)),
+		indexPartition: indexPartition,
+	}
+}
 
-		for idx, segment := range segments {
-			if segment.State == commonpb.SegmentState_Dropped || segment.State == commonpb.SegmentState_NotExist {
-				continue
-			}
-			var errCnt int
-			err := func() error {
-				var f *os.File
-				var pqWriter *binlogv1.ParquetWriter
-				selector := func(_ int64) bool { return true }
-				switch p.OutputFormat {
-				case "stdout":
-					selector = func(field int64) bool { return field == partKeyField.FieldID }
-				case "json-pk":
-					selector = func(field int64) bool { return field == partKeyField.FieldID }
-					fallthrough
-				case "json":
-					f, err = os.Create(fmt.Sprintf("%d-%d.json", collection.ID, segment.ID))
-					if err != nil {
-						return err
-					}
-				case "parquet":
-					f, err = os.Create(fmt.Sprintf("%d-%d.parquet", collection.ID, segment.ID))
-					if err != nil {
-						return err
-					}
-					pqWriter = binlogv1.NewParquetWriter(susCol.collection)
-				}
-				deltalog, err := s.DownloadDeltalogs(ctx, resolvedStore.Store, rootPath, susCol.collection, segment)
-				if err != nil {
-					return err
-				}
+// expectedPartition returns the partition id for the value, -1 if no partition owns the hash index.
+func (c *partitionKeyChecker) expectedPartition(value any) (int64, error) {
+	if c.partitionNum == 0 {
+		return 0, errors.New("collection has no partition")
+	}
+	hash, err := hashRoutingValue(value)
+	if err != nil {
+		return 0, err
+	}
+	partitionID, ok := c.indexPartition[hash%c.partitionNum]
+	if !ok {
+		return -1, nil
+	}
+	return partitionID, nil
+}
 
-				s.ScanBinlogs(ctx, resolvedStore.Store, rootPath, susCol.collection, segment, selector, func(readers map[int64]*binlogv1.BinlogReader) {
-					targetIndex := partIdx[segment.PartitionID]
-					iter, err := NewBinlogIterator(susCol.collection, readers)
-					if err != nil {
-						fmt.Println("failed to create iterator", err.Error())
-						return
-					}
+// partitionKeySegmentTask checks the partition key of rows in one segment.
+type partitionKeySegmentTask struct {
+	checker   *partitionKeyChecker
+	limit     int64
+	stopped   *atomic.Bool
+	stopIfErr bool
 
-					err = iter.Range(func(rowID, ts int64, pk storagecommon.PrimaryKey, data map[int64]any) error {
-						deleted := false
-						deltalog.Range(func(delPk storagecommon.PrimaryKey, delTs uint64) bool {
-							if delPk.EQ(pk) && ts < int64(delTs) {
-								deleted = true
-								return false
-							}
-							return true
-						})
-						if deleted {
-							return nil
-						}
-
-						partKeyValue := data[partKeyField.FieldID]
-						var hashVal uint32
-						switch partKeyField.DataType {
-						case models.DataTypeInt64:
-							hashVal, _ = Hash32Int64(partKeyValue.(int64))
-						case models.DataTypeVarChar, models.DataTypeString:
-							hashVal = HashString2Uint32(partKeyValue.(string))
-						default:
-							return errors.Newf("unexpected partition key field type %v", partKeyField.DataType)
-						}
-						if (hashVal % uint32(len(partitions))) == targetIndex {
-							return nil
-						}
-						errCnt++
-						found = true
-
-						output := lo.MapKeys(data, func(v any, k int64) string {
-							return idField[k].Name
-						})
-						output[pkField.Name] = pk.GetValue()
-						switch p.OutputFormat {
-						case "stdout":
-							if p.OutputPrimaryKey {
-								fmt.Printf("PK %v partition does not follow partition key rule (%s=%v)\n", pk.GetValue(), partKeyField.Name, partKeyValue)
-							}
-							if p.StopIfErr {
-								return errQuickExit
-							}
-						case "json", "json-pk":
-							bs, err := json.Marshal(output)
-							if err != nil {
-								fmt.Println(err.Error())
-								return err
-							}
-							f.Write(bs)
-							f.Write([]byte("\n"))
-						case "parquet":
-							data[0] = rowID
-							data[1] = ts
-							data[pkField.FieldID] = pk.GetValue()
-							writeParquetData(susCol.collection, pqWriter, rowID, ts, pk, output)
-						}
-						return nil
-					})
-					if err != nil && !errors.Is(err, errQuickExit) {
-						fmt.Println(err.Error())
-					}
-				})
-				return nil
-			}()
-			if err != nil && !errors.Is(err, errQuickExit) {
-				return err
+	report *partitionKeySegmentReport
+}
+
+func (t *partitionKeySegmentTask) check(pk any, partKey any) error {
+	t.report.Rows++
+	expected, err := t.checker.expectedPartition(partKey)
+	if err != nil {
+		return err
+	}
+	if expected == t.report.PartitionID {
+		return nil
+	}
+	t.report.Misplaced++
+	t.report.MisplacedTo[expected]++
+	if t.limit < 0 || int64(len(t.report.Samples)) < t.limit {
+		t.report.Samples = append(t.report.Samples, misplacedRow{PK: pk, PartitionKey: partKey, ExpectedPartition: expected})
+	}
+	if t.stopIfErr {
+		t.stopped.Store(true)
+	}
+	return nil
+}
+
+type misplacedRow struct {
+	PK                any   `json:"pk"`
+	PartitionKey      any   `json:"partition_key"`
+	ExpectedPartition int64 `json:"expected_partition"`
+}
+
+type partitionKeySegmentReport struct {
+	SegmentID   int64           `json:"segment_id"`
+	PartitionID int64           `json:"partition_id"`
+	Rows        int64           `json:"rows"`
+	Misplaced   int64           `json:"misplaced"`
+	MisplacedTo map[int64]int64 `json:"misplaced_to,omitempty"`
+	Samples     []misplacedRow  `json:"samples,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+type partitionKeyPartitionReport struct {
+	PartitionID   int64           `json:"partition_id"`
+	PartitionName string          `json:"partition_name"`
+	Segments      int             `json:"segments"`
+	Rows          int64           `json:"rows"`
+	Misplaced     int64           `json:"misplaced"`
+	MisplacedTo   map[int64]int64 `json:"misplaced_to,omitempty"`
+}
+
+type partitionKeyCollectionReport struct {
+	CollectionID      int64                          `json:"collection_id"`
+	CollectionName    string                         `json:"collection_name"`
+	PartitionKeyField string                         `json:"partition_key_field"`
+	PartitionNum      int                            `json:"partition_num"`
+	Rows              int64                          `json:"rows"`
+	Misplaced         int64                          `json:"misplaced"`
+	Failed            int                            `json:"failed"`
+	Partitions        []*partitionKeyPartitionReport `json:"partitions"`
+	Segments          []*partitionKeySegmentReport   `json:"segments"`
+}
+
+// summarize sorts segment results and aggregates them per partition.
+func (r *partitionKeyCollectionReport) summarize(partitions []*models.Partition) {
+	sort.Slice(r.Segments, func(i, j int) bool { return r.Segments[i].SegmentID < r.Segments[j].SegmentID })
+	partReports := make(map[int64]*partitionKeyPartitionReport)
+	for _, partition := range partitions {
+		partReports[partition.GetProto().PartitionID] = &partitionKeyPartitionReport{
+			PartitionID:   partition.GetProto().PartitionID,
+			PartitionName: partition.GetProto().PartitionName,
+			MisplacedTo:   make(map[int64]int64),
+		}
+	}
+	for _, segment := range r.Segments {
+		if segment.Error != "" {
+			r.Failed++
+		}
+		r.Rows += segment.Rows
+		r.Misplaced += segment.Misplaced
+		partReport, ok := partReports[segment.PartitionID]
+		if !ok {
+			partReport = &partitionKeyPartitionReport{PartitionID: segment.PartitionID, MisplacedTo: make(map[int64]int64)}
+			partReports[segment.PartitionID] = partReport
+		}
+		partReport.Segments++
+		partReport.Rows += segment.Rows
+		partReport.Misplaced += segment.Misplaced
+		for target, cnt := range segment.MisplacedTo {
+			partReport.MisplacedTo[target] += cnt
+		}
+	}
+	r.Partitions = lo.Values(partReports)
+	sort.Slice(r.Partitions, func(i, j int) bool { return r.Partitions[i].PartitionID < r.Partitions[j].PartitionID })
+}
+
+type partitionKeyReport struct {
+	Collections []*partitionKeyCollectionReport `json:"collections"`
+}
+
+func (r *partitionKeyReport) printSummary() {
+	for _, collection := range r.Collections {
+		rows := make([]table.Row, 0, len(collection.Partitions))
+		for _, partition := range collection.Partitions {
+			rows = append(rows, table.Row{partition.PartitionID, partition.PartitionName, partition.Segments, partition.Rows, partition.Misplaced})
+		}
+		title := fmt.Sprintf("Collection %s(%d) partition key %s", collection.CollectionName, collection.CollectionID, collection.PartitionKeyField)
+		fmt.Println(framework.RenderTable(table.Row{"Partition", "Name", "Segments", "Rows", "Misplaced"}, rows, title))
+		for _, segment := range collection.Segments {
+			if segment.Error != "" {
+				fmt.Printf("failed to scan segment %d: %s\n", segment.SegmentID, segment.Error)
 			}
-			if p.StopIfErr && found {
-				break
+		}
+		if collection.Misplaced == 0 {
+			fmt.Printf("Collection %s all data OK!\n", collection.CollectionName)
+			continue
+		}
+		fmt.Printf("Collection %s found %d partition key error in %d rows\n", collection.CollectionName, collection.Misplaced, collection.Rows)
+	}
+}
+
+func (r *partitionKeyReport) print(outputPK bool) {
+	for _, collection := range r.Collections {
+		for _, segment := range collection.Segments {
+			if segment.Misplaced == 0 {
+				continue
 			}
-			progress := (idx + 1) * 100 / len(segments)
-			status := fmt.Sprintf("%d [%s]", segment.ID, colorReady.Sprint("done"))
-			if errCnt > 0 {
-				collectionErrs += errCnt
-				status = fmt.Sprintf("%d [%s](%d)", segment.ID, colorError.Sprint("error"), errCnt)
+			fmt.Printf("Segment %d (partition %d): %d/%d rows misplaced\n", segment.SegmentID, segment.PartitionID, segment.Misplaced, segment.Rows)
+			if !outputPK {
+				continue
+			}
+			for _, sample := range segment.Samples {
+				fmt.Printf("\tPK %v partition does not follow partition key rule (%s=%v), shall be in partition %d\n",
+					sample.PK, collection.PartitionKeyField, sample.PartitionKey, sample.ExpectedPartition)
 			}
-
-			fmt.Fprintf(progressDisplay, progressFmt, progress, idx+1, len(segments), status)
 		}
-		progressDisplay.Stop()
-		fmt.Println()
-		if p.StopIfErr {
-			if found {
-				fmt.Printf("Collection %s found partition key error\n", collection.Schema.Name)
-			} else {
-				fmt.Printf("Collection %s all data OK!\n", collection.Schema.Name)
+	}
+	r.printSummary()
+}
+
+// writeParquet writes one row for each misplaced row sample, pk and partition key values are formatted as string.
+func (r *partitionKeyReport) writeParquet(w io.Writer) error {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "collection_id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "partition_id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "segment_id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "pk", Type: arrow.BinaryTypes.String},
+		{Name: "partition_key", Type: arrow.BinaryTypes.String},
+		{Name: "expected_partition", Type: arrow.PrimitiveTypes.Int64},
+	}, nil)
+	writer, err := pqarrow.NewFileWriter(schema, w,
+		parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy)),
+		pqarrow.DefaultWriterProps())
+	if err != nil {
+		return err
+	}
+
+	builder := array.NewRecordBuilder(memory.NewGoAllocator(), schema)
+	defer builder.Release()
+	for _, collection := range r.Collections {
+		for _, segment := range collection.Segments {
+			for _, sample := range segment.Samples {
+				builder.Field(0).(*array.Int64Builder).Append(collection.CollectionID)
+				builder.Field(1).(*array.Int64Builder).Append(segment.PartitionID)
+				builder.Field(2).(*array.Int64Builder).Append(segment.SegmentID)
+				builder.Field(3).(*array.StringBuilder).Append(fmt.Sprint(sample.PK))
+				builder.Field(4).(*array.StringBuilder).Append(fmt.Sprint(sample.PartitionKey))
+				builder.Field(5).(*array.Int64Builder).Append(sample.ExpectedPartition)
 			}
-		} else {
-			fmt.Printf("Collection %s found %d partition key error\n", collection.Schema.Name, collectionErrs)
 		}
 	}
-	return nil
+	rec := builder.NewRecord()
+	defer rec.Release()
+	if err := writer.Write(rec); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
 }
 
 func (s *InstanceState) DownloadDeltalogs(ctx context.Context, store oss.ObjectStore, rootPath string, collection *models.Collection, segment *models.Segment) (*storage.DeltaData, error) {
@@ -0,0 +1,87 @@
+package states
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/milvus-io/birdwatcher/models"
+	"github.com/milvus-io/milvus/pkg/v2/proto/datapb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/etcdpb"
+)
+
+func TestPartitionKeySegmentTask(t *testing.T) {
+	partitions := []*models.Partition{
+		models.NewPartition(&etcdpb.PartitionInfo{PartitionID: 100, PartitionName: "_default_0"}, ""),
+		models.NewPartition(&etcdpb.PartitionInfo{PartitionID: 101, PartitionName: "_default_1"}, ""),
+	}
+	checker := newPartitionKeyChecker(partitions)
+
+	var stopped atomic.Bool
+	task := &partitionKeySegmentTask{
+		checker: checker,
+		limit:   1,
+		stopped: &stopped,
+		report:  &partitionKeySegmentReport{SegmentID: 1, PartitionID: 100, MisplacedTo: make(map[int64]int64)},
+	}
+	var misplaced int64
+	for i := int64(0); i < 10; i++ {
+		hash, err := hashRoutingValue(i)
+		require.NoError(t, err)
+		if hash%2 != 0 {
+			misplaced++
+		}
+		require.NoError(t, task.check(i, i))
+	}
+	require.NotZero(t, misplaced)
+	assert.EqualValues(t, 10, task.report.Rows)
+	assert.Equal(t, misplaced, task.report.Misplaced)
+	assert.Equal(t, map[int64]int64{101: misplaced}, task.report.MisplacedTo)
+	assert.Len(t, task.report.Samples, 1)
+	assert.EqualValues(t, 101, task.report.Samples[0].ExpectedPartition)
+	assert.False(t, stopped.Load())
+
+	_, err := checker.expectedPartition(1.0)
+	assert.Error(t, err)
+
+	report := &partitionKeyCollectionReport{Segments: []*partitionKeySegmentReport{
+		task.report,
+		{SegmentID: 0, PartitionID: 101, Rows: 5, Error: "broken"},
+	}}
+	report.summarize(partitions)
+	assert.EqualValues(t, 0, report.Segments[0].SegmentID)
+	assert.EqualValues(t, 15, report.Rows)
+	assert.Equal(t, misplaced, report.Misplaced)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Partitions, 2)
+	assert.Equal(t, "_default_0", report.Partitions[0].PartitionName)
+	assert.Equal(t, misplaced, report.Partitions[0].Misplaced)
+	assert.EqualValues(t, 5, report.Partitions[1].Rows)
+}
+
+func TestPartitionKeyL0Deletes(t *testing.T) {
+	segment := &models.Segment{SegmentInfo: &datapb.SegmentInfo{ID: 1, PartitionID: 100, InsertChannel: "dml_0_v0"}}
+	cases := []struct {
+		channel     string
+		partitionID int64
+		applies     bool
+	}{
+		{"dml_0_v0", 100, true},
+		{"dml_0_v0", allPartitionsID, true},
+		{"dml_0_v0", 101, false},
+		{"dml_1_v0", 100, false},
+		{"dml_1_v0", allPartitionsID, false},
+	}
+	for _, c := range cases {
+		l0 := &partitionKeyL0Deletes{channel: c.channel, partitionID: c.partitionID}
+		assert.Equal(t, c.applies, l0.appliesTo(segment), "%s/%d", c.channel, c.partitionID)
+	}
+
+	deleted := map[any]uint64{int64(1): 10}
+	mergePartitionKeyDeletes(deleted, int64(1), 5)
+	mergePartitionKeyDeletes(deleted, int64(1), 20)
+	mergePartitionKeyDeletes(deleted, int64(2), 3)
+	assert.Equal(t, map[any]uint64{int64(1): 20, int64(2): 3}, deleted)
+}
@@ -26,6 +26,7 @@ type GetConfigurationParam struct {
 	Format              string `name:"format" default:"line" desc:"output format"`
 	DialTimeout         int64  `name:"dialTimeout" default:"2" desc:"grpc dial timeout in seconds"`
 	Filter              string `name:"filter" default:"" desc:"configuration key filter sub string"`
+	Drift               bool   `name:"drift" default:"false" desc:"report drift between nodes of same role, against defaults and etcd overrides"`
 }
 
 func (s *InstanceState) GetConfigurationCommand(ctx context.Context, p *GetConfigurationParam) error {
@@ -36,6 +37,7 @@ func (s *InstanceState) GetConfigurationCommand(ctx context.Context, p *GetConfi
 	}
 
 	results := make(map[string]map[string]string)
+	roles := make(map[string]string)
 
 	for _, session := range sessions {
 		opts := []grpc.DialOption{
@@ -58,7 +60,7 @@ func (s *InstanceState) GetConfigurationCommand(ctx context.Context, p *GetConfi
 
 		var client mgrpc.ConfigurationSource
 		switch strings.ToLower(session.ServerName) {
-		case "rootcoord":
+		case "rootcoord", "mixcoord":
 			client = rootcoordpb.NewRootCoordClient(conn)
 		case "datacoord":
 			client = datapb.NewDataCoordClient(conn)
@@ -87,7 +89,13 @@ func (s *InstanceState) GetConfigurationCommand(ctx context.Context, p *GetConfi
 			return p.Filter == "" || strings.Contains(configuration.GetKey(), p.Filter)
 		})
 
-		results[fmt.Sprintf("%s-%d", session.ServerName, session.ServerID)] = common.KVListMap(configurations)
+		node := fmt.Sprintf("%s-%d", session.ServerName, session.ServerID)
+		results[node] = common.KVListMap(configurations)
+		roles[node] = session.ServerName
+	}
+
+	if p.Drift {
+		return s.printConfigDrift(ctx, p, results, roles)
 	}
 
 	switch strings.ToLower(p.Format) {
@@ -0,0 +1,198 @@
+package states
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/samber/lo"
+
+	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/states/etcd/common"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+)
+
+const (
+	configDriftNode    = "node"
+	configDriftDefault = "default"
+	configDriftEtcd    = "etcd"
+)
+
+// configDrift is one configuration key reported by the drift check.
+type configDrift struct {
+	Key     string `json:"key"`
+	Kind    string `json:"kind"`
+	Role    string `json:"role,omitempty"`
+	Default string `json:"default,omitempty"`
+	// Etcd is the value set via etcd config source, if any.
+	Etcd string `json:"etcd,omitempty"`
+	// Values is the value of each node, missing key is not listed.
+	Values map[string]string `json:"values"`
+}
+
+type configDriftReport struct {
+	Nodes    []configDrift `json:"nodes"`
+	Defaults []configDrift `json:"defaults"`
+	Etcd     []configDrift `json:"etcd"`
+}
+
+func (s *InstanceState) printConfigDrift(ctx context.Context, p *GetConfigurationParam, results map[string]map[string]string, roles map[string]string) error {
+	keys, values, err := common.ListEtcdConfigs(ctx, s.client, s.instanceName)
+	if err != nil {
+		return err
+	}
+	prefix := s.instanceName + "/config/"
+	etcdConfigs := make(map[string]string)
+	for idx, key := range keys {
+		key = formatConfigKey(strings.TrimPrefix(key, prefix))
+		if p.Filter == "" || strings.Contains(key, p.Filter) {
+			etcdConfigs[key] = values[idx]
+		}
+	}
+
+	defaults := lo.PickBy(paramDefaults(), func(key string, _ string) bool {
+		return p.Filter == "" || strings.Contains(key, p.Filter)
+	})
+
+	report := buildConfigDriftReport(results, roles, defaults, etcdConfigs)
+	if strings.ToLower(p.Format) == "json" {
+		fmt.Println(framework.MarshalJSON(report))
+		return nil
+	}
+	fmt.Print(report.String())
+	return nil
+}
+
+// formatConfigKey normalizes the key the same way as milvus config manager,
+// so that yaml keys, etcd keys and ShowConfigurations keys can be compared.
+func formatConfigKey(key string) string {
+	return strings.NewReplacer("/", "", "_", "", ".", "").Replace(strings.ToLower(key))
+}
+
+// paramDefaults collects default values of all param items compiled into birdwatcher.
+func paramDefaults() map[string]string {
+	defaults := make(map[string]string)
+	collectParamDefaults(reflect.ValueOf(paramtable.Get()), defaults, make(map[uintptr]struct{}))
+	return defaults
+}
+
+func collectParamDefaults(v reflect.Value, defaults map[string]string, visited map[uintptr]struct{}) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		if _, ok := visited[v.Pointer()]; ok {
+			return
+		}
+		visited[v.Pointer()] = struct{}{}
+		collectParamDefaults(v.Elem(), defaults, visited)
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(paramtable.ParamItem{}) {
+			if key := v.FieldByName("Key").String(); key != "" {
+				defaults[formatConfigKey(key)] = v.FieldByName("DefaultValue").String()
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			collectParamDefaults(v.Field(i), defaults, visited)
+		}
+	}
+}
+
+// buildConfigDriftReport compares node configurations between nodes of same role, against defaults and etcd overrides.
+func buildConfigDriftReport(results map[string]map[string]string, roles map[string]string, defaults, etcdConfigs map[string]string) *configDriftReport {
+	report := &configDriftReport{}
+	roleNodes := make(map[string][]string)
+	for node := range results {
+		roleNodes[roles[node]] = append(roleNodes[roles[node]], node)
+	}
+
+	for _, role := range lo.Keys(roleNodes) {
+		nodes := roleNodes[role]
+		keys := make(map[string]struct{})
+		for _, node := range nodes {
+			for key := range results[node] {
+				keys[key] = struct{}{}
+			}
+		}
+		for key := range keys {
+			values := make(map[string]string)
+			for _, node := range nodes {
+				if value, ok := results[node][key]; ok {
+					values[node] = value
+				}
+			}
+			distinct := lo.Uniq(lo.Values(values))
+			if len(nodes) > 1 && (len(distinct) > 1 || len(values) != len(nodes)) {
+				report.Nodes = append(report.Nodes, configDrift{Key: key, Kind: configDriftNode, Role: role, Values: values})
+			}
+			if def, ok := defaults[key]; ok && lo.SomeBy(distinct, func(value string) bool { return value != def }) {
+				report.Defaults = append(report.Defaults, configDrift{Key: key, Kind: configDriftDefault, Role: role, Default: def, Etcd: etcdConfigs[key], Values: values})
+			}
+		}
+	}
+
+	for key, value := range etcdConfigs {
+		values := make(map[string]string)
+		for node, configs := range results {
+			if v, ok := configs[key]; ok {
+				values[node] = v
+			}
+		}
+		report.Etcd = append(report.Etcd, configDrift{Key: key, Kind: configDriftEtcd, Default: defaults[key], Etcd: value, Values: values})
+	}
+
+	for _, drifts := range [][]configDrift{report.Nodes, report.Defaults, report.Etcd} {
+		sort.Slice(drifts, func(i, j int) bool {
+			if drifts[i].Role != drifts[j].Role {
+				return drifts[i].Role < drifts[j].Role
+			}
+			return drifts[i].Key < drifts[j].Key
+		})
+	}
+	return report
+}
+
+// formatNodeValues groups nodes by value, like `v1 [node-1,node-2]`.
+func formatNodeValues(values map[string]string) string {
+	byValue := make(map[string][]string)
+	for node, value := range values {
+		byValue[value] = append(byValue[value], node)
+	}
+	parts := make([]string, 0, len(byValue))
+	for _, value := range lo.Keys(byValue) {
+		nodes := byValue[value]
+		sort.Strings(nodes)
+		parts = append(parts, fmt.Sprintf("%q [%s]", value, strings.Join(nodes, ",")))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+func (r *configDriftReport) String() string {
+	sb := &strings.Builder{}
+	fmt.Fprintln(sb, color.RedString("=== Drift between nodes of same role (%d) ===", len(r.Nodes)))
+	for _, drift := range r.Nodes {
+		fmt.Fprintf(sb, "[%s] %s: %s\n", drift.Role, color.RedString(drift.Key), formatNodeValues(drift.Values))
+	}
+	fmt.Fprintln(sb, color.YellowString("=== Differ from defaults (%d) ===", len(r.Defaults)))
+	for _, drift := range r.Defaults {
+		source := ""
+		if drift.Etcd != "" {
+			source = color.CyanString(" (etcd)")
+		}
+		fmt.Fprintf(sb, "[%s] %s%s: default %q, %s\n", drift.Role, color.YellowString(drift.Key), source, drift.Default, formatNodeValues(drift.Values))
+	}
+	fmt.Fprintln(sb, color.CyanString("=== Overridden via etcd config source (%d) ===", len(r.Etcd)))
+	for _, drift := range r.Etcd {
+		fmt.Fprintf(sb, "%s: etcd %q, default %q, %s\n", color.CyanString(drift.Key), drift.Etcd, drift.Default, formatNodeValues(drift.Values))
+	}
+	return sb.String()
+}
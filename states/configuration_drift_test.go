@@ -0,0 +1,36 @@
+package states
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildConfigDriftReport(t *testing.T) {
+	results := map[string]map[string]string{
+		"querynode-1": {"querynodegracefulstoptimeout": "30", "commonmetricsport": "9091"},
+		"querynode-2": {"querynodegracefulstoptimeout": "60", "commonmetricsport": "9091"},
+		"datanode-3":  {"commonmetricsport": "9092"},
+	}
+	roles := map[string]string{"querynode-1": "querynode", "querynode-2": "querynode", "datanode-3": "datanode"}
+	defaults := map[string]string{"commonmetricsport": "9091", "querynodegracefulstoptimeout": "30"}
+	etcdConfigs := map[string]string{formatConfigKey("queryNode/gracefulStopTimeout"): "60"}
+
+	report := buildConfigDriftReport(results, roles, defaults, etcdConfigs)
+
+	require.Len(t, report.Nodes, 1)
+	assert.Equal(t, "querynodegracefulstoptimeout", report.Nodes[0].Key)
+	assert.Equal(t, "querynode", report.Nodes[0].Role)
+
+	require.Len(t, report.Defaults, 2)
+	assert.Equal(t, "datanode", report.Defaults[0].Role)
+	assert.Equal(t, "commonmetricsport", report.Defaults[0].Key)
+	assert.Equal(t, "60", report.Defaults[1].Etcd)
+
+	require.Len(t, report.Etcd, 1)
+	assert.Equal(t, "30", report.Etcd[0].Default)
+	assert.Len(t, report.Etcd[0].Values, 2)
+
+	assert.Equal(t, `"30" [querynode-1] "60" [querynode-2]`, formatNodeValues(report.Nodes[0].Values))
+}
@@ -2,22 +2,42 @@ package states
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cockroachdb/errors"
 
 	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/wal/adaptor"
+	"github.com/milvus-io/birdwatcher/wal/offline"
+	"github.com/milvus-io/birdwatcher/wal/utility"
+	"github.com/milvus-io/milvus/pkg/v2/proto/messagespb"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/options"
+	"github.com/milvus-io/milvus/pkg/v2/util/tsoutil"
 )
 
 type ConsumeV2Param struct {
 	framework.ParamBase `use:"consumev2" desc:"consume msgs from mq"`
-	WALName             string `name:"wal_name" default:"Pulsar" desc:"wal name to consume"`
-	PChannel            string `name:"pchannel" desc:"the pchannel to consume"`
-	Limit               string `name:"limit" default:"-1" desc:"limit the number of messages to consume"`
-	MQAddr              string `name:"mq_addr" default:"" desc:"mq address for consume mode (single address)"`
-	// TODO: sheep, support consume by messageID
+	WALName             string   `name:"wal_name" default:"Pulsar" desc:"wal name to consume"`
+	PChannel            string   `name:"pchannel" desc:"the pchannel to consume"`
+	Limit               string   `name:"limit" default:"-1" desc:"limit the number of messages to consume"`
+	MQAddr              string   `name:"mq_addr" default:"" desc:"mq address for consume mode (single address), data directory for rocksmq, storage location for woodpecker, dump file for offline"`
+	VChannel            string   `name:"vchannel" default:"" desc:"only show messages of the vchannel"`
+	MessageTypes        []string `name:"types" default:"" desc:"only show messages of the types, e.g. Insert,Delete,CreateCollection"`
+	CollectionID        int64    `name:"collection" default:"0" desc:"only show messages of the collection"`
+	MinTimeTick         int64    `name:"min_tt" default:"0" desc:"only show messages with time tick >= min_tt"`
+	MaxTimeTick         int64    `name:"max_tt" default:"0" desc:"stop once a message with time tick > max_tt is seen, 0 means no limit"`
+	Decode              bool     `name:"decode" default:"false" desc:"decode message body, insert/delete pks, row counts and ddl bodies"`
+	MaxPKs              int64    `name:"max_pks" default:"10" desc:"max primary keys shown per insert/delete message in decode mode, -1 for all"`
+	Format              string   `name:"format" default:"" desc:"output format, default or jsonl"`
+	Dump                string   `name:"dump" default:"" desc:"persist raw messages into the file, replay it later with --wal_name offline --mq_addr <file>"`
+	WALStartParam
 }
 
 func (s *InstanceState) ConsumeV2Command(ctx context.Context, p *ConsumeV2Param) error {
@@ -25,12 +45,57 @@ func (s *InstanceState) ConsumeV2Command(ctx context.Context, p *ConsumeV2Param)
 		return errors.New("pchannel must be provided")
 	}
 
+	readOption, err := p.readOption(p.WALName, p.MQAddr, p.VChannel)
+	if err != nil {
+		return err
+	}
+	filter, err := p.messageFilter()
+	if err != nil {
+		return err
+	}
+	decoder := s.newMessageDecoder(int(p.MaxPKs))
+	var output func(msg message.ImmutableMessage) error
+	switch strings.ToLower(p.Format) {
+	case "", "default":
+		output = func(msg message.ImmutableMessage) error {
+			fmt.Printf("📥%s\n", FormatMessageInfo(msg))
+			if p.Decode {
+				decoded := decoder.decode(ctx, msg)
+				if decoded != nil {
+					bs, _ := json.MarshalIndent(decoded, "    ", "  ")
+					fmt.Printf("    %s\n", bs)
+				}
+			}
+			return nil
+		}
+	case "jsonl":
+		enc := json.NewEncoder(os.Stdout)
+		output = func(msg message.ImmutableMessage) error {
+			row := newConsumedMessage(msg)
+			if p.Decode {
+				row.Body = decoder.decode(ctx, msg)
+			}
+			return enc.Encode(row)
+		}
+	default:
+		return errors.Newf("unknown format %s, expect default or jsonl", p.Format)
+	}
+	jsonl := strings.EqualFold(p.Format, "jsonl")
+
 	// Setup signal handling for Ctrl+C
 	sigChan := SetupSignalHandling()
 	defer CleanupSignalHandling(sigChan)
-	fmt.Println("Starting message consumption. Press Ctrl+C to stop...")
+	// keep stdout clean for piping in jsonl mode
+	if !jsonl {
+		fmt.Println("Starting message consumption. Press Ctrl+C to stop...")
+	}
 
-	scanner, err := NewWALScanner(ctx, p.WALName, p.PChannel, p.MQAddr)
+	var scanner *WALScanner
+	if p.Dump != "" {
+		scanner, err = NewWALDumpScanner(ctx, p.WALName, p.PChannel, p.MQAddr, readOption, p.Dump)
+	} else {
+		scanner, err = NewWALScannerWithOption(ctx, p.WALName, p.PChannel, p.MQAddr, readOption)
+	}
 	if err != nil {
 		return err
 	}
@@ -46,25 +111,208 @@ func (s *InstanceState) ConsumeV2Command(ctx context.Context, p *ConsumeV2Param)
 		}
 	}
 	count := 0
+	eof := scanner.EOF
+	var drained <-chan time.Time
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-sigChan:
 			return nil
+		case <-eof:
+			eof = nil
+			drained = time.After(offlineDrainTimeout)
+		case <-drained:
+			if !jsonl {
+				fmt.Printf("Reached end of dump, %d messages consumed.\n", count)
+			}
+			return nil
 		case msg, ok := <-scanner.MessageChan:
 			if !ok {
 				return errors.New("scanner closed")
 			}
-			if msg.MessageType().IsSelfControlled() {
+			if drained != nil {
+				drained = time.After(offlineDrainTimeout)
+			}
+			if p.MaxTimeTick > 0 && msg.TimeTick() > uint64(p.MaxTimeTick) {
+				if !jsonl {
+					fmt.Printf("Reached max time tick %d, stopping consumption.\n", p.MaxTimeTick)
+				}
+				return nil
+			}
+			if msg.MessageType().IsSelfControlled() || !filter(msg) {
 				continue
 			}
-			fmt.Printf("📥%s\n", FormatMessageInfo(msg))
+			if err := output(msg); err != nil {
+				return err
+			}
 			count++
 			if count >= limit {
-				fmt.Printf("Reached limit %d, stopping consumption.\n", limit)
+				if !jsonl {
+					fmt.Printf("Reached limit %d, stopping consumption.\n", limit)
+				}
 				return nil
 			}
 		}
 	}
 }
+
+// WALStartParam is the start position flags shared by the commands scanning a pchannel.
+type WALStartParam struct {
+	Start      string `name:"start" default:"earliest" desc:"start position: earliest, latest, msgid or timestamp"`
+	MessageID  string `name:"message_id" default:"" desc:"binary message id to start from when start=msgid, same input as parse-wal-message-id"`
+	StartAfter bool   `name:"start_after" default:"false" desc:"start after the message id instead of including it"`
+	StartTime  string `name:"start_time" default:"" desc:"start time when start=timestamp, RFC3339 or raw time tick"`
+}
+
+// readOption builds the deliver policy and the scanner side filters from the start position flags.
+// mqAddr is the dump file path for offline wal, message ids are unmarshaled with the source wal recorded in its header.
+func (p *WALStartParam) readOption(walName string, mqAddr string, vchannel string) (adaptor.ReadOption, error) {
+	readOption := adaptor.ReadOption{
+		VChannel:      vchannel,
+		DeliverPolicy: options.DeliverPolicyAll(),
+	}
+	switch strings.ToLower(p.Start) {
+	case "", "earliest":
+	case "latest":
+		readOption.DeliverPolicy = options.DeliverPolicyLatest()
+	case "msgid":
+		if p.MessageID == "" {
+			return readOption, errors.New("message_id must be provided when start=msgid")
+		}
+		idWALName := walName
+		if strings.EqualFold(walName, offlineWALName) {
+			header, err := offline.ReadHeader(mqAddr)
+			if err != nil {
+				return readOption, err
+			}
+			idWALName = header.WALName
+		}
+		msgID, err := utility.UnmarshalMessageID(idWALName, p.MessageID)
+		if err != nil {
+			return readOption, err
+		}
+		if p.StartAfter {
+			readOption.DeliverPolicy = options.DeliverPolicyStartAfter(msgID)
+		} else {
+			readOption.DeliverPolicy = options.DeliverPolicyStartFrom(msgID)
+		}
+	case "timestamp":
+		// WAL is not indexed by time, scan from the earliest position and let the scanner skip older messages.
+		tt, err := parseStartTimeTick(p.StartTime)
+		if err != nil {
+			return readOption, err
+		}
+		readOption.MessageFilter = append(readOption.MessageFilter, options.DeliverFilterTimeTickGTE(tt))
+	default:
+		return readOption, errors.Newf("unknown start position %s, expect earliest, latest, msgid or timestamp", p.Start)
+	}
+	return readOption, nil
+}
+
+// messageFilter returns the client side filter built from vchannel, type, collection and time tick flags.
+func (p *ConsumeV2Param) messageFilter() (func(message.ImmutableMessage) bool, error) {
+	types := make(map[message.MessageType]struct{})
+	for _, name := range p.MessageTypes {
+		if name == "" {
+			continue
+		}
+		msgType, err := parseMessageType(name)
+		if err != nil {
+			return nil, err
+		}
+		types[msgType] = struct{}{}
+	}
+	return func(msg message.ImmutableMessage) bool {
+		if p.VChannel != "" && msg.VChannel() != p.VChannel {
+			return false
+		}
+		if len(types) > 0 {
+			if _, ok := types[msg.MessageType()]; !ok {
+				return false
+			}
+		}
+		if p.CollectionID > 0 && collectionIDOfVChannel(msg.VChannel()) != p.CollectionID {
+			return false
+		}
+		return msg.TimeTick() >= uint64(p.MinTimeTick)
+	}, nil
+}
+
+// parseStartTimeTick accepts RFC3339 time or a raw hybrid timestamp.
+func parseStartTimeTick(input string) (uint64, error) {
+	if input == "" {
+		return 0, errors.New("start_time must be provided when start=timestamp")
+	}
+	if tt, err := strconv.ParseUint(input, 10, 64); err == nil {
+		return tt, nil
+	}
+	t, err := time.Parse(time.RFC3339, input)
+	if err != nil {
+		return 0, errors.Newf("invalid start_time %s, expect RFC3339 or time tick", input)
+	}
+	return tsoutil.ComposeTSByTime(t, 0), nil
+}
+
+func parseMessageType(name string) (message.MessageType, error) {
+	for typeName, value := range messagespb.MessageType_value {
+		if strings.EqualFold(typeName, name) {
+			return message.MessageType(value), nil
+		}
+	}
+	return 0, errors.Newf("unknown message type %s", name)
+}
+
+// collectionIDOfVChannel extracts collection id from vchannel name like `by-dev-rootcoord-dml_0_449999v0`.
+func collectionIDOfVChannel(vchannel string) int64 {
+	idx := strings.LastIndex(vchannel, "_")
+	if idx < 0 {
+		return 0
+	}
+	raw := vchannel[idx+1:]
+	if v := strings.LastIndex(raw, "v"); v > 0 {
+		raw = raw[:v]
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// consumedMessage is the json lines view of a message.
+type consumedMessage struct {
+	Type      string           `json:"type"`
+	VChannel  string           `json:"vchannel"`
+	TimeTick  uint64           `json:"time_tick"`
+	Time      time.Time        `json:"time"`
+	MessageID string           `json:"message_id"`
+	Replicate *replicateHeader `json:"replicate,omitempty"`
+	Size      int              `json:"size"`
+	Body      any              `json:"body,omitempty"`
+}
+
+type replicateHeader struct {
+	VChannel  string `json:"vchannel"`
+	TimeTick  uint64 `json:"time_tick"`
+	MessageID string `json:"message_id"`
+}
+
+func newConsumedMessage(msg message.ImmutableMessage) *consumedMessage {
+	row := &consumedMessage{
+		Type:      msg.MessageType().String(),
+		VChannel:  msg.VChannel(),
+		TimeTick:  msg.TimeTick(),
+		Time:      tsoutil.PhysicalTime(msg.TimeTick()),
+		MessageID: msg.MessageID().String(),
+		Size:      msg.EstimateSize(),
+	}
+	if h := msg.ReplicateHeader(); h != nil {
+		row.Replicate = &replicateHeader{
+			VChannel:  h.VChannel,
+			TimeTick:  h.TimeTick,
+			MessageID: h.MessageID.String(),
+		}
+	}
+	return row
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/cockroachdb/errors"
 
@@ -16,6 +17,8 @@ type CmpPChannelsParam struct {
 	WALName             string   `name:"wal_name" default:"Pulsar" desc:"wal name to consume"`
 	PChannels           []string `name:"pchannels" default:"" desc:"the pchannels to compare"`
 	MQAddrs             []string `name:"mq_addrs" default:"" desc:"mq addresses for cmp (multiple addresses, one per pchannel)"`
+	Report              bool     `name:"report" default:"false" desc:"align messages and report all divergences when stopped instead of stopping at the first inconsistency"`
+	MaxDiffs            int64    `name:"max_diffs" default:"10" desc:"number of differences shown per category in report mode"`
 }
 
 func (s *InstanceState) CmpPChannelsCommand(ctx context.Context, p *CmpPChannelsParam) error {
@@ -45,6 +48,10 @@ func (s *InstanceState) CmpPChannelsCommand(ctx context.Context, p *CmpPChannels
 		defer scanner.Scanner.Close()
 	}
 
+	if p.Report {
+		return s.compareDivergence(ctx, scanners, pchannels, sigChan, int(p.MaxDiffs))
+	}
+
 	// Start the simplified comparison process
 	return s.comparePChannelsMessage(ctx, scanners, pchannels, sigChan)
 }
@@ -78,6 +85,7 @@ func (s *InstanceState) comparePChannelsMessage(
 		readyForComparison[pchannelName] = false
 	}
 
+	lastProgress := time.Now()
 	// Process messages and perform comparison
 	for {
 		select {
@@ -108,6 +116,7 @@ func (s *InstanceState) comparePChannelsMessage(
 					}
 
 					// Store the current message for this pchannel
+					lastProgress = time.Now()
 					currentMessages[pchannelName] = msg
 					messageCounters[pchannelName]++
 					readyForComparison[pchannelName] = true
@@ -118,6 +127,11 @@ func (s *InstanceState) comparePChannelsMessage(
 				}
 			}
 
+			if !allReady && allExhausted(scanners) && time.Since(lastProgress) > offlineDrainTimeout {
+				fmt.Printf("Reached end of dumps, message counters: %v\n", messageCounters)
+				return nil
+			}
+
 			// Check if all pchannels are ready for comparison
 			if allReady {
 				for _, pchannelName := range pchannelNames {
@@ -240,3 +254,18 @@ func (s *InstanceState) printInconsistentCurrentMessages(
 	}
 	fmt.Println("=============================")
 }
+
+// allExhausted reports whether every scanner is an offline wal which has reached the end of its dump.
+func allExhausted(scanners []*WALScanner) bool {
+	for _, scanner := range scanners {
+		if scanner.EOF == nil {
+			return false
+		}
+		select {
+		case <-scanner.EOF:
+		default:
+			return false
+		}
+	}
+	return true
+}
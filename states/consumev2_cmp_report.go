@@ -0,0 +1,398 @@
+package states
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
+)
+
+// divergence categories of the report mode.
+const (
+	divergenceMissing    = "missing"    // message on the reference pchannel but not on the compared one
+	divergenceExtra      = "extra"      // message on the compared pchannel but not on the reference one
+	divergenceMismatched = "mismatched" // same replicate key but different message type
+)
+
+var divergenceCategories = []string{divergenceMissing, divergenceExtra, divergenceMismatched}
+
+// alignedMessage is a message with the alignment key shared between primary and replica:
+// the replicate header message id and time tick if replicated, otherwise its own.
+type alignedMessage struct {
+	msg      message.ImmutableMessage
+	key      string
+	timeTick uint64
+	vchannel string
+	msgType  string
+}
+
+func newAlignedMessage(msg message.ImmutableMessage) *alignedMessage {
+	if h := msg.ReplicateHeader(); h != nil {
+		return &alignedMessage{msg: msg, key: h.MessageID.String(), timeTick: h.TimeTick, vchannel: h.VChannel, msgType: msg.MessageType().String()}
+	}
+	return &alignedMessage{msg: msg, key: msg.MessageID().String(), timeTick: msg.TimeTick(), vchannel: msg.VChannel(), msgType: msg.MessageType().String()}
+}
+
+// maxAlignerPending caps messages queued on one side of an aligner, the oldest ones are dropped beyond it
+// so a long diverged or stalled run doesn't grow memory without bound.
+const maxAlignerPending = 100000
+
+type divergenceKey struct {
+	category string
+	vchannel string
+	msgType  string
+}
+
+// missingRange is a run of consecutive reference messages absent from the compared pchannel.
+type missingRange struct {
+	from  *alignedMessage
+	to    *alignedMessage
+	count int
+}
+
+type divergence struct {
+	category  string
+	reference message.ImmutableMessage
+	compared  message.ImmutableMessage
+}
+
+// pchannelAligner merge-joins the reference pchannel with one compared pchannel by replicate time tick,
+// messages sharing a time tick are matched by replicate message id, so a gap only affects the messages in it.
+type pchannelAligner struct {
+	pchannel     string
+	maxDiffs     int
+	ref          []*alignedMessage
+	cmp          []*alignedMessage
+	lastRefTT    uint64
+	lastCmpTT    uint64
+	matched      int
+	counts       map[divergenceKey]int
+	samples      map[string][]divergence
+	pendingRef   int
+	pendingCmp   int
+	ignoredAlter int
+	// messages dropped from the queue head after exceeding maxAlignerPending
+	overflowRef int
+	overflowCmp int
+	ranges      []missingRange
+	rangeOpen   bool
+}
+
+func newPChannelAligner(pchannel string, maxDiffs int) *pchannelAligner {
+	return &pchannelAligner{
+		pchannel: pchannel,
+		maxDiffs: maxDiffs,
+		counts:   make(map[divergenceKey]int),
+		samples:  make(map[string][]divergence),
+	}
+}
+
+func (a *pchannelAligner) pushReference(msg message.ImmutableMessage) {
+	a.pushRef(newAlignedMessage(msg))
+}
+
+func (a *pchannelAligner) pushRef(m *alignedMessage) {
+	a.ref = append(a.ref, m)
+	a.lastRefTT = max(a.lastRefTT, m.timeTick)
+	a.advance(false)
+	for len(a.ref) > maxAlignerPending {
+		a.ref = a.ref[1:]
+		a.overflowRef++
+	}
+}
+
+func (a *pchannelAligner) pushCompared(msg message.ImmutableMessage) {
+	// the secondary milvus may append AlterReplicateConfig itself, which has no counterpart on the reference.
+	if msg.MessageType() == message.MessageTypeAlterReplicateConfig && msg.ReplicateHeader() == nil {
+		a.ignoredAlter++
+		return
+	}
+	a.pushCmp(newAlignedMessage(msg))
+}
+
+func (a *pchannelAligner) pushCmp(m *alignedMessage) {
+	a.cmp = append(a.cmp, m)
+	a.lastCmpTT = max(a.lastCmpTT, m.timeTick)
+	a.advance(false)
+	for len(a.cmp) > maxAlignerPending {
+		a.cmp = a.cmp[1:]
+		a.overflowCmp++
+	}
+}
+
+// advance consumes the heads of both queues, a time tick group is only matched once it's complete on both sides
+// unless final is set.
+func (a *pchannelAligner) advance(final bool) {
+	for len(a.ref) > 0 && len(a.cmp) > 0 {
+		refTT, cmpTT := a.ref[0].timeTick, a.cmp[0].timeTick
+		switch {
+		case refTT < cmpTT:
+			a.record(divergenceMissing, a.ref[0], nil)
+			a.ref = a.ref[1:]
+		case cmpTT < refTT:
+			a.record(divergenceExtra, nil, a.cmp[0])
+			a.cmp = a.cmp[1:]
+		default:
+			refEnd := groupEnd(a.ref)
+			cmpEnd := groupEnd(a.cmp)
+			if !final && (refEnd == len(a.ref) || cmpEnd == len(a.cmp)) {
+				return
+			}
+			a.matchGroup(a.ref[:refEnd], a.cmp[:cmpEnd])
+			a.ref = a.ref[refEnd:]
+			a.cmp = a.cmp[cmpEnd:]
+		}
+	}
+	// time ticks are monotonic on a pchannel, messages older than the last one seen on the empty side
+	// have no counterpart coming, so they are settled instead of queued.
+	if len(a.cmp) == 0 {
+		for len(a.ref) > 0 && a.ref[0].timeTick < a.lastCmpTT {
+			a.record(divergenceMissing, a.ref[0], nil)
+			a.ref = a.ref[1:]
+		}
+	}
+	if len(a.ref) == 0 {
+		for len(a.cmp) > 0 && a.cmp[0].timeTick < a.lastRefTT {
+			a.record(divergenceExtra, nil, a.cmp[0])
+			a.cmp = a.cmp[1:]
+		}
+	}
+}
+
+// finish flushes the queues, messages newer than the last time tick seen on the other side are still pending.
+func (a *pchannelAligner) finish() {
+	a.advance(true)
+	for _, m := range a.ref {
+		if m.timeTick <= a.lastCmpTT {
+			a.record(divergenceMissing, m, nil)
+		} else {
+			a.pendingRef++
+		}
+	}
+	for _, m := range a.cmp {
+		if m.timeTick <= a.lastRefTT {
+			a.record(divergenceExtra, nil, m)
+		} else {
+			a.pendingCmp++
+		}
+	}
+	a.ref, a.cmp = nil, nil
+}
+
+func (a *pchannelAligner) matchGroup(refs, cmps []*alignedMessage) {
+	byKey := make(map[string][]*alignedMessage, len(cmps))
+	for _, m := range cmps {
+		byKey[m.key] = append(byKey[m.key], m)
+	}
+	for _, r := range refs {
+		candidates := byKey[r.key]
+		if len(candidates) == 0 {
+			a.record(divergenceMissing, r, nil)
+			continue
+		}
+		c := candidates[0]
+		byKey[r.key] = candidates[1:]
+		// the reference message exists on the compared pchannel, the missing range ends here
+		a.rangeOpen = false
+		if r.msgType != c.msgType {
+			a.record(divergenceMismatched, r, c)
+			continue
+		}
+		a.matched++
+	}
+	for _, m := range cmps {
+		if left := byKey[m.key]; len(left) > 0 && left[0] == m {
+			a.record(divergenceExtra, nil, m)
+			byKey[m.key] = left[1:]
+		}
+	}
+}
+
+func (a *pchannelAligner) record(category string, ref, cmp *alignedMessage) {
+	d := divergence{category: category}
+	m := ref
+	if ref != nil {
+		d.reference = ref.msg
+	}
+	if cmp != nil {
+		d.compared = cmp.msg
+		if m == nil {
+			m = cmp
+		}
+	}
+	a.counts[divergenceKey{category: category, vchannel: m.vchannel, msgType: m.msgType}]++
+	if category == divergenceMissing {
+		if a.rangeOpen {
+			last := &a.ranges[len(a.ranges)-1]
+			last.to = ref
+			last.count++
+		} else {
+			a.ranges = append(a.ranges, missingRange{from: ref, to: ref, count: 1})
+			a.rangeOpen = true
+		}
+	}
+	if len(a.samples[category]) < a.maxDiffs {
+		a.samples[category] = append(a.samples[category], d)
+	}
+}
+
+// groupEnd returns the index of the first message with a time tick different from the head.
+func groupEnd(msgs []*alignedMessage) int {
+	for i, m := range msgs {
+		if m.timeTick != msgs[0].timeTick {
+			return i
+		}
+	}
+	return len(msgs)
+}
+
+// compareDivergence reads all pchannels concurrently and aligns every pchannel against the first one,
+// the report is printed when consuming stops (Ctrl+C, context done or end of offline dumps).
+func (s *InstanceState) compareDivergence(
+	ctx context.Context,
+	scanners []*WALScanner,
+	pchannelNames []string,
+	sigChan chan os.Signal,
+	maxDiffs int,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type indexedMessage struct {
+		index int
+		msg   message.ImmutableMessage
+	}
+	merged := make(chan indexedMessage)
+	for i, scanner := range scanners {
+		go func(i int, ch <-chan message.ImmutableMessage) {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg, ok := <-ch:
+					if !ok {
+						return
+					}
+					select {
+					case merged <- indexedMessage{index: i, msg: msg}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}(i, scanner.MessageChan)
+	}
+
+	aligners := make([]*pchannelAligner, 0, len(pchannelNames)-1)
+	for _, name := range pchannelNames[1:] {
+		aligners = append(aligners, newPChannelAligner(name, maxDiffs))
+	}
+	counts := make([]int, len(pchannelNames))
+	lastProgress := time.Now()
+	ticker := time.NewTicker(offlineDrainTimeout / 2)
+	defer ticker.Stop()
+
+	var stopErr error
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			stopErr = ctx.Err()
+			break loop
+		case <-sigChan:
+			break loop
+		case <-ticker.C:
+			if allExhausted(scanners) && time.Since(lastProgress) > offlineDrainTimeout {
+				break loop
+			}
+		case im := <-merged:
+			lastProgress = time.Now()
+			if im.msg.MessageType().IsSelfControlled() {
+				continue
+			}
+			counts[im.index]++
+			if im.index == 0 {
+				for _, a := range aligners {
+					a.pushReference(im.msg)
+				}
+			} else {
+				aligners[im.index-1].pushCompared(im.msg)
+			}
+		}
+	}
+
+	for _, a := range aligners {
+		a.finish()
+	}
+	printDivergenceReport(pchannelNames, counts, aligners)
+	return stopErr
+}
+
+func printDivergenceReport(pchannelNames []string, counts []int, aligners []*pchannelAligner) {
+	fmt.Println("=== DIVERGENCE REPORT ===")
+	fmt.Printf("Reference pchannel %s, %d messages consumed\n", pchannelNames[0], counts[0])
+	for i, a := range aligners {
+		total := map[string]int{}
+		for key, cnt := range a.counts {
+			total[key.category] += cnt
+		}
+		fmt.Printf("\nPChannel %s: %d messages consumed, matched %d, missing %d, extra %d, mismatched %d, pending %d/%d (reference/compared)\n",
+			a.pchannel, counts[i+1], a.matched,
+			total[divergenceMissing], total[divergenceExtra], total[divergenceMismatched],
+			a.pendingRef, a.pendingCmp)
+		if a.overflowRef > 0 || a.overflowCmp > 0 {
+			fmt.Printf("  %d/%d (reference/compared) messages dropped unaligned after exceeding %d pending messages\n", a.overflowRef, a.overflowCmp, maxAlignerPending)
+		}
+		if a.ignoredAlter > 0 {
+			fmt.Printf("  %d AlterReplicateConfig messages appended by the compared cluster ignored\n", a.ignoredAlter)
+		}
+		if len(a.counts) == 0 {
+			fmt.Println("  ✅ no divergence")
+			continue
+		}
+
+		keys := make([]divergenceKey, 0, len(a.counts))
+		for key := range a.counts {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].category != keys[j].category {
+				return keys[i].category < keys[j].category
+			}
+			if keys[i].vchannel != keys[j].vchannel {
+				return keys[i].vchannel < keys[j].vchannel
+			}
+			return keys[i].msgType < keys[j].msgType
+		})
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  Category\tVChannel\tType\tCount")
+		for _, key := range keys {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%d\n", key.category, key.vchannel, key.msgType, a.counts[key])
+		}
+		w.Flush()
+
+		for _, category := range divergenceCategories {
+			samples := a.samples[category]
+			if len(samples) == 0 {
+				continue
+			}
+			fmt.Printf("  First %d %s:\n", len(samples), category)
+			for _, d := range samples {
+				var parts []string
+				if d.reference != nil {
+					parts = append(parts, "ref "+FormatMessageInfo(d.reference))
+				}
+				if d.compared != nil {
+					parts = append(parts, "cmp "+FormatMessageInfo(d.compared))
+				}
+				fmt.Printf("    ❌ %s\n", strings.Join(parts, " <-> "))
+			}
+		}
+	}
+	fmt.Println("=========================")
+}
@@ -0,0 +1,55 @@
+package states
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAlignedMessage(id int, timeTick uint64) *alignedMessage {
+	return &alignedMessage{key: fmt.Sprintf("id-%d", id), timeTick: timeTick, vchannel: "v0", msgType: "Insert"}
+}
+
+func TestPChannelAlignerMissingRanges(t *testing.T) {
+	a := newPChannelAligner("target", 10)
+	// reference 1..8, compared misses 2,3 and 6,7
+	for i := 1; i <= 8; i++ {
+		a.pushRef(testAlignedMessage(i, uint64(i)))
+		if i != 2 && i != 3 && i != 6 && i != 7 {
+			a.pushCmp(testAlignedMessage(i, uint64(i)))
+		}
+	}
+	a.finish()
+
+	assert.Equal(t, 4, a.matched)
+	require.Len(t, a.ranges, 2)
+	assert.EqualValues(t, 2, a.ranges[0].from.timeTick)
+	assert.EqualValues(t, 3, a.ranges[0].to.timeTick)
+	assert.Equal(t, 2, a.ranges[0].count)
+	assert.EqualValues(t, 6, a.ranges[1].from.timeTick)
+	assert.EqualValues(t, 7, a.ranges[1].to.timeTick)
+	assert.Equal(t, 2, a.ranges[1].count)
+	assert.Equal(t, 4, a.counts[divergenceKey{category: divergenceMissing, vchannel: "v0", msgType: "Insert"}])
+}
+
+func TestPChannelAlignerSettlesQueues(t *testing.T) {
+	a := newPChannelAligner("target", 10)
+	a.pushCmp(testAlignedMessage(100, 100))
+	// compared already passed these time ticks, they are missing and not queued
+	for i := 1; i <= 5; i++ {
+		a.pushRef(testAlignedMessage(i, uint64(i)))
+	}
+	assert.Empty(t, a.ref)
+	require.Len(t, a.ranges, 1)
+	assert.Equal(t, 5, a.ranges[0].count)
+
+	// compared stalled, reference queue is capped
+	a = newPChannelAligner("target", 10)
+	for i := 1; i <= maxAlignerPending+10; i++ {
+		a.pushRef(testAlignedMessage(i, uint64(i)))
+	}
+	assert.Len(t, a.ref, maxAlignerPending)
+	assert.Equal(t, 10, a.overflowRef)
+}
@@ -0,0 +1,237 @@
+package states
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/milvus-io/birdwatcher/states/etcd/common"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
+)
+
+// decodedBody is the decoded view of a message body, shared by the default and jsonl output.
+type decodedBody struct {
+	CollectionID int64          `json:"collection_id,omitempty"`
+	PartitionIDs []int64        `json:"partition_ids,omitempty"`
+	Rows         uint64         `json:"rows,omitempty"`
+	PKField      string         `json:"pk_field,omitempty"`
+	PKs          []any          `json:"pks,omitempty"`
+	PKsTruncated bool           `json:"pks_truncated,omitempty"`
+	Name         string         `json:"name,omitempty"`
+	Fields       []string       `json:"fields,omitempty"`
+	TxnMessages  []*decodedBody `json:"txn_messages,omitempty"`
+	Type         string         `json:"type,omitempty"`
+	Error        string         `json:"error,omitempty"`
+}
+
+// messageDecoder decodes message bodies, collection schemas are read from meta once to locate insert pk field.
+type messageDecoder struct {
+	state   *InstanceState
+	maxPKs  int
+	schemas map[int64]*schemapb.CollectionSchema
+}
+
+func (s *InstanceState) newMessageDecoder(maxPKs int) *messageDecoder {
+	return &messageDecoder{
+		state:   s,
+		maxPKs:  maxPKs,
+		schemas: make(map[int64]*schemapb.CollectionSchema),
+	}
+}
+
+// decode returns nil for message types without a body worth rendering.
+func (d *messageDecoder) decode(ctx context.Context, msg message.ImmutableMessage) *decodedBody {
+	switch msg.MessageType() {
+	case message.MessageTypeInsert:
+		return d.decodeInsert(ctx, msg)
+	case message.MessageTypeDelete:
+		return d.decodeDelete(msg)
+	case message.MessageTypeCreateCollection:
+		createMsg, err := message.AsImmutableCreateCollectionMessageV1(msg)
+		if err != nil {
+			return &decodedBody{Error: err.Error()}
+		}
+		result := &decodedBody{
+			CollectionID: createMsg.Header().GetCollectionId(),
+			PartitionIDs: createMsg.Header().GetPartitionIds(),
+		}
+		body, err := createMsg.Body()
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.Name = body.GetCollectionName()
+		schema := &schemapb.CollectionSchema{}
+		if err := proto.Unmarshal(body.GetSchema(), schema); err == nil {
+			for _, field := range schema.GetFields() {
+				result.Fields = append(result.Fields, field.GetName()+":"+field.GetDataType().String())
+			}
+		}
+		return result
+	case message.MessageTypeDropCollection:
+		dropMsg, err := message.AsImmutableDropCollectionMessageV1(msg)
+		if err != nil {
+			return &decodedBody{Error: err.Error()}
+		}
+		result := &decodedBody{CollectionID: dropMsg.Header().GetCollectionId()}
+		if body, err := dropMsg.Body(); err == nil {
+			result.Name = body.GetCollectionName()
+		}
+		return result
+	case message.MessageTypeCreatePartition:
+		createMsg, err := message.AsImmutableCreatePartitionMessageV1(msg)
+		if err != nil {
+			return &decodedBody{Error: err.Error()}
+		}
+		result := &decodedBody{
+			CollectionID: createMsg.Header().GetCollectionId(),
+			PartitionIDs: []int64{createMsg.Header().GetPartitionId()},
+		}
+		if body, err := createMsg.Body(); err == nil {
+			result.Name = body.GetPartitionName()
+		}
+		return result
+	case message.MessageTypeDropPartition:
+		dropMsg, err := message.AsImmutableDropPartitionMessageV1(msg)
+		if err != nil {
+			return &decodedBody{Error: err.Error()}
+		}
+		result := &decodedBody{
+			CollectionID: dropMsg.Header().GetCollectionId(),
+			PartitionIDs: []int64{dropMsg.Header().GetPartitionId()},
+		}
+		if body, err := dropMsg.Body(); err == nil {
+			result.Name = body.GetPartitionName()
+		}
+		return result
+	case message.MessageTypeTxn:
+		txnMsg := message.AsImmutableTxnMessage(msg)
+		if txnMsg == nil {
+			return nil
+		}
+		result := &decodedBody{}
+		if err := txnMsg.RangeOver(func(sub message.ImmutableMessage) error {
+			decoded := d.decode(ctx, sub)
+			if decoded == nil {
+				decoded = &decodedBody{}
+			}
+			decoded.Type = sub.MessageType().String()
+			result.TxnMessages = append(result.TxnMessages, decoded)
+			return nil
+		}); err != nil {
+			result.Error = err.Error()
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+func (d *messageDecoder) decodeInsert(ctx context.Context, msg message.ImmutableMessage) *decodedBody {
+	insertMsg, err := message.AsImmutableInsertMessageV1(msg)
+	if err != nil {
+		return &decodedBody{Error: err.Error()}
+	}
+	header := insertMsg.Header()
+	result := &decodedBody{CollectionID: header.GetCollectionId()}
+	for _, partition := range header.GetPartitions() {
+		result.PartitionIDs = append(result.PartitionIDs, partition.GetPartitionId())
+		result.Rows += partition.GetRows()
+	}
+	body, err := insertMsg.Body()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if result.Rows == 0 {
+		result.Rows = body.GetNumRows()
+	}
+
+	schema := d.getSchema(ctx, header.GetCollectionId())
+	if schema == nil {
+		return result
+	}
+	var pkField *schemapb.FieldSchema
+	for _, field := range schema.GetFields() {
+		if field.GetIsPrimaryKey() {
+			pkField = field
+			break
+		}
+	}
+	if pkField == nil {
+		return result
+	}
+	result.PKField = pkField.GetName()
+	for _, fieldData := range body.GetFieldsData() {
+		if fieldData.GetFieldId() != pkField.GetFieldID() {
+			continue
+		}
+		switch pkField.GetDataType() {
+		case schemapb.DataType_Int64:
+			result.PKs, result.PKsTruncated = limitPKs(fieldData.GetScalars().GetLongData().GetData(), d.maxPKs)
+		case schemapb.DataType_VarChar:
+			result.PKs, result.PKsTruncated = limitPKs(fieldData.GetScalars().GetStringData().GetData(), d.maxPKs)
+		}
+	}
+	return result
+}
+
+func (d *messageDecoder) decodeDelete(msg message.ImmutableMessage) *decodedBody {
+	deleteMsg, err := message.AsImmutableDeleteMessageV1(msg)
+	if err != nil {
+		return &decodedBody{Error: err.Error()}
+	}
+	result := &decodedBody{
+		CollectionID: deleteMsg.Header().GetCollectionId(),
+		Rows:         deleteMsg.Header().GetRows(),
+	}
+	body, err := deleteMsg.Body()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if body.GetPartitionID() > 0 {
+		result.PartitionIDs = []int64{body.GetPartitionID()}
+	}
+	if result.Rows == 0 {
+		result.Rows = uint64(body.GetNumRows())
+	}
+	pks := body.GetPrimaryKeys()
+	switch {
+	case pks.GetIntId() != nil:
+		result.PKs, result.PKsTruncated = limitPKs(pks.GetIntId().GetData(), d.maxPKs)
+	case pks.GetStrId() != nil:
+		result.PKs, result.PKsTruncated = limitPKs(pks.GetStrId().GetData(), d.maxPKs)
+	}
+	return result
+}
+
+// getSchema returns nil if the collection meta cannot be found, e.g. the collection is already dropped.
+func (d *messageDecoder) getSchema(ctx context.Context, collectionID int64) *schemapb.CollectionSchema {
+	if schema, ok := d.schemas[collectionID]; ok {
+		return schema
+	}
+	var schema *schemapb.CollectionSchema
+	if d.state != nil && d.state.client != nil {
+		collection, err := common.GetCollectionByIDVersion(ctx, d.state.client, d.state.basePath, collectionID)
+		if err == nil {
+			schema = collection.GetProto().GetSchema()
+		}
+	}
+	d.schemas[collectionID] = schema
+	return schema
+}
+
+func limitPKs[T any](data []T, limit int) ([]any, bool) {
+	truncated := false
+	if limit >= 0 && len(data) > limit {
+		data = data[:limit]
+		truncated = true
+	}
+	result := make([]any, 0, len(data))
+	for _, v := range data {
+		result = append(result, v)
+	}
+	return result, truncated
+}
@@ -0,0 +1,152 @@
+package common
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/datapb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/etcdpb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/indexpb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/querypb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/streamingpb"
+)
+
+// Meta categories of decodable keys.
+const (
+	MetaCategoryDatabase          = "database"
+	MetaCategoryCollection        = "collection"
+	MetaCategoryPartition         = "partition"
+	MetaCategoryField             = "field"
+	MetaCategoryFunction          = "function"
+	MetaCategoryAlias             = "alias"
+	MetaCategorySegment           = "segment"
+	MetaCategoryBinlog            = "binlog"
+	MetaCategoryChannelCheckpoint = "channel-checkpoint"
+	MetaCategoryChannelWatch      = "channel-watch"
+	MetaCategoryCompaction        = "compaction"
+	MetaCategoryImport            = "import"
+	MetaCategorySnapshot          = "snapshot"
+	MetaCategoryIndex             = "index"
+	MetaCategorySegmentIndex      = "segment-index"
+	MetaCategoryStatsTask         = "stats-task"
+	MetaCategoryReplica           = "replica"
+	MetaCategoryLoad              = "load"
+	MetaCategoryResourceGroup     = "resource-group"
+	MetaCategoryStreaming         = "streaming"
+)
+
+// MetaDecoder describes the proto message stored under a meta key prefix.
+type MetaDecoder struct {
+	// Prefix is relative to the meta path, e.g. `datacoord-meta/s`.
+	Prefix   string
+	Category string
+	New      func() proto.Message
+}
+
+func newMessage[T any, P interface {
+	*T
+	proto.Message
+}]() func() proto.Message {
+	return func() proto.Message { return P(new(T)) }
+}
+
+var metaDecoders = []MetaDecoder{
+	{Prefix: DataBaseMetaPrefix, Category: MetaCategoryDatabase, New: newMessage[etcdpb.DatabaseInfo]()},
+	{Prefix: CollectionMetaPrefix, Category: MetaCategoryCollection, New: newMessage[etcdpb.CollectionInfo]()},
+	{Prefix: DBCollectionMetaPrefix, Category: MetaCategoryCollection, New: newMessage[etcdpb.CollectionInfo]()},
+	{Prefix: path.Join(RCPrefix, PartitionPrefix), Category: MetaCategoryPartition, New: newMessage[etcdpb.PartitionInfo]()},
+	{Prefix: FieldMetaPrefix, Category: MetaCategoryField, New: newMessage[schemapb.FieldSchema]()},
+	{Prefix: StructArrayFieldMetaPrefix, Category: MetaCategoryField, New: newMessage[schemapb.StructArrayFieldSchema]()},
+	{Prefix: FunctionMetaPrefix, Category: MetaCategoryFunction, New: newMessage[schemapb.FunctionSchema]()},
+	{Prefix: AliasPrefixBefore210, Category: MetaCategoryAlias, New: newMessage[etcdpb.AliasInfo]()},
+	{Prefix: AliasPrefixWithoutDB, Category: MetaCategoryAlias, New: newMessage[etcdpb.AliasInfo]()},
+	{Prefix: AliasPrefixDB, Category: MetaCategoryAlias, New: newMessage[etcdpb.AliasInfo]()},
+
+	{Prefix: path.Join(DCPrefix, SegmentMetaPrefix), Category: MetaCategorySegment, New: newMessage[datapb.SegmentInfo]()},
+	{Prefix: path.Join(DCPrefix, "binlog"), Category: MetaCategoryBinlog, New: newMessage[datapb.FieldBinlog]()},
+	{Prefix: path.Join(DCPrefix, "deltalog"), Category: MetaCategoryBinlog, New: newMessage[datapb.FieldBinlog]()},
+	{Prefix: path.Join(DCPrefix, SegmentStatsMetaPrefix), Category: MetaCategoryBinlog, New: newMessage[datapb.FieldBinlog]()},
+	{Prefix: path.Join(DCPrefix, SegmentBM25LogPrefix), Category: MetaCategoryBinlog, New: newMessage[datapb.FieldBinlog]()},
+	{Prefix: path.Join(DCPrefix, ChannelCheckpointPrefix), Category: MetaCategoryChannelCheckpoint, New: newMessage[msgpb.MsgPosition]()},
+	{Prefix: ChannelWatchPrefix, Category: MetaCategoryChannelWatch, New: newMessage[datapb.ChannelWatchInfo]()},
+	{Prefix: path.Join(DCPrefix, CompactionTaskPrefix), Category: MetaCategoryCompaction, New: newMessage[datapb.CompactionTask]()},
+	{Prefix: ImportJobPrefix, Category: MetaCategoryImport, New: newMessage[datapb.ImportJob]()},
+	{Prefix: PreImportTaskPrefix, Category: MetaCategoryImport, New: newMessage[datapb.PreImportTask]()},
+	{Prefix: ImportTaskPrefix, Category: MetaCategoryImport, New: newMessage[datapb.ImportTaskV2]()},
+	{Prefix: path.Join(DCPrefix, DCSnapshotPrefix), Category: MetaCategorySnapshot, New: newMessage[datapb.SnapshotInfo]()},
+	{Prefix: path.Join(DCPrefix, StatsTaskPrefix), Category: MetaCategoryStatsTask, New: newMessage[indexpb.StatsTask]()},
+	{Prefix: IndexPrefix, Category: MetaCategoryIndex, New: newMessage[indexpb.FieldIndex]()},
+	{Prefix: SegmentIndexPrefix, Category: MetaCategorySegmentIndex, New: newMessage[indexpb.SegmentIndex]()},
+
+	{Prefix: ReplicaPrefix, Category: MetaCategoryReplica, New: newMessage[querypb.Replica]()},
+	{Prefix: CollectionLoadPrefixV2, Category: MetaCategoryLoad, New: newMessage[querypb.CollectionLoadInfo]()},
+	{Prefix: PartitionLoadedPrefix, Category: MetaCategoryLoad, New: newMessage[querypb.PartitionLoadInfo]()},
+	{Prefix: strings.TrimSuffix(ResourceGroupPrefix, "/"), Category: MetaCategoryResourceGroup, New: newMessage[querypb.ResourceGroup]()},
+
+	{Prefix: strings.TrimSuffix(walDistributionPrefix, "/"), Category: MetaCategoryStreaming, New: newMessage[streamingpb.PChannelMeta]()},
+	{Prefix: strings.TrimSuffix(walBroadcastPrefix, "/"), Category: MetaCategoryStreaming, New: newMessage[streamingpb.BroadcastTask]()},
+	{Prefix: replicateConfiguration, Category: MetaCategoryStreaming, New: newMessage[streamingpb.ReplicateConfigurationMeta]()},
+	{Prefix: strings.TrimSuffix(replicatePChannel, "/"), Category: MetaCategoryStreaming, New: newMessage[streamingpb.ReplicatePChannelMeta]()},
+}
+
+func init() {
+	// longer prefix wins, e.g. `root-coord/database/db-info` before `root-coord/database`.
+	sort.SliceStable(metaDecoders, func(i, j int) bool {
+		return len(metaDecoders[i].Prefix) > len(metaDecoders[j].Prefix)
+	})
+}
+
+// MetaDecoders returns all known meta prefixes.
+func MetaDecoders() []MetaDecoder {
+	return metaDecoders
+}
+
+// MetaCategories returns the distinct meta categories in sorted order.
+func MetaCategories() []string {
+	set := make(map[string]struct{})
+	for _, d := range metaDecoders {
+		set[d.Category] = struct{}{}
+	}
+	result := make([]string, 0, len(set))
+	for category := range set {
+		result = append(result, category)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// LookupMetaDecoder finds the decoder of a key relative to the meta path,
+// rootcoord snapshot keys (`snapshots/...`) are looked up by the key they snapshot.
+func LookupMetaDecoder(relKey string) (MetaDecoder, bool) {
+	relKey = strings.TrimPrefix(relKey, "/")
+	relKey = strings.TrimPrefix(relKey, SnapshotPrefix+"/")
+	for _, d := range metaDecoders {
+		if relKey == d.Prefix || strings.HasPrefix(relKey, d.Prefix+"/") {
+			return d, true
+		}
+	}
+	return MetaDecoder{}, false
+}
+
+// DecodeMetaValue decodes the value of a key relative to the meta path.
+// It returns nil message for tombstone values and ok=false if the prefix is unknown or the value is not the expected proto.
+func DecodeMetaValue(relKey string, value []byte) (msg proto.Message, ok bool) {
+	d, found := LookupMetaDecoder(relKey)
+	if !found {
+		return nil, false
+	}
+	if bytes.Equal(value, CollectionTombstone) {
+		return nil, true
+	}
+	msg = d.New()
+	if err := proto.Unmarshal(value, msg); err != nil {
+		return nil, false
+	}
+	return msg, true
+}
@@ -0,0 +1,49 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/milvus-io/milvus/pkg/v2/proto/datapb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/etcdpb"
+)
+
+func TestLookupMetaDecoder(t *testing.T) {
+	d, ok := LookupMetaDecoder("datacoord-meta/s/100/200/300")
+	require.True(t, ok)
+	require.Equal(t, MetaCategorySegment, d.Category)
+
+	d, ok = LookupMetaDecoder("datacoord-meta/snapshot/100/1")
+	require.True(t, ok)
+	require.Equal(t, MetaCategorySnapshot, d.Category)
+
+	d, ok = LookupMetaDecoder("root-coord/database/collection-info/1/100")
+	require.True(t, ok)
+	require.Equal(t, MetaCategoryCollection, d.Category)
+
+	d, ok = LookupMetaDecoder("snapshots/root-coord/collection/100_ts123")
+	require.True(t, ok)
+	require.Equal(t, MetaCategoryCollection, d.Category)
+
+	_, ok = LookupMetaDecoder("session/querynode-1")
+	require.False(t, ok)
+}
+
+func TestDecodeMetaValue(t *testing.T) {
+	bs, err := proto.Marshal(&datapb.SegmentInfo{ID: 300, CollectionID: 100})
+	require.NoError(t, err)
+	msg, ok := DecodeMetaValue("datacoord-meta/s/100/200/300", bs)
+	require.True(t, ok)
+	require.Equal(t, int64(300), msg.(*datapb.SegmentInfo).GetID())
+
+	msg, ok = DecodeMetaValue("root-coord/collection/100", CollectionTombstone)
+	require.True(t, ok)
+	require.Nil(t, msg)
+
+	bs, err = proto.Marshal(&etcdpb.CollectionInfo{ID: 100})
+	require.NoError(t, err)
+	_, ok = DecodeMetaValue("unknown/100", bs)
+	require.False(t, ok)
+}
@@ -3,242 +3,408 @@ package repair
 import (
 	"context"
 	"fmt"
+	"os"
 	"path"
+	"strings"
+	"time"
 
+	"github.com/cockroachdb/errors"
+	"github.com/minio/minio-go/v7"
+	"github.com/samber/lo"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/models"
+	"github.com/milvus-io/birdwatcher/oss"
+	"github.com/milvus-io/birdwatcher/states/etcd/common"
 	"github.com/milvus-io/birdwatcher/states/kv"
+	"github.com/milvus-io/birdwatcher/states/ossutil"
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	"github.com/milvus-io/milvus/pkg/v2/proto/datapb"
 )
 
 type RepairSegmentParam struct {
 	framework.ExecutionParam `use:"repair segment" desc:"do segment & index meta check and try to repair"`
 
-	Collection int64 `name:"collection" default:"0" desc:"collection id to filter with"`
-	Segment    int64 `name:"segment" default:"0" desc:"segment id to filter with"`
+	Collection      int64  `name:"collection" default:"0" desc:"collection id to filter with"`
+	Segment         int64  `name:"segment" default:"0" desc:"segment id to filter with"`
+	SkipStorage     bool   `name:"skipStorage" default:"false" desc:"skip checking binlog & index files in object storage"`
+	MinioAddress    string `name:"minioAddr" default:"" desc:"object storage address override"`
+	SkipBucketCheck bool   `name:"skipBucketCheck" default:"false" desc:"skip bucket exist check due to permission issue"`
 }
 
-// old logic backup
-/*
-	if !skipDownload {
-		minioClient, bucketName, err := getMinioAccess()
+// segmentRepair is the problems found in one segment and the binlog fix if any.
+type segmentRepair struct {
+	segment  *models.Segment
+	problems []string
+	// embedded is true when binlogs are stored inside segment info (v1 meta).
+	embedded bool
+	// keys of the per-field binlog records in v2 meta, by field id.
+	binlogKeys map[int64]string
+	// binlogs is the repaired insert binlogs, nil if no binlog fix applicable.
+	binlogs   []*datapb.FieldBinlog
+	numOfRows int64
+}
+
+func (r *segmentRepair) fixable() bool {
+	return r.binlogs != nil || r.numOfRows != r.segment.GetNumOfRows()
+}
+
+// segmentIndexRepair is a dangling segment index record to remove.
+type segmentIndexRepair struct {
+	segIdx *models.SegmentIndex
+	reason string
+}
+
+// RepairSegmentCommand defines repair segment command.
+func (c *ComponentRepair) RepairSegmentCommand(ctx context.Context, p *RepairSegmentParam) error {
+	segments, err := common.ListSegments(ctx, c.client, c.basePath, func(segment *models.Segment) bool {
+		return (p.Collection == 0 || segment.CollectionID == p.Collection) &&
+			(p.Segment == 0 || segment.ID == p.Segment)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list segments")
+	}
+
+	fieldIndexes, err := common.ListIndex(ctx, c.client, c.basePath, func(index *models.FieldIndex) bool {
+		return (p.Collection == 0 || p.Collection == index.GetProto().GetIndexInfo().GetCollectionID()) && !index.GetProto().GetDeleted()
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list indexes")
+	}
+
+	segmentIndexes, err := common.ListSegmentIndex(ctx, c.client, c.basePath, func(segIdx *models.SegmentIndex) bool {
+		return (p.Collection == 0 || p.Collection == segIdx.GetProto().GetCollectionID()) &&
+			(p.Segment == 0 || p.Segment == segIdx.GetProto().GetSegmentID()) &&
+			!segIdx.GetProto().GetIsDeleted()
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list segment indexes")
+	}
+
+	var store oss.ObjectStore
+	var rootPath string
+	if !p.SkipStorage {
+		params := []oss.MinioConnectParam{oss.WithSkipCheckBucket(p.SkipBucketCheck)}
+		if p.MinioAddress != "" {
+			params = append(params, oss.WithMinioAddr(p.MinioAddress))
+		}
+		resolvedStore, err := ossutil.GetObjectStoreFromCfg(ctx, c.client, c.basePath, params...)
+		if err != nil {
+			return errors.Wrap(err, "failed to connect object storage, use --skipStorage to check meta only")
+		}
+		store, rootPath = resolvedStore.Store, resolvedStore.RootPath
+	}
+
+	var repairs []*segmentRepair
+	for _, segment := range segments {
+		if segment.State != commonpb.SegmentState_Flushed {
+			continue
+		}
+		repair, err := c.checkSegmentBinlogs(ctx, segment, store, rootPath)
+		if err != nil {
+			return err
+		}
+		if len(repair.problems) > 0 {
+			repairs = append(repairs, repair)
+		}
+	}
 
+	indexRepairs, err := checkSegmentIndexes(ctx, segments, fieldIndexes, segmentIndexes, store, rootPath)
+	if err != nil {
+		return err
+	}
+
+	if len(repairs) == 0 && len(indexRepairs) == 0 {
+		fmt.Println("no error found")
+		return nil
+	}
+
+	for _, repair := range repairs {
+		segment := repair.segment
+		fmt.Printf("Segment %d (collection %d, partition %d, rows %d):\n", segment.ID, segment.CollectionID, segment.PartitionID, segment.GetNumOfRows())
+		for _, problem := range repair.problems {
+			fmt.Println("\t", problem)
+		}
+		switch {
+		case repair.binlogs != nil:
+			fmt.Printf("\tfix: remove binlog batches missing in storage, row number %d -> %d\n", segment.GetNumOfRows(), repair.numOfRows)
+		case repair.fixable():
+			fmt.Printf("\tfix: update row number %d -> %d\n", segment.GetNumOfRows(), repair.numOfRows)
+		default:
+			fmt.Println("\tno meta fix applicable, manual repair required")
+		}
+	}
+	for _, repair := range indexRepairs {
+		segIdx := repair.segIdx.GetProto()
+		fmt.Printf("Segment index (segment %d, index %d, build %d): %s\n", segIdx.GetSegmentID(), segIdx.GetIndexID(), segIdx.GetBuildID(), repair.reason)
+		fmt.Println("\tfix: remove segment index record", repair.segIdx.Key())
+	}
+
+	if !p.Run {
+		fmt.Println("Dry run, use --run to apply the fixes")
+		return nil
+	}
+
+	folder := path.Join(c.config.WorkspacePath, fmt.Sprintf("repair_segment_%s", time.Now().Format("20060102150405")))
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		return errors.Wrap(err, "failed to create backup folder")
+	}
+	fmt.Println("original meta will be saved in", folder)
+
+	for _, repair := range repairs {
+		if !repair.fixable() {
+			continue
+		}
+		if err := c.applySegmentRepair(ctx, folder, repair); err != nil {
+			return errors.Wrapf(err, "failed to repair segment %d", repair.segment.ID)
+		}
+		fmt.Printf("Segment %d repaired\n", repair.segment.ID)
+	}
+	for _, repair := range indexRepairs {
+		key := repair.segIdx.Key()
+		if err := c.backupKey(ctx, folder, key); err != nil {
+			return err
+		}
+		if err := c.client.Remove(ctx, key); err != nil {
+			return errors.Wrapf(err, "failed to remove segment index %s", key)
+		}
+		fmt.Println("Segment index removed", key)
+	}
+	return nil
+}
+
+// checkSegmentBinlogs checks binlog row counts and existence of segment logs in object storage.
+func (c *ComponentRepair) checkSegmentBinlogs(ctx context.Context, segment *models.Segment, store oss.ObjectStore, rootPath string) (*segmentRepair, error) {
+	repair := &segmentRepair{segment: segment, numOfRows: segment.GetNumOfRows()}
+
+	binlogs := segment.SegmentInfo.GetBinlogs()
+	repair.embedded = len(binlogs) > 0
+	if !repair.embedded {
+		prefix := path.Join(c.basePath, common.DCPrefix, fmt.Sprintf("binlog/%d/%d/%d", segment.CollectionID, segment.PartitionID, segment.ID))
+		var keys []string
+		var err error
+		binlogs, keys, err = common.ListProtoObjects[datapb.FieldBinlog](ctx, c.client, prefix)
 		if err != nil {
-			fmt.Println("failed to get minio access", err.Error())
+			return nil, errors.Wrapf(err, "failed to list binlogs of segment %d", segment.ID)
+		}
+		repair.binlogKeys = make(map[int64]string)
+		for idx, fieldBinlog := range binlogs {
+			repair.binlogKeys[fieldBinlog.GetFieldID()] = keys[idx]
 		}
+	}
+	if len(binlogs) == 0 {
+		if segment.GetNumOfRows() > 0 {
+			repair.problems = append(repair.problems, "segment has rows but no insert binlog")
+		}
+		return repair, nil
+	}
 
-		folder := fmt.Sprintf("repair_segment_%s", time.Now().Format("20060102150406"))
-		for segmentID, segment := range target {
-			fileName := fmt.Sprintf("segment_%d", segmentID)
-			oldSeg := targetOld[segmentID]
-			f, err := os.Create(path.Join(folder, fileName))
+	rowCount, ok := integrityCheck(binlogs)
+	if !ok {
+		for _, fieldBinlog := range binlogs {
+			repair.problems = append(repair.problems, fmt.Sprintf("field %d binlog entries: %d", fieldBinlog.GetFieldID(), binlogEntries(fieldBinlog)))
+		}
+		repair.problems = append(repair.problems, "binlog entry number not match between fields")
+	} else if rowCount != segment.GetNumOfRows() {
+		repair.problems = append(repair.problems, fmt.Sprintf("binlog entry number %d not match segment row number %d", rowCount, segment.GetNumOfRows()))
+		repair.numOfRows = rowCount
+	}
+
+	if store == nil {
+		return repair, nil
+	}
+
+	// positions of insert binlog batches with any file missing
+	missing := make(map[int]struct{})
+	for _, fieldBinlog := range binlogs {
+		for idx, binlog := range fieldBinlog.GetBinlogs() {
+			logPath := binlog.GetLogPath()
+			if logPath == "" {
+				logPath = fmt.Sprintf("ROOT_PATH/insert_log/%d/%d/%d/%d/%d", segment.CollectionID, segment.PartitionID, segment.ID, fieldBinlog.GetFieldID(), binlog.GetLogID())
+			}
+			found, err := objectExists(ctx, store, oss.ResolveObjectKey(rootPath, logPath))
 			if err != nil {
-				fmt.Println("failed to open file", fileName, err.Error())
-				return
+				return nil, err
+			}
+			if !found {
+				missing[idx] = struct{}{}
+				repair.problems = append(repair.problems, fmt.Sprintf("insert binlog %s missing in storage", logPath))
 			}
-			bs, err := proto.Marshal(oldSeg)
-			w := bufio.NewWriter(f)
-			w.Write(bs)
-			w.Flush()
-			f.Close()
-
-			index, ok := targetIndex[segmentID]
-			if ok {
-				fileName = fmt.Sprintf("index_%d", segmentID)
-				f, err = os.Create(path.Join(folder, fileName))
+		}
+	}
+
+	items := []struct {
+		tag          string
+		fieldBinlogs []*models.FieldBinlog
+	}{
+		{tag: "statslog", fieldBinlogs: segment.GetStatslogs()},
+		{tag: "deltalog", fieldBinlogs: segment.GetDeltalogs()},
+		{tag: "bm25 statslog", fieldBinlogs: segment.GetBm25Statslogs()},
+	}
+	for _, item := range items {
+		for _, fieldBinlog := range item.fieldBinlogs {
+			for _, binlog := range fieldBinlog.Binlogs {
+				found, err := objectExists(ctx, store, oss.ResolveObjectKey(rootPath, binlog.LogPath))
 				if err != nil {
-					fmt.Println("failed to open file", fileName, err.Error())
-					return
+					return nil, err
+				}
+				if !found {
+					repair.problems = append(repair.problems, fmt.Sprintf("%s %s missing in storage", item.tag, binlog.LogPath))
 				}
-				bs, err = proto.Marshal(index)
-				w := bufio.NewWriter(f)
-				w.Write(bs)
-				w.Flush()
-				f.Close()
-
 			}
+		}
+	}
 
-			err = downloadSegment(minioClient, bucketName, segment, targetIndex[segmentID], folder)
+	if len(missing) > 0 {
+		trimmed, ok := removeBinlogBatches(binlogs, missing)
+		if !ok {
+			// cannot remove missing batches consistently, leave it for manual repair
+			repair.numOfRows = segment.GetNumOfRows()
+			return repair, nil
+		}
+		rowCount, ok := integrityCheck(trimmed)
+		if !ok || rowCount == 0 {
+			repair.numOfRows = segment.GetNumOfRows()
+			return repair, nil
+		}
+		repair.binlogs = trimmed
+		repair.numOfRows = rowCount
+	}
+	return repair, nil
+}
+
+// checkSegmentIndexes finds segment index records of missing segment, missing index or missing index files.
+func checkSegmentIndexes(ctx context.Context, segments []*models.Segment, fieldIndexes []*models.FieldIndex, segmentIndexes []*models.SegmentIndex, store oss.ObjectStore, rootPath string) ([]*segmentIndexRepair, error) {
+	segmentIDs := lo.SliceToMap(segments, func(segment *models.Segment) (int64, struct{}) {
+		return segment.ID, struct{}{}
+	})
+	indexIDs := lo.SliceToMap(fieldIndexes, func(index *models.FieldIndex) (int64, struct{}) {
+		return index.GetProto().GetIndexInfo().GetIndexID(), struct{}{}
+	})
+
+	var result []*segmentIndexRepair
+	for _, segIdx := range segmentIndexes {
+		info := segIdx.GetProto()
+		if _, ok := segmentIDs[info.GetSegmentID()]; !ok {
+			result = append(result, &segmentIndexRepair{segIdx: segIdx, reason: "segment not found"})
+			continue
+		}
+		if _, ok := indexIDs[info.GetIndexID()]; !ok {
+			result = append(result, &segmentIndexRepair{segIdx: segIdx, reason: "index not found or deleted"})
+			continue
+		}
+		if store == nil || info.GetIndexState() != commonpb.IndexState_Finished {
+			continue
+		}
+		for _, fileKey := range info.GetIndexFileKeys() {
+			filePath := fmt.Sprintf("ROOT_PATH/index_files/%d/%d/%d/%d/%s", info.GetBuildID(), info.GetIndexVersion(), info.GetPartitionID(), info.GetSegmentID(), fileKey)
+			found, err := objectExists(ctx, store, oss.ResolveObjectKey(rootPath, filePath))
 			if err != nil {
-				fmt.Println("failed to download segment", err.Error())
-				return
+				return nil, err
+			}
+			if !found {
+				result = append(result, &segmentIndexRepair{segIdx: segIdx, reason: fmt.Sprintf("index file %s missing in storage", filePath)})
+				break
 			}
 		}
-	}*/
-
-// RepairSegmentCommand defines repair segment command.
-func (c *ComponentRepair) RepairSegmentCommand(ctx context.Context, p *RepairSegmentParam) error {
-	// segments, err := common.ListSegments(ctx, c.client, c.basePath, func(info *datapb.SegmentInfo) bool {
-	// 	return (p.Collection == 0 || info.CollectionID == p.Collection) &&
-	// 		(p.Segment == 0 || info.ID == p.Segment)
-	// })
-	// if err != nil {
-	// 	return errors.Wrap(err, "failed to list segments")
-	// }
-
-	// indexBuildInfo, err := common.ListIndex(ctx, c.client, c.basePath, func(index *models.FieldIndex) bool {
-	// 	return (p.Collection == 0 || p.Collection == index.GetProto().GetIndexInfo().GetCollectionID())
-	// })
-	// if err != nil {
-	// 	return errors.Wrap(err, "failed to list indexes")
-	// }
-
-	// segmentIndexes, err := common.ListSegmentIndex(ctx, c.client, c.basePath, func(segIdx *models.SegmentIndex) bool {
-	// 	return (p.Collection == 0 || p.Collection == segIdx.GetProto().GetCollectionID()) &&
-	// 		(p.Segment == 0 || p.Segment == segIdx.GetProto().GetSegmentID())
-	// })
-	// if err != nil {
-	// 	return errors.Wrap(err, "failed to list segment indexes")
-	// }
-
-	// seg2Idx := lo.GroupBy(segmentIndexes, func(segIdx *models.SegmentIndex) int64 {
-	// 	return segIdx.GetProto().GetSegmentID()
-	// }) //make(map[int64][]etcdpb.SegmentIndexInfo)
-
-	// buildID2Info := lo.SliceToMap(segmentIndexes, func(index *models.SegmentIndex) (int64, *models.SegmentIndex) {
-	// 	return index.GetProto().GetBuildID(), index
-	// })
-
-	// collections := make(map[int64]*models.Collection)
-
-	// targetOld := make(map[int64]*datapb.SegmentInfo)
-	// target := make(map[int64]*datapb.SegmentInfo)
-	// targetIndex := make(map[int64]*indexpb.IndexMeta)
-
-	// for _, segment := range segments {
-	// 	if segment.State != commonpb.SegmentState_Flushed {
-	// 		continue
-	// 	}
-	// 	segIdxs, ok := seg2Idx[segment.GetID()]
-	// 	if !ok {
-	// 		continue
-	// 	}
-
-	// 	coll, ok := collections[segment.CollectionID]
-	// 	if !ok {
-	// 		coll, err = common.GetCollectionByIDVersion(ctx, c.client, c.basePath, p.Collection)
-	// 		if err != nil {
-	// 			fmt.Printf("failed to query collection(id=%d) info error: %s", segment.CollectionID, err.Error())
-	// 			continue
-	// 		}
-	// 		collections[segment.CollectionID] = coll
-	// 	}
-
-	// 	common.FillFieldsIfV2(c.client, c.basePath, segment.SegmentInfo)
-
-	// 	// for _, segIdx := range segIdxs {
-	// 	// 	var valid bool
-	// 	// 	// for _, field := range coll.GetProto().Schema.Fields {
-	// 	// 	// 	if field.FieldID == segIdx.GetProto(). {
-	// 	// 	// 		if field.DataType == models.DataTypeFloatVector || field.DataType == models.DataTypeBinaryVector {
-	// 	// 	// 			valid = true
-	// 	// 	// 		}
-	// 	// 	// 		break
-	// 	// 	// 	}
-	// 	// 	// }
-	// 	// 	// if !valid {
-	// 	// 	// 	// skip index if not found or not Vector index
-	// 	// 	// 	continue
-	// 	// 	// }
-	// 	// 	// info, ok := buildID2Info[segIdx.BuildID]
-	// 	// 	// if !ok {
-	// 	// 	// 	// skip index check for no index found
-	// 	// 	// 	continue
-	// 	// 	// }
-	// 	// 	// updated, found := checkBinlogIndex(segment, &info)
-	// 	// 	// if found {
-	// 	// 	// 	target[segment.ID] = updated
-	// 	// 	// 	targetIndex[segment.ID] = &info
-	// 	// 	// 	targetOld[segment.ID] = segment
-	// 	// 	// }
-	// 	// }
-	// }
-	// if len(target) == 0 {
-	// 	fmt.Println("no error found")
-	// 	return nil
-	// }
-	// if !p.Run {
-	// 	return nil
-	// }
-
-	// // row count check
-	// for segmentID, segment := range target {
-	// 	if !integrityCheck(segment) {
-	// 		fmt.Printf("generated segment[id=%d] row count not match, repair failed.", segmentID)
-	// 		delete(target, segmentID)
-	// 	}
-	// }
-
-	// // update segment meta, v1 format for now
-	// for _, segment := range target {
-	// 	err := writeRepairedSegment(c.client, c.basePath, segment)
-	// 	if err != nil {
-	// 		errors.Wrap(err, "failed to write back repaired segment meta")
-	// 	}
-	// }
-	return nil
+	}
+	return result, nil
 }
 
-// func checkBinlogIndex(segment *datapb.SegmentInfo, indexMeta *indexpb.IndexMeta) (*datapb.SegmentInfo, bool) {
-// 	indexed := make(map[string]struct{})
-// 	for _, path := range indexMeta.GetReq().GetDataPaths() {
-// 		indexed[path] = struct{}{}
-// 	}
-
-// 	excludedIdx := make(map[int]struct{})
-
-// 	for _, fieldLog := range segment.GetBinlogs() {
-// 		if fieldLog.FieldID == indexMeta.GetReq().GetFieldSchema().GetFieldID() {
-// 			for idx, binlog := range fieldLog.GetBinlogs() {
-// 				_, ok := indexed[binlog.GetLogPath()]
-// 				if !ok {
-// 					excludedIdx[idx] = struct{}{}
-// 					fmt.Printf("[segment=%d]binlog %s in segment but not indexed\n", segment.ID, binlog.LogPath)
-// 				}
-// 			}
-// 		}
-// 	}
-
-// 	if len(excludedIdx) == 0 {
-// 		return nil, false
-// 	}
-
-// 	// remove binlog from field logs
-// 	update := proto.Clone(segment).(*datapb.SegmentInfo)
-// 	for _, fieldLog := range update.GetBinlogs() {
-// 		newbinlogs := make([]*datapb.Binlog, 0, len(fieldLog.GetBinlogs()))
-// 		for idx, binlog := range fieldLog.GetBinlogs() {
-// 			_, exclude := excludedIdx[idx]
-// 			if !exclude {
-// 				newbinlogs = append(newbinlogs, binlog)
-// 			}
-// 		}
-// 		fieldLog.Binlogs = newbinlogs
-// 	}
-
-// 	return update, true
-// }
-
-func integrityCheck(segment *datapb.SegmentInfo) bool {
-	var rowCount int64
-	// use 0-th field as base
-	for _, binlog := range segment.GetBinlogs()[0].GetBinlogs() {
-		rowCount += binlog.EntriesNum
+// applySegmentRepair saves the original segment meta to the folder and writes the repaired one.
+func (c *ComponentRepair) applySegmentRepair(ctx context.Context, folder string, repair *segmentRepair) error {
+	segment := repair.segment
+	segmentKey := path.Join(c.basePath, fmt.Sprintf("datacoord-meta/s/%d/%d/%d", segment.CollectionID, segment.PartitionID, segment.ID))
+	if err := c.backupKey(ctx, folder, segmentKey); err != nil {
+		return err
 	}
 
-	for i := 1; i < len(segment.GetBinlogs()); i++ {
-		var frc int64
-		for _, binlog := range segment.GetBinlogs()[i].GetBinlogs() {
-			frc += binlog.EntriesNum
+	updated := proto.Clone(segment.SegmentInfo).(*datapb.SegmentInfo)
+	updated.NumOfRows = repair.numOfRows
+	if repair.binlogs != nil {
+		if repair.embedded {
+			updated.Binlogs = repair.binlogs
+		} else {
+			for _, fieldBinlog := range repair.binlogs {
+				key, ok := repair.binlogKeys[fieldBinlog.GetFieldID()]
+				if !ok {
+					return errors.Newf("binlog key of field %d not found", fieldBinlog.GetFieldID())
+				}
+				if err := c.backupKey(ctx, folder, key); err != nil {
+					return err
+				}
+				bs, err := proto.Marshal(fieldBinlog)
+				if err != nil {
+					return err
+				}
+				if err := c.client.Save(ctx, key, string(bs)); err != nil {
+					return err
+				}
+			}
 		}
+	}
+	return writeRepairedSegment(c.client, c.basePath, updated)
+}
+
+// backupKey saves the raw value of the key into the folder, named by the key relative to base path.
+func (c *ComponentRepair) backupKey(ctx context.Context, folder string, key string) error {
+	value, err := c.client.Load(ctx, key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load %s for backup", key)
+	}
+	fileName := strings.ReplaceAll(strings.TrimPrefix(key, c.basePath+"/"), "/", "_")
+	return os.WriteFile(path.Join(folder, fileName), []byte(value), 0o644)
+}
 
-		if frc != rowCount {
-			return false
+func objectExists(ctx context.Context, store oss.ObjectStore, key string) (bool, error) {
+	_, err := store.Stat(ctx, key)
+	if err == nil {
+		return true, nil
+	}
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return false, nil
+	}
+	return false, errors.Wrapf(err, "failed to stat %s", key)
+}
+
+// removeBinlogBatches removes the binlogs at the positions from all fields,
+// it fails when fields have different binlog number so that batches cannot be aligned.
+func removeBinlogBatches(binlogs []*datapb.FieldBinlog, positions map[int]struct{}) ([]*datapb.FieldBinlog, bool) {
+	result := make([]*datapb.FieldBinlog, 0, len(binlogs))
+	for _, fieldBinlog := range binlogs {
+		if len(fieldBinlog.GetBinlogs()) != len(binlogs[0].GetBinlogs()) {
+			return nil, false
 		}
+		updated := proto.Clone(fieldBinlog).(*datapb.FieldBinlog)
+		updated.Binlogs = lo.Filter(updated.GetBinlogs(), func(_ *datapb.Binlog, idx int) bool {
+			_, ok := positions[idx]
+			return !ok
+		})
+		result = append(result, updated)
 	}
+	return result, true
+}
 
-	return true
+func binlogEntries(fieldBinlog *datapb.FieldBinlog) int64 {
+	return lo.SumBy(fieldBinlog.GetBinlogs(), func(binlog *datapb.Binlog) int64 {
+		return binlog.GetEntriesNum()
+	})
+}
+
+// integrityCheck returns the row count of binlogs and whether all fields have the same entry number.
+func integrityCheck(binlogs []*datapb.FieldBinlog) (int64, bool) {
+	if len(binlogs) == 0 {
+		return 0, true
+	}
+	// use 0-th field as base
+	rowCount := binlogEntries(binlogs[0])
+	for i := 1; i < len(binlogs); i++ {
+		if binlogEntries(binlogs[i]) != rowCount {
+			return rowCount, false
+		}
+	}
+	return rowCount, true
 }
 
 func writeRepairedSegment(cli kv.MetaKV, basePath string, segment *datapb.SegmentInfo) error {
@@ -247,6 +413,7 @@ func writeRepairedSegment(cli kv.MetaKV, basePath string, segment *datapb.Segmen
 	bs, err := proto.Marshal(segment)
 	if err != nil {
 		fmt.Println("failed to marshal segment info", err.Error())
+		return err
 	}
 	err = cli.Save(context.Background(), p, string(bs))
 	return err
@@ -0,0 +1,40 @@
+package repair
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/milvus/pkg/v2/proto/datapb"
+)
+
+func TestRemoveBinlogBatches(t *testing.T) {
+	fieldBinlog := func(fieldID int64, entries ...int64) *datapb.FieldBinlog {
+		fbl := &datapb.FieldBinlog{FieldID: fieldID}
+		for idx, num := range entries {
+			fbl.Binlogs = append(fbl.Binlogs, &datapb.Binlog{LogID: fieldID*100 + int64(idx), EntriesNum: num})
+		}
+		return fbl
+	}
+
+	binlogs := []*datapb.FieldBinlog{fieldBinlog(0, 10, 20, 30), fieldBinlog(100, 10, 20, 30)}
+	rowCount, ok := integrityCheck(binlogs)
+	assert.True(t, ok)
+	assert.EqualValues(t, 60, rowCount)
+
+	trimmed, ok := removeBinlogBatches(binlogs, map[int]struct{}{1: {}})
+	require.True(t, ok)
+	rowCount, ok = integrityCheck(trimmed)
+	assert.True(t, ok)
+	assert.EqualValues(t, 40, rowCount)
+	assert.EqualValues(t, 10002, trimmed[1].GetBinlogs()[1].GetLogID())
+	// origin binlogs not changed
+	assert.Len(t, binlogs[0].GetBinlogs(), 3)
+
+	_, ok = removeBinlogBatches([]*datapb.FieldBinlog{fieldBinlog(0, 10, 20), fieldBinlog(100, 30)}, map[int]struct{}{0: {}})
+	assert.False(t, ok)
+
+	_, ok = integrityCheck([]*datapb.FieldBinlog{fieldBinlog(0, 10, 20), fieldBinlog(100, 30, 1)})
+	assert.False(t, ok)
+}
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -74,6 +76,13 @@ type BackupParam struct {
 	IgnoreRevision bool  `name:"ignoreRevision" default:"false" desc:"backup ignore revision change, ONLY shall works with no nodes online"`
 	BatchSize      int64 `name:"batchSize" default:"100" desc:"batch fetch size for etcd backup operation"`
 	BackupMetrics  bool  `name:"backupMetrics" default:"false" desc:"fetch component metrics flag"`
+
+	CollectionIDs []int64  `name:"collection" default:"" desc:"only backup keys referencing the collections"`
+	Categories    []string `name:"category" default:"" desc:"only backup keys of the meta categories, e.g. segment,index,other"`
+	Base          string   `name:"base" default:"" desc:"base backup file, only keys changed since its revision are stored"`
+
+	Target string `name:"target" default:"" desc:"upload backup to object storage instead of local file, e.g. s3://bucket/prefix"`
+	BackupStorageParam
 }
 
 func (p *BackupParam) ParseArgs(args []string) error {
@@ -98,24 +107,50 @@ func (s *InstanceState) BackupCommand(ctx context.Context, p *BackupParam) error
 		return fmt.Errorf("component %s not supported for separate backup, use ALL instead", p.component.String())
 	}
 
-	f, err := getBackupFile(p.component.String())
+	var opts []kv.BackupOption
+	filter, err := newBackupFilter(s.basePath, p.CollectionIDs, p.Categories)
+	if err != nil {
+		return err
+	}
+	if filter != nil {
+		opts = append(opts, kv.WithBackupFilter(filter))
+	}
+	name := p.component.String()
+	if p.Base != "" {
+		meta, err := readBackupEtcdMeta(ctx, &p.BackupStorageParam, p.Base)
+		if err != nil {
+			return errors.Wrap(err, "failed to read base backup")
+		}
+		if path.Join(meta["instance"], meta["metaPath"]) != s.basePath {
+			return errors.Newf("base backup is for %s instead of %s", path.Join(meta["instance"], meta["metaPath"]), s.basePath)
+		}
+		baseRev, err := strconv.ParseInt(meta["rev"], 10, 64)
+		if err != nil || baseRev <= 0 {
+			return errors.Newf("base backup does not have valid revision %q", meta["rev"])
+		}
+		fmt.Printf("incremental backup since revision %d\n", baseRev)
+		opts = append(opts, kv.WithBackupSince(baseRev))
+		name += "-inc"
+	}
+
+	target, err := p.createBackupTarget(ctx, p.Target, name)
 	if err != nil {
 		return errors.Wrap(err, "failed to open backup file")
 	}
-	defer f.Close()
 
-	gw := gzip.NewWriter(f)
-	defer gw.Close()
-	w := bufio.NewWriter(gw)
+	gw := gzip.NewWriter(target)
+	cw := newBackupChecksumWriter(gw)
+	w := bufio.NewWriter(cw)
 
 	// write backup header
 	// version 2 used for now
 	err = writeBackupHeader(w, 2)
 	if err != nil {
+		target.Finish(err)
 		return errors.Wrap(err, "failed to write backup file header")
 	}
 
-	err = backupEtcdV2(s.client, s.basePath, prefix, w, p)
+	err = backupEtcdV2(s.client, s.basePath, prefix, w, p, opts...)
 	if err != nil {
 		fmt.Printf("backup etcd failed, error: %v\n", err)
 	}
@@ -124,20 +159,39 @@ func (s *InstanceState) BackupCommand(ctx context.Context, p *BackupParam) error
 		backupConfiguration(s.client, s.basePath, w)
 		backupAppMetrics(s.client, s.basePath, w)
 	}
-	fmt.Printf("backup for prefix done, stored in file: %s\n", f.Name())
+	if err := finishBackup(target, gw, w, cw, err); err != nil {
+		return err
+	}
+	fmt.Printf("backup for prefix done, stored in file: %s\n", target.Location())
 	return nil
 }
 
+// finishBackup writes the manifest and completes the target, the target is aborted if backup or manifest failed.
+func finishBackup(target backupTarget, gw *gzip.Writer, w *bufio.Writer, cw *backupChecksumWriter, err error) error {
+	if merr := writeBackupManifest(w, cw); merr != nil && err == nil {
+		err = errors.Wrap(merr, "failed to write backup manifest")
+	}
+	if cerr := gw.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	if ferr := target.Finish(err); ferr != nil {
+		return errors.Wrapf(ferr, "failed to finish backup %s", target.Location())
+	}
+	return err
+}
+
 func getBackupFile(component string) (*os.File, error) {
-	now := time.Now()
-	filePath := fmt.Sprintf("bw_etcd_%s.%s.bak.gz", component, now.Format("060102-150405"))
-	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	f, err := os.OpenFile(backupFileName(component), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
 	if err != nil {
 		return nil, err
 	}
 	return f, nil
 }
 
+func backupFileName(component string) string {
+	return fmt.Sprintf("bw_etcd_%s.%s.bak.gz", component, time.Now().Format("060102-150405"))
+}
+
 func writeBackupHeader(w io.Writer, version int32) error {
 	lb := make([]byte, 8)
 	header := &models.BackupHeader{Version: version}
@@ -152,8 +206,8 @@ func writeBackupHeader(w io.Writer, version int32) error {
 	return nil
 }
 
-func backupEtcdV2(cli kv.MetaKV, base, prefix string, w *bufio.Writer, opt *BackupParam) error {
-	return cli.BackupKV(base, prefix, w, opt.IgnoreRevision, opt.BatchSize)
+func backupEtcdV2(cli kv.MetaKV, base, prefix string, w *bufio.Writer, opt *BackupParam, opts ...kv.BackupOption) error {
+	return cli.BackupKV(base, prefix, w, opt.IgnoreRevision, opt.BatchSize, opts...)
 }
 
 func backupMetrics(cli kv.MetaKV, basePath string, w *bufio.Writer) error {
@@ -176,7 +230,7 @@ func backupMetrics(cli kv.MetaKV, basePath string, w *bufio.Writer) error {
 	defer writeBackupBytes(w, nil)
 
 	for _, session := range sessions {
-		mbs, dmbs, err := fetchInstanceMetrics(session)
+		mbs, dmbs, err := fetchInstanceMetrics(context.Background(), session, getMetricsPort(context.Background(), session))
 		if err != nil {
 			fmt.Printf("failed to fetch metrics for %s(%d), %s\n", session.ServerName, session.ServerID, err.Error())
 			continue
@@ -0,0 +1,91 @@
+package states
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/milvus-io/birdwatcher/models"
+)
+
+type testBackupTarget struct {
+	bytes.Buffer
+	abort    error
+	finished bool
+}
+
+func (t *testBackupTarget) Finish(abort error) error {
+	t.abort = abort
+	t.finished = true
+	return nil
+}
+
+func (t *testBackupTarget) Location() string {
+	return "test"
+}
+
+func TestFinishBackup(t *testing.T) {
+	newWriters := func(target *testBackupTarget) (*gzip.Writer, *bufio.Writer, *backupChecksumWriter) {
+		gw := gzip.NewWriter(target)
+		cw := newBackupChecksumWriter(gw)
+		w := bufio.NewWriter(cw)
+		require.NoError(t, writeBackupHeader(w, 2))
+		return gw, w, cw
+	}
+
+	target := &testBackupTarget{}
+	gw, w, cw := newWriters(target)
+	require.NoError(t, finishBackup(target, gw, w, cw, nil))
+	assert.True(t, target.finished)
+	assert.NoError(t, target.abort)
+
+	// part not finished, manifest fails and target is aborted
+	target = &testBackupTarget{}
+	gw, w, cw = newWriters(target)
+	bs, err := proto.Marshal(&models.PartHeader{PartType: models.PartType_EtcdBackup, PartLen: -1})
+	require.NoError(t, err)
+	writeBackupBytes(w, bs)
+	err = finishBackup(target, gw, w, cw, nil)
+	assert.Error(t, err)
+	assert.True(t, target.finished)
+	assert.Error(t, target.abort)
+
+	// backup error is kept and passed to target
+	target = &testBackupTarget{}
+	gw, w, cw = newWriters(target)
+	backupErr := errors.New("backup failed")
+	err = finishBackup(target, gw, w, cw, backupErr)
+	assert.ErrorIs(t, err, backupErr)
+	assert.ErrorIs(t, target.abort, backupErr)
+}
+
+func TestLocalBackupTargetFinish(t *testing.T) {
+	dir := t.TempDir()
+	open := func(name string) *localBackupTarget {
+		f, err := os.Create(filepath.Join(dir, name))
+		require.NoError(t, err)
+		_, err = f.Write([]byte("partial"))
+		require.NoError(t, err)
+		return &localBackupTarget{f}
+	}
+
+	target := open("ok.bak.gz")
+	require.NoError(t, target.Finish(nil))
+	_, err := os.Stat(target.Location())
+	assert.NoError(t, err)
+
+	// aborted backup leaves no truncated file
+	target = open("aborted.bak.gz")
+	backupErr := errors.New("backup failed")
+	assert.ErrorIs(t, target.Finish(backupErr), backupErr)
+	_, err = os.Stat(target.Location())
+	assert.True(t, os.IsNotExist(err))
+}
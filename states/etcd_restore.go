@@ -96,6 +96,13 @@ func restoreV2File(rd *bufio.Reader, state *embedEtcdMockState) error {
 
 		switch ph.PartType {
 		case models.PartType_EtcdBackup:
+			if isDeletesPart(&ph) {
+				if err := restoreEtcdDeletesV2(state.client, rd); err != nil {
+					fmt.Println("failed to apply deleted keys from backup file", err.Error())
+					return err
+				}
+				continue
+			}
 			instance, err := restoreEtcdFromBackV2(state.client, rd, &ph)
 			if err != nil {
 				fmt.Println("failed to restore etcd from backup file", err.Error())
@@ -111,6 +118,17 @@ func restoreV2File(rd *bufio.Reader, state *embedEtcdMockState) error {
 			// testRestoreConfigurations(rd, ph)
 		case models.PartType_AppMetrics:
 			// testRestoreConfigurations(rd, ph)
+		case models.PartType_Manifest:
+			// checked by verify-backup only, skip the manifest frame and stopper
+			for {
+				_, nb, err := readBackupBytes(rd)
+				if err != nil {
+					return err
+				}
+				if nb == 0 {
+					break
+				}
+			}
 		}
 	}
 }
@@ -127,10 +145,6 @@ func restoreEtcdFromBackV2(cli kv.MetaKV, rd io.Reader, ph *models.PartHeader) (
 		return "", err
 	}
 
-	var nextBytes uint64
-	var bs []byte
-
-	lb := make([]byte, 8)
 	i := 0
 	progressDisplay := uilive.New()
 	progressFmt := "Restoring backup ... %d%%(%d/%d)\n"
@@ -152,54 +166,7 @@ func restoreEtcdFromBackV2(cli kv.MetaKV, rd io.Reader, ph *models.PartHeader) (
 			}
 		}()
 		var lastPrint time.Time
-		for {
-			bsRead, err := io.ReadFull(rd, lb) // rd.Read(lb)
-			// all file read
-			if err == io.EOF {
-				// return meta["instance"], nil
-				errCh <- nil
-				return
-			}
-			if err != nil {
-				fmt.Println("failed to read file:", err.Error())
-				errCh <- err
-				return
-			}
-			if bsRead < 8 {
-				fmt.Printf("fail to read next length %d instead of 8 read\n", bsRead)
-				errCh <- errors.New("invalid file format")
-				return
-			}
-
-			nextBytes = binary.LittleEndian.Uint64(lb)
-			// stopper found
-			if nextBytes == 0 {
-				errCh <- nil
-				return
-			}
-			bs = make([]byte, nextBytes)
-
-			// cannot use rd.Read(bs), since proto marshal may generate a stopper
-			bsRead, err = io.ReadFull(rd, bs)
-			if err != nil {
-				fmt.Println("failed to read next kv data", err.Error())
-				errCh <- err
-				return
-			}
-			if uint64(bsRead) != nextBytes {
-				fmt.Printf("bytesRead(%d)is not equal to nextBytes(%d)\n", bsRead, nextBytes)
-				errCh <- errors.New("bad file format")
-				return
-			}
-
-			entry := &commonpb.KeyDataPair{}
-			err = proto.Unmarshal(bs, entry)
-			if err != nil {
-				// Skip for now
-				fmt.Printf("fail to parse line: %s, skip for now\n", err.Error())
-				continue
-			}
-
+		errCh <- readBackupEntries(rd, func(entry *commonpb.KeyDataPair) error {
 			batch = append(batch, entry)
 			if len(batch) >= batchNum {
 				ch <- batch
@@ -212,7 +179,8 @@ func restoreEtcdFromBackV2(cli kv.MetaKV, rd io.Reader, ph *models.PartHeader) (
 				fmt.Fprintf(progressDisplay, progressFmt, progress, i, cnt)
 				lastPrint = time.Now()
 			}
-		}
+			return nil
+		})
 	}()
 
 	var wg sync.WaitGroup
@@ -251,6 +219,79 @@ func restoreEtcdFromBackV2(cli kv.MetaKV, rd io.Reader, ph *models.PartHeader) (
 	return meta["instance"], nil
 }
 
+// isDeletesPart checks whether the etcd part lists keys deleted since the base revision of an incremental backup.
+func isDeletesPart(ph *models.PartHeader) bool {
+	meta := make(map[string]string)
+	if err := json.Unmarshal(ph.Extra, &meta); err != nil {
+		return false
+	}
+	return meta["deletes"] == "true"
+}
+
+func restoreEtcdDeletesV2(cli kv.MetaKV, rd io.Reader) error {
+	cnt := 0
+	err := readBackupEntries(rd, func(entry *commonpb.KeyDataPair) error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+		defer cancel()
+		cnt++
+		return cli.Remove(ctx, entry.GetKey())
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d deleted keys removed\n", cnt)
+	return nil
+}
+
+// readBackupEntries reads key-value entries of an etcd backup part until the stopper or end of file.
+func readBackupEntries(rd io.Reader, fn func(entry *commonpb.KeyDataPair) error) error {
+	lb := make([]byte, 8)
+	for {
+		bsRead, err := io.ReadFull(rd, lb) // rd.Read(lb)
+		// all file read
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			fmt.Println("failed to read file:", err.Error())
+			return err
+		}
+		if bsRead < 8 {
+			fmt.Printf("fail to read next length %d instead of 8 read\n", bsRead)
+			return errors.New("invalid file format")
+		}
+
+		nextBytes := binary.LittleEndian.Uint64(lb)
+		// stopper found
+		if nextBytes == 0 {
+			return nil
+		}
+		bs := make([]byte, nextBytes)
+
+		// cannot use rd.Read(bs), since proto marshal may generate a stopper
+		bsRead, err = io.ReadFull(rd, bs)
+		if err != nil {
+			fmt.Println("failed to read next kv data", err.Error())
+			return err
+		}
+		if uint64(bsRead) != nextBytes {
+			fmt.Printf("bytesRead(%d)is not equal to nextBytes(%d)\n", bsRead, nextBytes)
+			return errors.New("bad file format")
+		}
+
+		entry := &commonpb.KeyDataPair{}
+		err = proto.Unmarshal(bs, entry)
+		if err != nil {
+			// Skip for now
+			fmt.Printf("fail to parse line: %s, skip for now\n", err.Error())
+			continue
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
+
 func restoreMetrics(rd io.Reader, ph *models.PartHeader, handler func(session *models.Session, metrics, defaultMetrics []byte)) error {
 	for {
 		bs, nb, err := readBackupBytes(rd)
@@ -6,13 +6,20 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/manifoldco/promptui"
-	"github.com/spf13/cobra"
+	"github.com/cockroachdb/errors"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/minio/minio-go/v7"
+	"github.com/samber/lo"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/milvus-io/birdwatcher/framework"
 	"github.com/milvus-io/birdwatcher/models"
 	"github.com/milvus-io/birdwatcher/oss"
 	"github.com/milvus-io/birdwatcher/states/etcd/common"
@@ -21,175 +28,527 @@ import (
 	"github.com/milvus-io/milvus/pkg/v2/proto/datapb"
 )
 
-func getGarbageCollectCmd(cli kv.MetaKV, basePath string) *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "garbage-collect",
-		Short: "scan oss of milvus instance for garbage(dry-run)",
-		Run: func(cmd *cobra.Command, args []string) {
-			p := promptui.Prompt{
-				Label: "BucketName",
-			}
-			bucketName, err := p.Run()
-			if err != nil {
-				fmt.Println("failed to get bucketName:", err.Error())
-				return
-			}
-			p.Label = "Minio Root Path"
-			minioRootPath, err := p.Run()
-			if err != nil {
-				fmt.Println("failed to get minioRootPath:", err.Error())
-				return
-			}
+type GarbageCollectParam struct {
+	framework.ExecutionParam `use:"garbage-collect" desc:"scan object storage of milvus instance for garbage, remove them with --run"`
+	CollectionID             int64  `name:"collection" default:"0" desc:"collection id to scan, scan all collections if not set"`
+	MinioAddress             string `name:"minioAddr" default:"" desc:"the minio address to override, if necessary"`
+	SkipBucketCheck          bool   `name:"skipBucketCheck" default:"false" desc:"skip bucket exist check due to permission issue"`
+	MinAge                   string `name:"minAge" default:"24h" desc:"objects modified within the duration are never treated as garbage"`
+	WorkerNum                int64  `name:"workerNum" default:"4" desc:"number of concurrent list workers"`
+	RateLimit                int64  `name:"rateLimit" default:"100" desc:"max objects removed per second with --run"`
+}
 
-			minioClient, err := getMinioClient()
-			if err != nil {
-				fmt.Println("failed to get minio client:", err.Error())
-				return
-			}
+// object prefixes under milvus root path, and their meta references
+const (
+	gcInsertLog = "insert_log"
+	gcStatsLog  = "stats_log"
+	gcDeltaLog  = "delta_log"
+	gcBM25Log   = "bm25_stats"
+	gcIndexFile = "index_files"
+	gcJSONStats = "json_stats"
+)
 
-			exists, _ := minioClient.BucketExists(context.Background(), bucketName)
-			if !exists {
-				fmt.Printf("bucket %s not exists\n", bucketName)
-				return
-			}
-			garbageCollect(cli, basePath, oss.NewMinioObjectStoreWithBucket(minioClient, bucketName), minioRootPath)
-		},
-	}
+var gcObjectTypes = []string{gcInsertLog, gcStatsLog, gcDeltaLog, gcBM25Log, gcIndexFile, gcJSONStats}
 
-	return cmd
-}
+type gcStatus int
 
 const (
-	// TODO silverxia change to configuration
-	insertLogPrefix = `insert_log`
-	statsLogPrefix  = `stats_log`
-	deltaLogPrefix  = `delta_log`
+	gcValid gcStatus = iota
+	// pending objects are not referenced but not removable yet, like files of dropped segments waiting for datacoord gc
+	gcPending
+	gcGarbage
 )
 
-func garbageCollect(cli kv.MetaKV, basePath string, store oss.ObjectStore, minioRootPath string) {
-	segments, err := common.ListSegments(context.TODO(), cli, basePath, func(*models.Segment) bool { return true })
+type gcListJob struct {
+	objType string
+	prefix  string
+}
+
+type gcObject struct {
+	collectionID int64
+	objType      string
+	info         oss.ObjectInfo
+}
+
+// gcReferences is the meta snapshot objects are checked against.
+type gcReferences struct {
+	files    map[string]struct{}
+	segments map[int64]*models.Segment
+	// buildID => collectionID
+	indexBuilds map[int64]int64
+	// "buildID/version" of segment indexes in use
+	indexVersions map[string]struct{}
+	// "buildID/version/segmentID/fieldID" of json key stats in use
+	jsonStats map[string]struct{}
+}
+
+// checkGCRun rejects removing objects against meta pinned at an old revision,
+// objects referenced by latest meta but not by the snapshot would be removed.
+func checkGCRun(run bool, pinnedRevision int64) error {
+	if run && pinnedRevision > 0 {
+		return errors.Newf("cannot remove objects while meta is pinned at revision %d, run `set revision --latest` first", pinnedRevision)
+	}
+	return nil
+}
+
+func (s *InstanceState) GarbageCollectCommand(ctx context.Context, p *GarbageCollectParam) error {
+	minAge, err := time.ParseDuration(p.MinAge)
 	if err != nil {
-		fmt.Println("failed to list segments:", err.Error())
-		return
+		return errors.Wrap(err, "invalid minAge")
+	}
+	if p.WorkerNum < 1 || p.RateLimit < 1 {
+		return errors.New("workerNum and rateLimit shall be positive")
+	}
+	if err := checkGCRun(p.Run, s.revisionKV.Revision()); err != nil {
+		return err
 	}
 
-	idSegments := make(map[int64]*datapb.SegmentInfo)
-	binlog := make(map[string]struct{})
-	deltalog := make(map[string]struct{})
-	statslog := make(map[string]struct{})
+	params := []oss.MinioConnectParam{oss.WithSkipCheckBucket(p.SkipBucketCheck)}
+	if p.MinioAddress != "" {
+		params = append(params, oss.WithMinioAddr(p.MinioAddress))
+	}
+	resolved, err := s.GetObjectStore(ctx, params...)
+	if err != nil {
+		return err
+	}
+	var client *minio.Client
+	if p.Run {
+		var ok bool
+		client, ok = oss.MinioClientFromObjectStore(resolved.Store)
+		if !ok {
+			return errors.New("resolved object store does not support removing objects")
+		}
+	}
 
-	for _, info := range segments {
-		segment := info.SegmentInfo
-		common.FillFieldsIfV2(cli, basePath, segment)
-		idSegments[segment.ID] = segment
+	refs, err := s.loadGCReferences(ctx, resolved.RootPath)
+	if err != nil {
+		return err
+	}
 
-		if !isSegmentHealthy(segment) {
-			continue
-		}
-		for _, logs := range segment.GetBinlogs() {
-			for _, l := range logs.GetBinlogs() {
-				binlog[l.GetLogPath()] = struct{}{}
+	prefixes, err := listGCPrefixes(ctx, resolved, p.CollectionID)
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("gc_report_%s", time.Now().Format("2006-01-02T15:04:05"))
+	f, err := os.Create(fileName)
+	if err != nil {
+		return errors.Wrap(err, "failed to create report file")
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+
+	report := newGCReport(w)
+	garbage := make(chan gcObject, 1024)
+	deleteDone := make(chan struct{})
+	go func() {
+		defer close(deleteDone)
+		limiter := rate.NewLimiter(rate.Limit(p.RateLimit), 1)
+		for obj := range garbage {
+			if err := limiter.Wait(ctx); err != nil {
+				continue
+			}
+			if err := client.RemoveObject(ctx, resolved.BucketName, obj.info.Key, minio.RemoveObjectOptions{}); err != nil {
+				report.failed(fmt.Sprintf("failed to remove %s: %s", obj.info.Key, err.Error()))
+				continue
 			}
+			report.removed(obj)
 		}
+	}()
 
-		for _, statsLogs := range segment.GetStatslogs() {
-			for _, l := range statsLogs.GetBinlogs() {
-				statslog[l.GetLogPath()] = struct{}{}
+	now := time.Now()
+	workCh := make(chan gcListJob)
+	wg := sync.WaitGroup{}
+	for i := int64(0); i < p.WorkerNum; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range workCh {
+				ch, err := resolved.Store.List(ctx, job.prefix, true)
+				if err != nil {
+					report.failed(fmt.Sprintf("failed to list prefix %q: %s", job.prefix, err.Error()))
+					continue
+				}
+				for info := range ch {
+					if info.Err != nil {
+						report.failed(fmt.Sprintf("failed to list object under %q: %s", job.prefix, info.Err.Error()))
+						continue
+					}
+					if info.IsDir {
+						continue
+					}
+					collectionID, status, reason := refs.classify(resolved.RootPath, job.objType, info.Key)
+					if status == gcGarbage && !info.LastModified.IsZero() && now.Sub(info.LastModified) < minAge {
+						status, reason = gcPending, "modified recently"
+					}
+					if p.CollectionID != 0 && collectionID != p.CollectionID {
+						continue
+					}
+					obj := gcObject{collectionID: collectionID, objType: job.objType, info: info}
+					report.add(obj, status, reason)
+					if status == gcGarbage && p.Run {
+						garbage <- obj
+					}
+				}
 			}
-		}
+		}()
+	}
+	for _, prefix := range prefixes {
+		workCh <- prefix
+	}
+	close(workCh)
+	wg.Wait()
+	close(garbage)
+	<-deleteDone
 
-		for _, deltaLogs := range segment.GetDeltalogs() {
-			for _, l := range deltaLogs.GetBinlogs() {
-				deltalog[l.GetLogPath()] = struct{}{}
+	fmt.Print(report.format(p.Run))
+	if !p.Run {
+		fmt.Println("Dry run, use --run to remove garbage objects")
+	}
+	fmt.Printf("report file written to %s\n", fileName)
+	return nil
+}
+
+// loadGCReferences collects object references from segment, segment index and json stats meta.
+func (s *InstanceState) loadGCReferences(ctx context.Context, rootPath string) (*gcReferences, error) {
+	segments, err := common.ListSegments(ctx, s.client, s.basePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list segments")
+	}
+	segmentIndexes, err := common.ListSegmentIndex(ctx, s.client, s.basePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list segment indexes")
+	}
+
+	refs := &gcReferences{
+		files:         make(map[string]struct{}),
+		segments:      make(map[int64]*models.Segment),
+		indexBuilds:   make(map[int64]int64),
+		indexVersions: make(map[string]struct{}),
+		jsonStats:     make(map[string]struct{}),
+	}
+	// binlog meta is loaded explicitly instead of segment lazy loading, which hides load failure as empty binlogs.
+	segmentFiles, err := loadGCSegmentFiles(ctx, s.client, s.basePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, segment := range segments {
+		refs.segments[segment.ID] = segment
+		if segment.GetState() == commonpb.SegmentState_Dropped {
+			continue
+		}
+		for _, logPath := range segmentFiles[segment.ID] {
+			refs.files[oss.ResolveObjectKey(rootPath, logPath)] = struct{}{}
+		}
+		// binlogs embedded in segment info by legacy versions
+		for _, fieldBinlogs := range [][]*datapb.FieldBinlog{segment.SegmentInfo.GetBinlogs(), segment.SegmentInfo.GetStatslogs(), segment.SegmentInfo.GetDeltalogs(), segment.SegmentInfo.GetBm25Statslogs()} {
+			for _, fieldBinlog := range fieldBinlogs {
+				for _, binlog := range fieldBinlog.GetBinlogs() {
+					if binlog.GetLogPath() != "" {
+						refs.files[oss.ResolveObjectKey(rootPath, binlog.GetLogPath())] = struct{}{}
+					}
+				}
 			}
 		}
+		for fieldID, stats := range segment.GetJsonKeyStats() {
+			refs.jsonStats[fmt.Sprintf("%d/%d/%d/%d", stats.GetBuildID(), stats.GetVersion(), segment.ID, fieldID)] = struct{}{}
+		}
+	}
+	for _, segIdx := range segmentIndexes {
+		info := segIdx.GetProto()
+		refs.indexBuilds[info.GetBuildID()] = info.GetCollectionID()
+		segment, ok := refs.segments[info.GetSegmentID()]
+		if info.GetIsDeleted() || !ok || segment.GetState() == commonpb.SegmentState_Dropped {
+			continue
+		}
+		refs.indexVersions[fmt.Sprintf("%d/%d", info.GetBuildID(), info.GetIndexVersion())] = struct{}{}
 	}
+	return refs, nil
+}
 
-	// walk only data cluster related prefixes
-	prefixes := make([]string, 0, 3)
-	prefixes = append(prefixes, path.Join(minioRootPath, insertLogPrefix))
-	prefixes = append(prefixes, path.Join(minioRootPath, statsLogPrefix))
-	prefixes = append(prefixes, path.Join(minioRootPath, deltaLogPrefix))
+// gcBinlogMetaPrefixes maps datacoord binlog meta prefixes to object types of the files they reference.
+var gcBinlogMetaPrefixes = map[string]string{
+	"binlog":                      gcInsertLog,
+	common.SegmentStatsMetaPrefix: gcStatsLog,
+	"deltalog":                    gcDeltaLog,
+	common.SegmentBM25LogPrefix:   gcBM25Log,
+}
 
-	logs := make([]map[string]struct{}, 0, 3)
-	logs = append(logs, binlog, statslog, deltalog)
+// loadGCSegmentFiles loads field binlog meta of all segments, returns segment id to logical file paths.
+// Any load or decode failure is returned, since missing references make live files look like garbage.
+func loadGCSegmentFiles(ctx context.Context, cli kv.MetaKV, basePath string) (map[int64][]string, error) {
+	result := make(map[int64][]string)
+	for metaPrefix, objType := range gcBinlogMetaPrefixes {
+		prefix := path.Join(basePath, common.DCPrefix, metaPrefix) + "/"
+		keys, values, err := cli.LoadWithPrefix(ctx, prefix)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load %s meta", metaPrefix)
+		}
+		if len(keys) != len(values) {
+			return nil, errors.Newf("keys and values of %s meta mismatch: %d vs %d", metaPrefix, len(keys), len(values))
+		}
+		for i, key := range keys {
+			// tombstone marks removed binlog meta, no file referenced
+			if values[i] == string(common.CollectionTombstone) {
+				continue
+			}
+			fieldBinlog := &datapb.FieldBinlog{}
+			if err := proto.Unmarshal([]byte(values[i]), fieldBinlog); err != nil {
+				return nil, errors.Wrapf(err, "failed to decode binlog meta %s", key)
+			}
+			_, rel, _ := strings.Cut(key, path.Join(common.DCPrefix, metaPrefix)+"/")
+			segmentID, paths, err := gcFieldBinlogPaths(objType, rel, fieldBinlog)
+			if err != nil {
+				return nil, err
+			}
+			result[segmentID] = append(result[segmentID], paths...)
+		}
+	}
+	return result, nil
+}
 
-	fileName := fmt.Sprintf("gc_report_%s", time.Now().Format("2006-01-02T15:04:05"))
+// gcFieldBinlogPaths returns logical paths of files referenced by the field binlog meta under key "collection/partition/segment/field".
+func gcFieldBinlogPaths(objType, key string, fieldBinlog *datapb.FieldBinlog) (int64, []string, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) < 3 {
+		return 0, nil, errors.Newf("unrecognized binlog meta key %s", key)
+	}
+	ids := make([]int64, 3)
+	for i := range ids {
+		id, err := strconv.ParseInt(parts[i], 10, 64)
+		if err != nil {
+			return 0, nil, errors.Wrapf(err, "unrecognized binlog meta key %s", key)
+		}
+		ids[i] = id
+	}
+	collectionID, partitionID, segmentID := ids[0], ids[1], ids[2]
 
-	logOutput, err := os.Create(fileName)
-	if err != nil {
-		fmt.Println("failed to create file report file", err.Error())
+	var paths []string
+	for _, binlog := range fieldBinlog.GetBinlogs() {
+		if objType == gcDeltaLog {
+			paths = append(paths, fmt.Sprintf("ROOT_PATH/%s/%d/%d/%d/%d", objType, collectionID, partitionID, segmentID, binlog.GetLogID()))
+		} else {
+			paths = append(paths, fmt.Sprintf("ROOT_PATH/%s/%d/%d/%d/%d/%d", objType, collectionID, partitionID, segmentID, fieldBinlog.GetFieldID(), binlog.GetLogID()))
+		}
+		if binlog.GetLogPath() != "" {
+			paths = append(paths, binlog.GetLogPath())
+		}
 	}
-	defer logOutput.Close()
-	w := bufio.NewWriter(logOutput)
+	return segmentID, paths, nil
+}
 
-	counter := 0
-	for idx, prefix := range prefixes {
-		ch, err := store.List(context.TODO(), prefix, true)
-		if err != nil {
-			fmt.Fprintf(w, "failed to list prefix %q: %s\n", prefix, err.Error())
+// listGCPrefixes splits object prefixes into list jobs, one per top level directory of each object type.
+func listGCPrefixes(ctx context.Context, resolved *oss.ResolvedObjectStore, collectionID int64) ([]gcListJob, error) {
+	var result []gcListJob
+	for _, objType := range gcObjectTypes {
+		prefix := path.Join(resolved.RootPath, objType) + "/"
+		// index files are organized by build id, collection filter is applied after classification
+		if collectionID != 0 && objType != gcIndexFile && objType != gcJSONStats {
+			result = append(result, gcListJob{objType: objType, prefix: fmt.Sprintf("%s%d/", prefix, collectionID)})
 			continue
 		}
+		ch, err := resolved.Store.List(ctx, prefix, false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list prefix %q", prefix)
+		}
 		for info := range ch {
 			if info.Err != nil {
-				fmt.Fprintf(w, "failed to list object under %q: %s\n", prefix, info.Err.Error())
-				continue
-			}
-			fmt.Println(info.Key)
-			counter++
-			fmt.Fprintf(w, "Processing path: \"%s\"", info.Key)
-			logsMap := logs[idx]
-			_, has := logsMap[info.Key]
-			if has {
-				w.WriteString(", result: valid key\n")
-				continue
+				return nil, errors.Wrapf(info.Err, "failed to list prefix %q", prefix)
 			}
+			result = append(result, gcListJob{objType: objType, prefix: info.Key})
+		}
+	}
+	return result, nil
+}
 
-			segmentID, err := ParseSegmentIDByBinlog(minioRootPath, info.Key)
+// classify returns the collection the object belongs to and whether it is garbage.
+func (refs *gcReferences) classify(rootPath, objType, key string) (int64, gcStatus, string) {
+	rel := strings.TrimPrefix(strings.TrimPrefix(key, strings.Trim(rootPath, "/")), "/")
+	parts := strings.Split(strings.TrimPrefix(rel, objType+"/"), "/")
+	ids := func(n int) ([]int64, bool) {
+		if len(parts) < n {
+			return nil, false
+		}
+		result := make([]int64, n)
+		for i := 0; i < n; i++ {
+			v, err := strconv.ParseInt(parts[i], 10, 64)
 			if err != nil {
-				fmt.Fprintf(w, ", failed to parse segmentID: %s\n", err.Error())
-				continue
+				return nil, false
 			}
+			result[i] = v
+		}
+		return result, true
+	}
 
-			segment, ok := idSegments[segmentID]
-			if ok {
-				if segment.GetState() == commonpb.SegmentState_Dropped {
-					fmt.Fprintf(w, ", segment %d is dropped, waiting for gc\n", segmentID)
-					continue
-				}
+	switch objType {
+	case gcInsertLog, gcStatsLog, gcDeltaLog, gcBM25Log:
+		// collection/partition/segment/[field/]log
+		v, ok := ids(3)
+		if !ok {
+			return 0, gcPending, "unrecognized path"
+		}
+		if _, ok := refs.files[key]; ok {
+			return v[0], gcValid, ""
+		}
+		status, reason := refs.segmentStatus(v[2], "not referenced by segment meta")
+		return v[0], status, reason
+	case gcIndexFile:
+		// build/version/partition/segment/file
+		v, ok := ids(4)
+		if !ok {
+			return 0, gcPending, "unrecognized path"
+		}
+		collectionID, ok := refs.indexBuilds[v[0]]
+		if !ok {
+			if segment, ok := refs.segments[v[3]]; ok {
+				collectionID = segment.CollectionID
 			}
-
-			w.WriteString(", not relate meta found, maybe garbage\n")
 		}
+		if _, ok := refs.indexVersions[fmt.Sprintf("%d/%d", v[0], v[1])]; ok {
+			return collectionID, gcValid, ""
+		}
+		status, reason := refs.segmentStatus(v[3], "index build not in use")
+		return collectionID, status, reason
+	case gcJSONStats:
+		// format/build/version/collection/partition/segment/field/...
+		v, ok := ids(7)
+		if !ok {
+			return 0, gcPending, "unrecognized path"
+		}
+		if _, ok := refs.jsonStats[fmt.Sprintf("%d/%d/%d/%d", v[1], v[2], v[5], v[6])]; ok {
+			return v[3], gcValid, ""
+		}
+		status, reason := refs.segmentStatus(v[5], "json stats not in use")
+		return v[3], status, reason
 	}
-	w.Flush()
-	fmt.Printf("%d entries processed, report file written to %s\n", counter, fileName)
+	return 0, gcPending, "unknown object type"
+}
+
+func (refs *gcReferences) segmentStatus(segmentID int64, reason string) (gcStatus, string) {
+	segment, ok := refs.segments[segmentID]
+	if ok && segment.GetState() == commonpb.SegmentState_Dropped {
+		return gcPending, fmt.Sprintf("segment %d dropped, waiting for datacoord gc", segmentID)
+	}
+	if !ok {
+		return gcGarbage, fmt.Sprintf("segment %d not found", segmentID)
+	}
+	return gcGarbage, reason
+}
+
+type gcStat struct {
+	Num  int64
+	Size int64
+}
+
+type gcCollectionStat struct {
+	Valid   gcStat
+	Pending gcStat
+	Garbage gcStat
+	Removed gcStat
+}
+
+// gcReport aggregates object stats per collection and writes not valid objects to the detail writer.
+type gcReport struct {
+	mut    sync.Mutex
+	w      *bufio.Writer
+	stats  map[int64]map[string]*gcCollectionStat
+	errNum int
 }
 
-// ParseSegmentIDByBinlog parse segment id from binlog paths
-// if path format is not expected, returns error
-func ParseSegmentIDByBinlog(rootPath, path string) (int64, error) {
-	if !strings.HasPrefix(path, rootPath) {
-		return 0, fmt.Errorf("path:\"%s\" does not contains rootPath:\"%s\"", path, rootPath)
+func newGCReport(w *bufio.Writer) *gcReport {
+	return &gcReport{
+		w:     w,
+		stats: make(map[int64]map[string]*gcCollectionStat),
 	}
+}
 
-	// remove leading "/"
-	p := path[len(rootPath):]
-	for strings.HasPrefix(p, "/") {
-		p = p[1:]
+func (r *gcReport) stat(collectionID int64, objType string) *gcCollectionStat {
+	if r.stats[collectionID] == nil {
+		r.stats[collectionID] = make(map[string]*gcCollectionStat)
+	}
+	stat, ok := r.stats[collectionID][objType]
+	if !ok {
+		stat = &gcCollectionStat{}
+		r.stats[collectionID][objType] = stat
 	}
+	return stat
+}
 
-	// binlog path should consist of "insertLog/collID/partID/segID/fieldID/fileName"
-	keyStr := strings.Split(p, "/")
-	if len(keyStr) != 6 {
-		return 0, fmt.Errorf("%s is not a valid binlog path", p)
+func (r *gcReport) add(obj gcObject, status gcStatus, reason string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	stat := r.stat(obj.collectionID, obj.objType)
+	switch status {
+	case gcValid:
+		stat.Valid.Num++
+		stat.Valid.Size += obj.info.Size
+	case gcPending:
+		stat.Pending.Num++
+		stat.Pending.Size += obj.info.Size
+		fmt.Fprintf(r.w, "%s, pending: %s\n", obj.info.Key, reason)
+	case gcGarbage:
+		stat.Garbage.Num++
+		stat.Garbage.Size += obj.info.Size
+		fmt.Fprintf(r.w, "%s, garbage: %s\n", obj.info.Key, reason)
 	}
-	return strconv.ParseInt(keyStr[len(keyStr)-3], 10, 64)
 }
 
-func isSegmentHealthy(segment *datapb.SegmentInfo) bool {
-	return segment != nil &&
-		segment.GetState() != commonpb.SegmentState_SegmentStateNone &&
-		segment.GetState() != commonpb.SegmentState_NotExist &&
-		segment.GetState() != commonpb.SegmentState_Dropped
+func (r *gcReport) removed(obj gcObject) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	stat := r.stat(obj.collectionID, obj.objType)
+	stat.Removed.Num++
+	stat.Removed.Size += obj.info.Size
+}
+
+func (r *gcReport) failed(msg string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.errNum++
+	fmt.Fprintln(r.w, msg)
+}
+
+func (r *gcReport) format(run bool) string {
+	sb := &strings.Builder{}
+	headers := table.Row{"Collection", "Type", "Valid", "Valid Size", "Pending", "Pending Size", "Garbage", "Garbage Size"}
+	if run {
+		headers = append(headers, "Removed", "Removed Size")
+	}
+	var rows []table.Row
+	total := &gcCollectionStat{}
+	collections := lo.Keys(r.stats)
+	sort.Slice(collections, func(i, j int) bool { return collections[i] < collections[j] })
+	for _, collectionID := range collections {
+		for _, objType := range gcObjectTypes {
+			stat, ok := r.stats[collectionID][objType]
+			if !ok {
+				continue
+			}
+			name := "unknown"
+			if collectionID != 0 {
+				name = strconv.FormatInt(collectionID, 10)
+			}
+			row := table.Row{name, objType, stat.Valid.Num, hrSize(stat.Valid.Size), stat.Pending.Num, hrSize(stat.Pending.Size), stat.Garbage.Num, hrSize(stat.Garbage.Size)}
+			if run {
+				row = append(row, stat.Removed.Num, hrSize(stat.Removed.Size))
+			}
+			rows = append(rows, row)
+			for _, pair := range [][2]*gcStat{{&total.Valid, &stat.Valid}, {&total.Pending, &stat.Pending}, {&total.Garbage, &stat.Garbage}, {&total.Removed, &stat.Removed}} {
+				pair[0].Num += pair[1].Num
+				pair[0].Size += pair[1].Size
+			}
+		}
+	}
+	sb.WriteString(framework.RenderTable(headers, rows, "Object Storage Garbage Report"))
+	fmt.Fprintf(sb, "\nTotal: %d valid (%s), %d pending (%s), %d garbage (%s)",
+		total.Valid.Num, hrSize(total.Valid.Size), total.Pending.Num, hrSize(total.Pending.Size), total.Garbage.Num, hrSize(total.Garbage.Size))
+	if run {
+		fmt.Fprintf(sb, ", %d removed (%s)", total.Removed.Num, hrSize(total.Removed.Size))
+	}
+	if r.errNum > 0 {
+		fmt.Fprintf(sb, ", %d errors, see report file", r.errNum)
+	}
+	sb.WriteString("\n")
+	return sb.String()
 }
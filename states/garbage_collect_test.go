@@ -0,0 +1,76 @@
+package states
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/birdwatcher/models"
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/datapb"
+)
+
+func TestGCReferencesClassify(t *testing.T) {
+	refs := &gcReferences{
+		files: map[string]struct{}{"files/insert_log/1/2/100/101/1001": {}},
+		segments: map[int64]*models.Segment{
+			100: {SegmentInfo: &datapb.SegmentInfo{ID: 100, CollectionID: 1, State: commonpb.SegmentState_Flushed}},
+			200: {SegmentInfo: &datapb.SegmentInfo{ID: 200, CollectionID: 1, State: commonpb.SegmentState_Dropped}},
+		},
+		indexBuilds:   map[int64]int64{500: 1},
+		indexVersions: map[string]struct{}{"500/2": {}},
+		jsonStats:     map[string]struct{}{"7/1/100/101": {}},
+	}
+
+	cases := []struct {
+		objType      string
+		key          string
+		collectionID int64
+		status       gcStatus
+	}{
+		{gcInsertLog, "files/insert_log/1/2/100/101/1001", 1, gcValid},
+		{gcInsertLog, "files/insert_log/1/2/100/101/1002", 1, gcGarbage},
+		{gcInsertLog, "files/insert_log/1/2/200/101/1", 1, gcPending},
+		{gcInsertLog, "files/insert_log/1/2/300/101/1", 1, gcGarbage},
+		{gcDeltaLog, "files/delta_log/1/2/100/1", 1, gcGarbage},
+		{gcInsertLog, "files/insert_log/abc", 0, gcPending},
+		{gcIndexFile, "files/index_files/500/2/2/100/HNSW", 1, gcValid},
+		{gcIndexFile, "files/index_files/500/1/2/100/HNSW", 1, gcGarbage},
+		{gcIndexFile, "files/index_files/600/1/2/200/HNSW", 1, gcPending},
+		{gcJSONStats, "files/json_stats/2/7/1/1/2/100/101/meta.json", 1, gcValid},
+		{gcJSONStats, "files/json_stats/2/7/0/1/2/100/101/meta.json", 1, gcGarbage},
+	}
+	for _, c := range cases {
+		collectionID, status, _ := refs.classify("files", c.objType, c.key)
+		assert.Equal(t, c.collectionID, collectionID, c.key)
+		assert.Equal(t, c.status, status, c.key)
+	}
+}
+
+func TestGCFieldBinlogPaths(t *testing.T) {
+	fieldBinlog := &datapb.FieldBinlog{FieldID: 101, Binlogs: []*datapb.Binlog{{LogID: 1001}, {LogID: 1002, LogPath: "files/insert_log/1/2/100/101/1002"}}}
+	segmentID, paths, err := gcFieldBinlogPaths(gcInsertLog, "1/2/100/101", fieldBinlog)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 100, segmentID)
+	assert.Equal(t, []string{
+		"ROOT_PATH/insert_log/1/2/100/101/1001",
+		"ROOT_PATH/insert_log/1/2/100/101/1002",
+		"files/insert_log/1/2/100/101/1002",
+	}, paths)
+
+	_, paths, err = gcFieldBinlogPaths(gcDeltaLog, "1/2/100/0", &datapb.FieldBinlog{Binlogs: []*datapb.Binlog{{LogID: 7}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ROOT_PATH/delta_log/1/2/100/7"}, paths)
+
+	_, _, err = gcFieldBinlogPaths(gcInsertLog, "1/abc/100/101", fieldBinlog)
+	assert.Error(t, err)
+	_, _, err = gcFieldBinlogPaths(gcInsertLog, "1", fieldBinlog)
+	assert.Error(t, err)
+}
+
+func TestCheckGCRun(t *testing.T) {
+	assert.NoError(t, checkGCRun(false, 0))
+	assert.NoError(t, checkGCRun(false, 100))
+	assert.NoError(t, checkGCRun(true, 0))
+	assert.Error(t, checkGCRun(true, 100))
+}
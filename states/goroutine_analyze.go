@@ -0,0 +1,469 @@
+package states
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+
+	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/models"
+	"github.com/milvus-io/birdwatcher/states/etcd/common"
+)
+
+type GoroutineAnalyzeParam struct {
+	framework.ParamBase `use:"goroutine-analyze [archive|dump]" desc:"analyze debug=2 goroutine dumps from online components, a pprof archive or a dump file"`
+	source              string
+	Component           string `name:"component" default:"" desc:"component name to analyze, like querynode"`
+	ServerID            int64  `name:"serverID" default:"0" desc:"server id to analyze"`
+	Port                int64  `name:"port" default:"0" desc:"metrics port milvus component is using, detected from configuration if not set"`
+	Minutes             int64  `name:"minutes" default:"5" desc:"goroutines blocked for at least the minutes are reported as long-blocked"`
+	Top                 int64  `name:"top" default:"5" desc:"number of stack groups to show per section"`
+}
+
+func (p *GoroutineAnalyzeParam) ParseArgs(args []string) error {
+	if len(args) > 1 {
+		return errors.New("goroutine-analyze accepts at most one archive or dump file")
+	}
+	if len(args) == 1 {
+		p.source = args[0]
+	}
+	return nil
+}
+
+// GoroutineAnalyzeCommand groups goroutine stacks per component and reports the suspects of hanging.
+func (app *ApplicationState) GoroutineAnalyzeCommand(ctx context.Context, p *GoroutineAnalyzeParam) error {
+	var dumps map[string][]byte
+	var err error
+	if p.source != "" {
+		dumps, err = readGoroutineDumpFile(p.source)
+	} else {
+		dumps, err = app.fetchGoroutineDumps(ctx, p)
+	}
+	if err != nil {
+		return err
+	}
+
+	nodes := lo.Keys(dumps)
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		if p.Component != "" && !strings.Contains(node, p.Component) {
+			continue
+		}
+		goroutines, err := parseGoroutineDump(dumps[node])
+		if err != nil {
+			fmt.Printf("%s: %s\n", node, err.Error())
+			continue
+		}
+		fmt.Printf("=== %s ===\n", node)
+		fmt.Print(analyzeGoroutines(goroutines, p.Minutes).format(int(p.Top)))
+	}
+	return nil
+}
+
+func (app *ApplicationState) fetchGoroutineDumps(ctx context.Context, p *GoroutineAnalyzeParam) (map[string][]byte, error) {
+	cli, basePath, ok := app.connectedMeta()
+	if !ok {
+		return nil, errors.New("not connected to any instance, provide an archive or dump file")
+	}
+	sessions, err := common.ListSessions(ctx, cli, basePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list sessions")
+	}
+	sessions = lo.UniqBy(sessions, func(session *models.Session) int64 {
+		return session.ServerID
+	})
+	dumps := make(map[string][]byte)
+	for _, session := range sessions {
+		if (p.Component != "" && !strings.EqualFold(session.ServerName, p.Component)) ||
+			(p.ServerID != 0 && session.ServerID != p.ServerID) {
+			continue
+		}
+		port := p.Port
+		if port == 0 {
+			port = getMetricsPort(ctx, session)
+		}
+		data, err := fetchMetricsPath(ctx, session, port, "debug/pprof/goroutine?debug=2")
+		if err != nil {
+			fmt.Printf("failed to fetch goroutine dump of %s(%d), %s\n", session.ServerName, session.ServerID, err.Error())
+			continue
+		}
+		dumps[fmt.Sprintf("%s_%d", session.ServerName, session.ServerID)] = data
+	}
+	if len(dumps) == 0 {
+		return nil, errors.New("no goroutine dump fetched")
+	}
+	return dumps, nil
+}
+
+// readGoroutineDumpFile reads text dumps from a pprof archive, or a single dump file.
+func readGoroutineDumpFile(file string) (map[string][]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		// not gzipped, plain dump file
+		return map[string][]byte{file: data}, nil
+	}
+	defer gr.Close()
+
+	dumps := make(map[string][]byte)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read archive %s", file)
+		}
+		entry, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.HasPrefix(entry, []byte("goroutine ")) {
+			fmt.Printf("%s is not a debug=2 goroutine dump, capture with `pprof --type goroutine --debug 2`\n", header.Name)
+			continue
+		}
+		dumps[header.Name] = entry
+	}
+	return dumps, nil
+}
+
+type goroutineFrame struct {
+	Func string
+	Args string
+	File string
+}
+
+type goroutineInfo struct {
+	ID          int64
+	State       string
+	WaitMinutes int64
+	Frames      []goroutineFrame
+	CreatedBy   string
+	// CreatedAt is the location of the go statement creating the goroutine.
+	CreatedAt string
+}
+
+// stackKey identifies goroutines with the same state and call stack.
+func (g *goroutineInfo) stackKey() string {
+	return g.State + "|" + strings.Join(lo.Map(g.Frames, func(frame goroutineFrame, _ int) string {
+		return frame.Func
+	}), "|")
+}
+
+var goroutineHeaderRe = regexp.MustCompile(`^goroutine (\d+)(?: gp=\S+ m=\S+(?: mp=\S+)?)? \[([^\]]*)\]:$`)
+
+// parseGoroutineDump parses debug=2 goroutine dump text.
+func parseGoroutineDump(data []byte) ([]*goroutineInfo, error) {
+	var result []*goroutineInfo
+	var current *goroutineInfo
+	// the file line following `created by` is the creator location instead of a frame file
+	var pendingCreatedBy bool
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := goroutineHeaderRe.FindStringSubmatch(line); m != nil {
+			id, _ := strconv.ParseInt(m[1], 10, 64)
+			current = &goroutineInfo{ID: id}
+			parseGoroutineStatus(current, m[2])
+			result = append(result, current)
+			pendingCreatedBy = false
+			continue
+		}
+		if current == nil || line == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			file := strings.TrimSpace(line)
+			if idx := strings.LastIndex(file, " +0x"); idx > 0 {
+				file = file[:idx]
+			}
+			if pendingCreatedBy {
+				current.CreatedAt = file
+				pendingCreatedBy = false
+			} else if len(current.Frames) > 0 {
+				current.Frames[len(current.Frames)-1].File = file
+			}
+		case strings.HasPrefix(line, "created by "):
+			createdBy := strings.TrimPrefix(line, "created by ")
+			if idx := strings.Index(createdBy, " in goroutine "); idx > 0 {
+				createdBy = createdBy[:idx]
+			}
+			current.CreatedBy = createdBy
+			pendingCreatedBy = true
+		case strings.HasPrefix(line, "..."):
+			// additional frames elided
+		default:
+			fn, args := line, ""
+			if idx := strings.LastIndex(line, "("); idx > 0 && strings.HasSuffix(line, ")") {
+				fn, args = line[:idx], line[idx+1:len(line)-1]
+			}
+			current.Frames = append(current.Frames, goroutineFrame{Func: fn, Args: args})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, errors.New("no goroutine found, not a debug=2 goroutine dump")
+	}
+	return result, nil
+}
+
+// parseGoroutineStatus parses status like `chan receive, 12 minutes, locked to thread`.
+func parseGoroutineStatus(g *goroutineInfo, status string) {
+	parts := strings.Split(status, ", ")
+	g.State = parts[0]
+	for _, part := range parts[1:] {
+		if strings.HasSuffix(part, " minutes") {
+			g.WaitMinutes, _ = strconv.ParseInt(strings.TrimSuffix(part, " minutes"), 10, 64)
+		}
+	}
+}
+
+// isBlockingState tells whether the goroutine is parked waiting for others.
+func isBlockingState(state string) bool {
+	switch {
+	case strings.HasPrefix(state, "chan "), state == "select", state == "select (no cases)",
+		strings.HasPrefix(state, "semacquire"), strings.HasPrefix(state, "sync."):
+		return true
+	}
+	return false
+}
+
+func isRuntimeFrame(fn string) bool {
+	return strings.HasPrefix(fn, "runtime.") || strings.HasPrefix(fn, "sync.") || strings.HasPrefix(fn, "internal/")
+}
+
+var mutexFrameRe = regexp.MustCompile(`^sync\.\(\*(RW)?Mutex\)\.(Lock|RLock|lockSlow|rUnlockSlow)$`)
+
+// lockWait returns the lock waited by the goroutine and the index of the frame acquiring it,
+// the lock is identified by the mutex address if available, or the acquiring function.
+func (g *goroutineInfo) lockWait() (string, int, bool) {
+	if !strings.HasPrefix(g.State, "semacquire") && !strings.HasPrefix(g.State, "sync.") {
+		return "", 0, false
+	}
+	var addr string
+	for idx, frame := range g.Frames {
+		if mutexFrameRe.MatchString(frame.Func) && addr == "" {
+			if arg, _, _ := strings.Cut(frame.Args, ","); strings.HasPrefix(arg, "0x") {
+				addr = arg
+			}
+		}
+		if isRuntimeFrame(frame.Func) {
+			continue
+		}
+		if addr == "" {
+			return frame.Func, idx, true
+		}
+		return addr, idx, true
+	}
+	return "", 0, false
+}
+
+type goroutineGroup struct {
+	State      string
+	Count      int
+	MaxMinutes int64
+	IDs        []int64
+	Frames     []goroutineFrame
+	CreatedBy  string
+	CreatedAt  string
+}
+
+// lockEdge means goroutines likely holding From are waiting for To.
+type lockEdge struct {
+	From, To  string
+	Goroutine int64
+	Site      string
+}
+
+type goroutineReport struct {
+	Total   int
+	States  map[string]int
+	Groups  []*goroutineGroup
+	Blocked []*goroutineGroup
+	Cycles  [][]lockEdge
+}
+
+func groupGoroutines(goroutines []*goroutineInfo) []*goroutineGroup {
+	groups := make(map[string]*goroutineGroup)
+	for _, g := range goroutines {
+		key := g.stackKey()
+		group, ok := groups[key]
+		if !ok {
+			group = &goroutineGroup{State: g.State, Frames: g.Frames, CreatedBy: g.CreatedBy, CreatedAt: g.CreatedAt}
+			groups[key] = group
+		}
+		group.Count++
+		group.IDs = append(group.IDs, g.ID)
+		group.MaxMinutes = max(group.MaxMinutes, g.WaitMinutes)
+	}
+	result := lo.Values(groups)
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		if result[i].MaxMinutes != result[j].MaxMinutes {
+			return result[i].MaxMinutes > result[j].MaxMinutes
+		}
+		return result[i].IDs[0] < result[j].IDs[0]
+	})
+	return result
+}
+
+func analyzeGoroutines(goroutines []*goroutineInfo, minutes int64) *goroutineReport {
+	report := &goroutineReport{
+		Total:  len(goroutines),
+		States: lo.CountValuesBy(goroutines, func(g *goroutineInfo) string { return g.State }),
+		Groups: groupGoroutines(goroutines),
+	}
+	report.Blocked = groupGoroutines(lo.Filter(goroutines, func(g *goroutineInfo, _ int) bool {
+		return isBlockingState(g.State) && g.WaitMinutes >= minutes
+	}))
+	report.Cycles = findLockCycles(goroutines)
+	return report
+}
+
+// findLockCycles infers lock order from stacks: a goroutine waiting for lock A inside a function,
+// where other goroutines wait for lock B, likely holds B. Cycles of the order are reported.
+func findLockCycles(goroutines []*goroutineInfo) [][]lockEdge {
+	// acquiring function -> locks acquired there
+	sites := make(map[string]map[string]struct{})
+	type waiter struct {
+		g    *goroutineInfo
+		lock string
+		idx  int
+	}
+	var waiters []waiter
+	for _, g := range goroutines {
+		lock, idx, ok := g.lockWait()
+		if !ok {
+			continue
+		}
+		site := g.Frames[idx].Func
+		if sites[site] == nil {
+			sites[site] = make(map[string]struct{})
+		}
+		sites[site][lock] = struct{}{}
+		waiters = append(waiters, waiter{g: g, lock: lock, idx: idx})
+	}
+
+	edges := make(map[string]map[string]lockEdge)
+	for _, w := range waiters {
+		for _, frame := range w.g.Frames[w.idx+1:] {
+			for held := range sites[frame.Func] {
+				if held == w.lock {
+					continue
+				}
+				if edges[held] == nil {
+					edges[held] = make(map[string]lockEdge)
+				}
+				if _, ok := edges[held][w.lock]; !ok {
+					edges[held][w.lock] = lockEdge{From: held, To: w.lock, Goroutine: w.g.ID, Site: w.g.Frames[w.idx].Func}
+				}
+			}
+		}
+	}
+
+	var cycles [][]lockEdge
+	seen := make(map[string]struct{})
+	locks := lo.Keys(edges)
+	sort.Strings(locks)
+	for _, start := range locks {
+		// depth first search for path back to start, only from the smallest lock of a cycle
+		var path []lockEdge
+		visited := make(map[string]bool)
+		var dfs func(lock string) bool
+		dfs = func(lock string) bool {
+			visited[lock] = true
+			next := lo.Keys(edges[lock])
+			sort.Strings(next)
+			for _, to := range next {
+				edge := edges[lock][to]
+				if to == start {
+					path = append(path, edge)
+					return true
+				}
+				if to < start || visited[to] {
+					continue
+				}
+				path = append(path, edge)
+				if dfs(to) {
+					return true
+				}
+				path = path[:len(path)-1]
+			}
+			return false
+		}
+		if dfs(start) {
+			key := strings.Join(lo.Map(path, func(e lockEdge, _ int) string { return e.From }), "->")
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				cycles = append(cycles, path)
+			}
+		}
+	}
+	return cycles
+}
+
+func formatGoroutineGroup(sb *strings.Builder, group *goroutineGroup) {
+	ids := lo.Subset(group.IDs, 0, 5)
+	fmt.Fprintf(sb, "  %d goroutine(s) [%s", group.Count, group.State)
+	if group.MaxMinutes > 0 {
+		fmt.Fprintf(sb, ", up to %d minutes", group.MaxMinutes)
+	}
+	fmt.Fprintf(sb, "] ids: %v\n", ids)
+	for _, frame := range group.Frames {
+		fmt.Fprintf(sb, "\t%s\n\t\t%s\n", frame.Func, frame.File)
+	}
+	if group.CreatedBy != "" {
+		fmt.Fprintf(sb, "\tcreated by %s\n\t\t%s\n", group.CreatedBy, group.CreatedAt)
+	}
+}
+
+func (r *goroutineReport) format(top int) string {
+	sb := &strings.Builder{}
+	states := lo.Keys(r.States)
+	sort.Slice(states, func(i, j int) bool {
+		return r.States[states[i]] > r.States[states[j]] || (r.States[states[i]] == r.States[states[j]] && states[i] < states[j])
+	})
+	fmt.Fprintf(sb, "Total goroutines: %d, stack groups: %d\n", r.Total, len(r.Groups))
+	fmt.Fprintf(sb, "States: %s\n", strings.Join(lo.Map(states, func(state string, _ int) string {
+		return fmt.Sprintf("%s=%d", state, r.States[state])
+	}), ", "))
+
+	fmt.Fprintf(sb, "--- Top stack groups ---\n")
+	for _, group := range lo.Subset(r.Groups, 0, uint(top)) {
+		formatGoroutineGroup(sb, group)
+	}
+	fmt.Fprintf(sb, "--- Long-blocked goroutines (%d groups) ---\n", len(r.Blocked))
+	for _, group := range lo.Subset(r.Blocked, 0, uint(top)) {
+		formatGoroutineGroup(sb, group)
+	}
+	fmt.Fprintf(sb, "--- Likely lock-order cycles (%d) ---\n", len(r.Cycles))
+	for idx, cycle := range r.Cycles {
+		fmt.Fprintf(sb, "  cycle %d:\n", idx+1)
+		for _, edge := range cycle {
+			fmt.Fprintf(sb, "\tholding %s, goroutine %d waits for %s in %s\n", edge.From, edge.Goroutine, edge.To, edge.Site)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,101 @@
+package states
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGoroutineDump = `goroutine 1 [running]:
+main.main()
+	/app/main.go:10 +0x1d
+
+goroutine 10 [sync.Mutex.Lock, 12 minutes]:
+sync.runtime_SemacquireMutex(0xc000010008?, 0x0?, 0x1?)
+	/usr/local/go/src/runtime/sema.go:77 +0x25
+sync.(*Mutex).lockSlow(0xc000010000)
+	/usr/local/go/src/sync/mutex.go:171 +0x15d
+sync.(*Mutex).Lock(...)
+	/usr/local/go/src/sync/mutex.go:90
+main.(*meta).update(0xc000020000)
+	/app/meta.go:20 +0x45
+main.(*cache).refresh(0xc000030000)
+	/app/cache.go:30 +0x25
+created by main.start in goroutine 1
+	/app/main.go:15 +0x66
+
+goroutine 11 [sync.Mutex.Lock, 12 minutes]:
+sync.runtime_SemacquireMutex(0xc000040008?, 0x0?, 0x1?)
+	/usr/local/go/src/runtime/sema.go:77 +0x25
+sync.(*Mutex).lockSlow(0xc000040000)
+	/usr/local/go/src/sync/mutex.go:171 +0x15d
+sync.(*Mutex).Lock(...)
+	/usr/local/go/src/sync/mutex.go:90
+main.(*cache).refresh(0xc000030000)
+	/app/cache.go:28 +0x25
+main.(*meta).update(0xc000020000)
+	/app/meta.go:22 +0x45
+created by main.start in goroutine 1
+	/app/main.go:16 +0x66
+
+goroutine 20 [chan receive, 7 minutes]:
+main.worker(0xc000050000)
+	/app/worker.go:5 +0x30
+created by main.start in goroutine 1
+	/app/main.go:17 +0x66
+
+goroutine 21 [chan receive, 2 minutes]:
+main.worker(0xc000050000)
+	/app/worker.go:5 +0x30
+created by main.start in goroutine 1
+	/app/main.go:17 +0x66
+`
+
+func TestAnalyzeGoroutines(t *testing.T) {
+	goroutines, err := parseGoroutineDump([]byte(testGoroutineDump))
+	require.NoError(t, err)
+	require.Len(t, goroutines, 5)
+	assert.EqualValues(t, 10, goroutines[1].ID)
+	assert.Equal(t, "sync.Mutex.Lock", goroutines[1].State)
+	assert.EqualValues(t, 12, goroutines[1].WaitMinutes)
+	assert.Equal(t, "main.start", goroutines[1].CreatedBy)
+	require.Len(t, goroutines[1].Frames, 5)
+	assert.Equal(t, "/app/meta.go:20", goroutines[1].Frames[3].File)
+
+	report := analyzeGoroutines(goroutines, 5)
+	assert.Equal(t, 5, report.Total)
+	assert.Equal(t, 2, report.States["chan receive"])
+	require.Len(t, report.Groups, 4)
+	assert.Equal(t, 2, report.Groups[0].Count)
+	assert.EqualValues(t, 7, report.Groups[0].MaxMinutes)
+
+	require.Len(t, report.Blocked, 3)
+	assert.EqualValues(t, 12, report.Blocked[0].MaxMinutes)
+	assert.Equal(t, []int64{20}, report.Blocked[2].IDs)
+
+	require.Len(t, report.Cycles, 1)
+	require.Len(t, report.Cycles[0], 2)
+	assert.Equal(t, "0xc000010000", report.Cycles[0][0].From)
+	assert.Equal(t, "0xc000040000", report.Cycles[0][0].To)
+	assert.Equal(t, "0xc000010000", report.Cycles[0][1].To)
+
+	_, err = parseGoroutineDump([]byte("not a dump"))
+	assert.Error(t, err)
+}
+
+func TestParseGoroutineCreatedBy(t *testing.T) {
+	dump := `goroutine 7 gp=0xc000007c00 m=nil [select, 3 minutes]:
+main.loop(0xc000060000)
+	/app/loop.go:42 +0x1b0
+created by main.(*server).start in goroutine 1
+	/app/server.go:88 +0x9c
+`
+	goroutines, err := parseGoroutineDump([]byte(dump))
+	require.NoError(t, err)
+	require.Len(t, goroutines, 1)
+	require.Len(t, goroutines[0].Frames, 1)
+	assert.Equal(t, "/app/loop.go:42", goroutines[0].Frames[0].File)
+	assert.Equal(t, "main.(*server).start", goroutines[0].CreatedBy)
+	assert.Equal(t, "/app/server.go:88", goroutines[0].CreatedAt)
+}
@@ -612,6 +612,12 @@ func inferCloudProviderFromScheme(scheme string) string {
 		return oss.CloudProviderAWS
 	case "gs":
 		return oss.CloudProviderGCP
+	case "obs":
+		return oss.CloudProviderHuawei
+	case "cos":
+		return oss.CloudProviderTencent
+	case "minio":
+		return oss.CloudProviderAWS
 	default:
 		return ""
 	}
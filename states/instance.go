@@ -32,6 +32,7 @@ type InstanceState struct {
 	metaPath     string
 	client       metakv.MetaKV
 	auditFile    *os.File
+	revisionKV   *metakv.RevisionKV
 
 	etcdState           framework.State
 	config              *configs.Config
@@ -107,8 +108,6 @@ func (s *InstanceState) SetupCommands() {
 
 		// remove-segment-by-id
 		// removeSegmentByID(cli, basePath),
-		// garbage-collect
-		getGarbageCollectCmd(cli, basePath),
 		// release-dropped-collection
 		getReleaseDroppedCollectionCmd(cli, basePath),
 
@@ -162,6 +161,10 @@ func (s *InstanceState) GetObjectStore(ctx context.Context, params ...oss.MinioC
 }
 
 func GetInstanceState(parent *framework.CmdState, cli metakv.MetaKV, instanceName, metaPath string, etcdState framework.State, config *configs.Config, extensions []Extension, objectStoreProvider ObjectStoreProvider) framework.State {
+	// all components read through the revision kv, so `set revision` applies to every command.
+	revisionKV := metakv.NewRevisionKV(cli)
+	cli = revisionKV
+
 	var kv metakv.MetaKV
 	name := fmt.Sprintf("audit_%s.log", time.Now().Format("2006_0102_150405"))
 	file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
@@ -185,6 +188,7 @@ func GetInstanceState(parent *framework.CmdState, cli metakv.MetaKV, instanceNam
 		metaPath:        metaPath,
 		client:          kv,
 		auditFile:       file,
+		revisionKV:      revisionKV,
 
 		etcdState:           etcdState,
 		config:              config,
@@ -0,0 +1,322 @@
+package states
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/samber/lo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/models"
+	"github.com/milvus-io/birdwatcher/states/etcd/common"
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/querypb"
+)
+
+// jemallocSample is one memory sample of a node, stored as a json line in the series file.
+type jemallocSample struct {
+	Node      string    `json:"node"`
+	NodeID    int64     `json:"node_id"`
+	Time      time.Time `json:"time"`
+	RSS       uint64    `json:"rss"`
+	Allocated uint64    `json:"jemalloc_allocated"`
+	Active    uint64    `json:"jemalloc_active"`
+	Resident  uint64    `json:"jemalloc_resident"`
+	Retained  uint64    `json:"jemalloc_retained"`
+	Segments  int64     `json:"loaded_segments"`
+	Error     string    `json:"error,omitempty"`
+	// SegmentError is set when memory stats are fetched but loaded segments are not.
+	SegmentError string `json:"segment_error,omitempty"`
+}
+
+// sampleJemallocStats polls memory stats and loaded segments of nodes and summarizes the trend.
+func (s *InstanceState) sampleJemallocStats(ctx context.Context, p *JemallocStatsParam) error {
+	interval, err := time.ParseDuration(p.Interval)
+	if err != nil {
+		return errors.Wrap(err, "invalid interval")
+	}
+	if interval <= 0 {
+		return errors.New("interval shall be positive")
+	}
+	nodeType := p.NodeType
+	if nodeType == "" {
+		nodeType = "querynode"
+	}
+
+	sessions, err := common.ListSessions(ctx, s.client, s.basePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to list sessions")
+	}
+	sessions = lo.UniqBy(lo.Filter(sessions, func(session *models.Session, _ int) bool {
+		return session.ServerName == nodeType
+	}), func(session *models.Session) int64 {
+		return session.ServerID
+	})
+	if len(sessions) == 0 {
+		fmt.Println("No matching nodes found")
+		return nil
+	}
+
+	fileName := path.Join(s.config.WorkspacePath, fmt.Sprintf("jemalloc_series_%s.jsonl", time.Now().Format("20060102150405")))
+	f, err := os.Create(fileName)
+	if err != nil {
+		return errors.Wrap(err, "failed to create series file")
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+	enc := json.NewEncoder(w)
+
+	var series []jemallocSample
+sampling:
+	for seq := int64(0); seq < p.Samples; seq++ {
+		if seq > 0 {
+			select {
+			case <-ctx.Done():
+				fmt.Println("sampling interrupted")
+				break sampling
+			case <-time.After(interval):
+			}
+		}
+		samples := collectJemallocSamples(ctx, sessions)
+		for _, sample := range samples {
+			if err := enc.Encode(sample); err != nil {
+				return errors.Wrap(err, "failed to write series file")
+			}
+		}
+		series = append(series, samples...)
+		fmt.Printf("sample %d/%d collected from %d nodes\n", seq+1, p.Samples, len(samples))
+	}
+
+	fmt.Print(formatJemallocSeriesSummary(summarizeJemallocSeries(series)))
+	fmt.Printf("series written to %s\n", fileName)
+	return nil
+}
+
+func collectJemallocSamples(ctx context.Context, sessions []*models.Session) []jemallocSample {
+	result := make([]jemallocSample, len(sessions))
+	wg := sync.WaitGroup{}
+	for idx, session := range sessions {
+		wg.Add(1)
+		go func(idx int, session *models.Session) {
+			defer wg.Done()
+			sample := jemallocSample{Node: session.ServerName, NodeID: session.ServerID, Time: time.Now()}
+			reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			stats, err := fetchJemallocStats(reqCtx, session, false)
+			if err != nil {
+				sample.Error = err.Error()
+				result[idx] = sample
+				return
+			}
+			sample.RSS = stats.memoryUsage
+			sample.Allocated = stats.allocated
+			sample.Active = stats.active
+			sample.Resident = stats.resident
+			sample.Retained = stats.retained
+			if session.ServerName == "querynode" {
+				sample.Segments, err = fetchLoadedSegmentNum(reqCtx, session)
+				if err != nil {
+					sample.SegmentError = err.Error()
+				}
+			}
+			result[idx] = sample
+		}(idx, session)
+	}
+	wg.Wait()
+	return result
+}
+
+// fetchLoadedSegmentNum returns number of sealed segments loaded by the querynode, same as `show segment-loaded-grpc`.
+func fetchLoadedSegmentNum(ctx context.Context, session *models.Session) (int64, error) {
+	conn, err := grpc.DialContext(ctx, session.Address,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock(),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(512*1024*1024)),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to connect")
+	}
+	defer conn.Close()
+
+	resp, err := querypb.NewQueryNodeClient(conn).GetDataDistribution(ctx, &querypb.GetDataDistributionRequest{
+		Base: &commonpb.MsgBase{
+			SourceID: -1,
+			TargetID: session.ServerID,
+		},
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "GetDataDistribution failed")
+	}
+	return int64(len(resp.GetSegments())), nil
+}
+
+type jemallocSeriesSummary struct {
+	Node    string
+	NodeID  int64
+	Samples int
+	Errors  int
+	First   jemallocSample
+	Last    jemallocSample
+	// growth rates in bytes per hour, by least square fit
+	RSSRate       float64
+	AllocatedRate float64
+	// fragmentation of last sample, (active-allocated)/active and resident/allocated
+	Fragmentation float64
+	ResidentRatio float64
+	// loaded segment number of first and last sample with segments fetched
+	FirstSegments int64
+	LastSegments  int64
+	SegmentErrors int
+	// pearson correlation between rss and loaded segment number
+	SegmentCorrelation float64
+}
+
+func summarizeJemallocSeries(series []jemallocSample) []*jemallocSeriesSummary {
+	groups := lo.GroupBy(series, func(sample jemallocSample) int64 { return sample.NodeID })
+	var result []*jemallocSeriesSummary
+	for nodeID, samples := range groups {
+		summary := &jemallocSeriesSummary{Node: samples[0].Node, NodeID: nodeID}
+		valid := lo.Filter(samples, func(sample jemallocSample, _ int) bool {
+			return sample.Error == ""
+		})
+		sort.Slice(valid, func(i, j int) bool { return valid[i].Time.Before(valid[j].Time) })
+		summary.Samples = len(valid)
+		summary.Errors = len(samples) - len(valid)
+		result = append(result, summary)
+		if len(valid) == 0 {
+			continue
+		}
+		summary.First, summary.Last = valid[0], valid[len(valid)-1]
+		if summary.Last.Active > 0 {
+			summary.Fragmentation = float64(int64(summary.Last.Active)-int64(summary.Last.Allocated)) / float64(summary.Last.Active)
+		}
+		if summary.Last.Allocated > 0 {
+			summary.ResidentRatio = float64(summary.Last.Resident) / float64(summary.Last.Allocated)
+		}
+
+		hours := lo.Map(valid, func(sample jemallocSample, _ int) float64 {
+			return sample.Time.Sub(valid[0].Time).Hours()
+		})
+		rss := lo.Map(valid, func(sample jemallocSample, _ int) float64 { return float64(sample.RSS) })
+		summary.RSSRate = linearSlope(hours, rss)
+		summary.AllocatedRate = linearSlope(hours, lo.Map(valid, func(sample jemallocSample, _ int) float64 { return float64(sample.Allocated) }))
+
+		// samples failed to fetch loaded segments still count for memory, but not for segment correlation
+		withSegments := lo.Filter(valid, func(sample jemallocSample, _ int) bool { return sample.SegmentError == "" })
+		summary.SegmentErrors = len(valid) - len(withSegments)
+		summary.SegmentCorrelation = math.NaN()
+		if len(withSegments) > 0 {
+			summary.FirstSegments, summary.LastSegments = withSegments[0].Segments, withSegments[len(withSegments)-1].Segments
+			summary.SegmentCorrelation = pearsonCorrelation(
+				lo.Map(withSegments, func(sample jemallocSample, _ int) float64 { return float64(sample.RSS) }),
+				lo.Map(withSegments, func(sample jemallocSample, _ int) float64 { return float64(sample.Segments) }))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].NodeID < result[j].NodeID })
+	return result
+}
+
+// linearSlope returns least square fitted slope of y over x, 0 if not computable.
+func linearSlope(x, y []float64) float64 {
+	if len(x) < 2 {
+		return 0
+	}
+	meanX, meanY := lo.Sum(x)/float64(len(x)), lo.Sum(y)/float64(len(y))
+	var num, den float64
+	for i := range x {
+		num += (x[i] - meanX) * (y[i] - meanY)
+		den += (x[i] - meanX) * (x[i] - meanX)
+	}
+	if den == 0 {
+		return 0
+	}
+	return num / den
+}
+
+// pearsonCorrelation returns the correlation coefficient, NaN if either series is constant.
+func pearsonCorrelation(x, y []float64) float64 {
+	if len(x) < 2 {
+		return math.NaN()
+	}
+	meanX, meanY := lo.Sum(x)/float64(len(x)), lo.Sum(y)/float64(len(y))
+	var cov, varX, varY float64
+	for i := range x {
+		cov += (x[i] - meanX) * (y[i] - meanY)
+		varX += (x[i] - meanX) * (x[i] - meanX)
+		varY += (y[i] - meanY) * (y[i] - meanY)
+	}
+	if varX == 0 || varY == 0 {
+		return math.NaN()
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+func formatRate(bytesPerHour float64) string {
+	if bytesPerHour < 0 {
+		return "-" + formatBytes(uint64(-bytesPerHour)) + "/h"
+	}
+	return "+" + formatBytes(uint64(bytesPerHour)) + "/h"
+}
+
+func formatJemallocSeriesSummary(summaries []*jemallocSeriesSummary) string {
+	sb := &strings.Builder{}
+	headers := table.Row{"Node", "Samples", "RSS", "RSS Growth", "Allocated", "Alloc Growth", "Fragmentation", "Resident/Alloc", "Segments", "RSS~Segments"}
+	var rows []table.Row
+	for _, summary := range summaries {
+		node := fmt.Sprintf("%s-%d", summary.Node, summary.NodeID)
+		samples := fmt.Sprintf("%d", summary.Samples)
+		if summary.Errors > 0 {
+			samples = fmt.Sprintf("%d (%d failed)", summary.Samples, summary.Errors)
+		}
+		if summary.Samples == 0 {
+			rows = append(rows, table.Row{node, samples, "-", "-", "-", "-", "-", "-", "-", "-"})
+			continue
+		}
+		correlation := "n/a"
+		if !math.IsNaN(summary.SegmentCorrelation) {
+			correlation = fmt.Sprintf("%.2f", summary.SegmentCorrelation)
+		}
+		segments := fmt.Sprintf("%d -> %d", summary.FirstSegments, summary.LastSegments)
+		if summary.SegmentErrors > 0 {
+			segments = fmt.Sprintf("%s (%d failed)", segments, summary.SegmentErrors)
+		}
+		rows = append(rows, table.Row{
+			node, samples,
+			fmt.Sprintf("%s -> %s", formatBytes(summary.First.RSS), formatBytes(summary.Last.RSS)),
+			formatRate(summary.RSSRate),
+			fmt.Sprintf("%s -> %s", formatBytes(summary.First.Allocated), formatBytes(summary.Last.Allocated)),
+			formatRate(summary.AllocatedRate),
+			fmt.Sprintf("%.2f%%", summary.Fragmentation*100),
+			fmt.Sprintf("%.2f", summary.ResidentRatio),
+			segments,
+			correlation,
+		})
+	}
+	sb.WriteString(framework.RenderTable(headers, rows, "Memory Trend Summary"))
+	sb.WriteString("\n")
+	for _, summary := range summaries {
+		if summary.Samples < 2 || summary.RSSRate <= 0 {
+			continue
+		}
+		// growth not explained by loading segments
+		if summary.FirstSegments == summary.LastSegments || math.IsNaN(summary.SegmentCorrelation) || summary.SegmentCorrelation < 0.5 {
+			fmt.Fprintf(sb, "%s-%d: RSS grows %s without matching segment loading, suspect leak or fragmentation\n",
+				summary.Node, summary.NodeID, formatRate(summary.RSSRate))
+		}
+	}
+	return sb.String()
+}
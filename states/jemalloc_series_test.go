@@ -0,0 +1,77 @@
+package states
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeJemallocSeries(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var series []jemallocSample
+	for i := 0; i < 4; i++ {
+		ts := start.Add(time.Duration(i) * 30 * time.Minute)
+		// node 1 grows with loaded segments
+		series = append(series, jemallocSample{
+			Node: "querynode", NodeID: 1, Time: ts,
+			RSS: uint64(1000 + i*100), Allocated: 800, Active: 1000, Resident: 1200, Segments: int64(10 + i),
+		})
+		// node 2 grows with constant segments
+		series = append(series, jemallocSample{
+			Node: "querynode", NodeID: 2, Time: ts,
+			RSS: uint64(2000 + i*50), Allocated: uint64(1000 + i*50), Active: 1000, Resident: 1000, Segments: 5,
+		})
+	}
+	series = append(series, jemallocSample{Node: "querynode", NodeID: 2, Time: start, Error: "timeout"})
+
+	summaries := summarizeJemallocSeries(series)
+	require.Len(t, summaries, 2)
+
+	assert.EqualValues(t, 1, summaries[0].NodeID)
+	assert.Equal(t, 4, summaries[0].Samples)
+	assert.InDelta(t, 200, summaries[0].RSSRate, 1e-6)
+	assert.InDelta(t, 0, summaries[0].AllocatedRate, 1e-6)
+	assert.InDelta(t, 0.2, summaries[0].Fragmentation, 1e-6)
+	assert.InDelta(t, 1.5, summaries[0].ResidentRatio, 1e-6)
+	assert.InDelta(t, 1, summaries[0].SegmentCorrelation, 1e-6)
+
+	assert.Equal(t, 1, summaries[1].Errors)
+	assert.InDelta(t, 100, summaries[1].RSSRate, 1e-6)
+	assert.True(t, math.IsNaN(summaries[1].SegmentCorrelation))
+
+	output := formatJemallocSeriesSummary(summaries)
+	assert.Contains(t, output, "querynode-2: RSS grows")
+	assert.NotContains(t, output, "querynode-1: RSS grows")
+}
+
+func TestSummarizeJemallocSeriesSegmentError(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var series []jemallocSample
+	for i := 0; i < 3; i++ {
+		series = append(series, jemallocSample{
+			Node: "querynode", NodeID: 1, Time: start.Add(time.Duration(i) * time.Hour),
+			RSS: uint64(1000 + i*100), Allocated: 800, Active: 1000, Resident: 1000, Segments: int64(10 + i),
+		})
+	}
+	// memory stats fetched, loaded segments not
+	series = append(series, jemallocSample{
+		Node: "querynode", NodeID: 1, Time: start.Add(3 * time.Hour),
+		RSS: 1300, Allocated: 800, Active: 1000, Resident: 1000, SegmentError: "GetDataDistribution failed",
+	})
+
+	summaries := summarizeJemallocSeries(series)
+	require.Len(t, summaries, 1)
+	summary := summaries[0]
+	assert.Equal(t, 4, summary.Samples)
+	assert.Equal(t, 0, summary.Errors)
+	assert.Equal(t, 1, summary.SegmentErrors)
+	assert.EqualValues(t, 1300, summary.Last.RSS)
+	assert.InDelta(t, 100, summary.RSSRate, 1e-6)
+	assert.EqualValues(t, 10, summary.FirstSegments)
+	assert.EqualValues(t, 12, summary.LastSegments)
+	assert.InDelta(t, 1, summary.SegmentCorrelation, 1e-6)
+	assert.Contains(t, formatJemallocSeriesSummary(summaries), "10 -> 12 (1 failed)")
+}
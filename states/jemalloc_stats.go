@@ -28,10 +28,16 @@ type JemallocStatsParam struct {
 	framework.ParamBase `use:"jemalloc-stats" desc:"get jemalloc memory statistics from Milvus nodes"`
 	Full                bool   `name:"full" default:"false" desc:"print full system info JSON"`
 	NodeType            string `name:"nodeType" default:"" desc:"filter by node type (querynode, datanode, etc)"`
+	Samples             int64  `name:"samples" default:"1" desc:"number of samples to poll, sampling mode tracks memory trend of querynodes if more than one"`
+	Interval            string `name:"interval" default:"30s" desc:"interval between two samples in sampling mode"`
 }
 
 // JemallocStatsCommand implements jemalloc-stats command
 func (s *InstanceState) JemallocStatsCommand(ctx context.Context, p *JemallocStatsParam) error {
+	if p.Samples > 1 {
+		return s.sampleJemallocStats(ctx, p)
+	}
+
 	sessions, err := common.ListSessions(ctx, s.client, s.basePath)
 	if err != nil {
 		return errors.Wrap(err, "failed to list sessions")
@@ -9,11 +9,13 @@ import (
 	"fmt"
 	"math"
 	"path"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/gosuri/uilive"
+	"github.com/samber/lo"
 	tikv "github.com/tikv/client-go/v2/kv"
 	"github.com/tikv/client-go/v2/txnkv"
 	"go.etcd.io/etcd/api/v3/mvccpb"
@@ -55,12 +57,18 @@ type MetaKV interface {
 	removeWithPrevKV(ctx context.Context, key string) (*mvccpb.KeyValue, error)
 	removeWithPrefixAndPrevKV(ctx context.Context, prefix string) ([]*mvccpb.KeyValue, error)
 	GetAllRootPath(ctx context.Context) ([]string, error)
-	BackupKV(base, prefix string, w *bufio.Writer, ignoreRevision bool, batchSize int64) error
+	BackupKV(base, prefix string, w *bufio.Writer, ignoreRevision bool, batchSize int64, opts ...BackupOption) error
 	WalkWithPrefix(ctx context.Context, prefix string, paginationSize int, fn func([]byte, []byte) error) error
 	Close()
 }
 
 func MustGetETCDClient(kv MetaKV) *clientv3.Client {
+	switch wrapped := kv.(type) {
+	case *RevisionKV:
+		return MustGetETCDClient(wrapped.cli)
+	case *FileAuditKV:
+		return MustGetETCDClient(wrapped.cli)
+	}
 	etcd := kv.(*etcdKV)
 	return etcd.client
 }
@@ -214,10 +222,25 @@ func writeBackupBytes(w *bufio.Writer, data []byte) {
 	}
 }
 
-func (kv *etcdKV) BackupKV(base, prefix string, w *bufio.Writer, ignoreRevision bool, batchSize int64) error {
+func (kv *etcdKV) BackupKV(base, prefix string, w *bufio.Writer, ignoreRevision bool, batchSize int64, opts ...BackupOption) error {
+	opt := &backupOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
 	defer cancel()
-	resp, err := kv.client.Get(ctx, joinPath(base, prefix), clientv3.WithCountOnly(), clientv3.WithPrefix())
+	countOptions := []clientv3.OpOption{clientv3.WithCountOnly(), clientv3.WithPrefix()}
+	if opt.revision > 0 {
+		if ignoreRevision {
+			return errors.New("backup at revision cannot ignore revision")
+		}
+		if opt.sinceRevision >= opt.revision {
+			return errors.Newf("base revision %d is not older than backup revision %d", opt.sinceRevision, opt.revision)
+		}
+		countOptions = append(countOptions, clientv3.WithRev(opt.revision))
+	}
+	resp, err := kv.client.Get(ctx, joinPath(base, prefix), countOptions...)
 	if err != nil {
 		return err
 	}
@@ -225,12 +248,37 @@ func (kv *etcdKV) BackupKV(base, prefix string, w *bufio.Writer, ignoreRevision
 	if ignoreRevision {
 		fmt.Println("WARNING!!! doing backup ignore revision! please make sure no instance of milvus is online!")
 	}
+	if opt.sinceRevision > 0 && ignoreRevision {
+		return errors.New("incremental backup cannot ignore revision")
+	}
 
 	// meta stored in extra
 	meta := make(map[string]string)
 
 	cnt := resp.Count
 	rev := resp.Header.Revision
+	if opt.revision > 0 {
+		rev = opt.revision
+	}
+	options := []clientv3.OpOption{clientv3.WithFromKey(), clientv3.WithLimit(batchSize)}
+	if !ignoreRevision {
+		options = append(options, clientv3.WithRev(rev))
+	}
+	if opt.sinceRevision > 0 {
+		options = append(options, clientv3.WithMinModRev(opt.sinceRevision+1))
+	}
+	prefixBS := []byte(joinPath(base, prefix))
+	// count only covers the prefix, count entries actually written for filtered or incremental backup
+	if opt.filter != nil || opt.sinceRevision > 0 {
+		cnt = 0
+		err := kv.rangeBackupEntries(context.Background(), prefixBS, options, opt.filter, func(_, _ []byte) error {
+			cnt++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
 	meta["cnt"] = fmt.Sprintf("%d", cnt)
 	meta["rev"] = fmt.Sprintf("%d", rev)
 	var instance, metaPath string
@@ -243,79 +291,168 @@ func (kv *etcdKV) BackupKV(base, prefix string, w *bufio.Writer, ignoreRevision
 	}
 	meta["instance"] = instance
 	meta["metaPath"] = metaPath
+	if opt.filter != nil {
+		meta["filtered"] = "true"
+	}
+	if opt.sinceRevision > 0 {
+		meta["baseRev"] = fmt.Sprintf("%d", opt.sinceRevision)
+	}
 
 	fmt.Println("meta path ", metaPath)
 
+	if err := writeEtcdPartHeader(w, meta); err != nil {
+		return err
+	}
+
+	progressDisplay := uilive.New()
+	progressFmt := "Backing up etcd ... %d%%(%d/%d)\n"
+	progressDisplay.Start()
+	fmt.Fprintf(progressDisplay, progressFmt, 0, 0, cnt)
+
+	// keys written in incremental backup, keys deleted and put again are not recorded as deleted.
+	written := make(map[string]struct{})
+	var i int
+	err = kv.rangeBackupEntries(context.Background(), prefixBS, options, opt.filter, func(key, value []byte) error {
+		entry := &commonpb.KeyDataPair{Key: string(key), Data: value}
+		bs, err := proto.Marshal(entry)
+		if err != nil {
+			fmt.Println("failed to marshal kv pair", err.Error())
+			return err
+		}
+		writeBackupBytes(w, bs)
+		if opt.sinceRevision > 0 {
+			written[string(key)] = struct{}{}
+		}
+		i++
+		if cnt > 0 {
+			fmt.Fprintf(progressDisplay, progressFmt, i*100/int(cnt), i, cnt)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.Flush()
+	progressDisplay.Stop()
+
+	// write stopper
+	writeBackupBytes(w, nil)
+
+	if opt.sinceRevision > 0 {
+		deleted, err := kv.deletedKeysSince(context.Background(), string(prefixBS), opt.sinceRevision, rev, opt.filter)
+		if err != nil {
+			return err
+		}
+		deleted = lo.Filter(deleted, func(key string, _ int) bool {
+			_, ok := written[key]
+			return !ok
+		})
+		sort.Strings(deleted)
+		meta["deletes"] = "true"
+		meta["cnt"] = fmt.Sprintf("%d", len(deleted))
+		if err := writeEtcdPartHeader(w, meta); err != nil {
+			return err
+		}
+		for _, key := range deleted {
+			bs, err := proto.Marshal(&commonpb.KeyDataPair{Key: key})
+			if err != nil {
+				return err
+			}
+			writeBackupBytes(w, bs)
+		}
+		writeBackupBytes(w, nil)
+		fmt.Printf("%d keys deleted since revision %d\n", len(deleted), opt.sinceRevision)
+	}
+
+	w.Flush()
+
+	fmt.Printf("backup etcd for prefix %s done\n", prefix)
+	return nil
+}
+
+func writeEtcdPartHeader(w *bufio.Writer, meta map[string]string) error {
 	bs, _ := json.Marshal(meta)
 	ph := models.PartHeader{
 		PartType: models.PartType_EtcdBackup,
 		PartLen:  -1, // not sure for length
 		Extra:    bs,
 	}
-	bs, err = proto.Marshal(&ph)
+	bs, err := proto.Marshal(&ph)
 	if err != nil {
 		fmt.Println("failed to marshal part header for etcd backup", err.Error())
 		return err
 	}
 	writeBackupBytes(w, bs)
+	return nil
+}
 
-	progressDisplay := uilive.New()
-	progressFmt := "Backing up etcd ... %d%%(%d/%d)\n"
-	progressDisplay.Start()
-	fmt.Fprintf(progressDisplay, progressFmt, 0, 0, cnt)
-
-	options := []clientv3.OpOption{clientv3.WithFromKey(), clientv3.WithLimit(batchSize)}
-	if !ignoreRevision {
-		options = append(options, clientv3.WithRev(rev))
-	}
-
-	currentKey := joinPath(base, prefix)
-	var i int
-	prefixBS := []byte(currentKey)
+// rangeBackupEntries iterates keys with the prefix from the prefix key with the options, entries not matching the filter are skipped.
+func (kv *etcdKV) rangeBackupEntries(ctx context.Context, prefix []byte, options []clientv3.OpOption, filter func(key, value []byte) bool, fn func(key, value []byte) error) error {
+	currentKey := string(prefix)
 	for {
-		resp, err = kv.client.Get(context.Background(), currentKey, options...)
+		resp, err := kv.client.Get(ctx, currentKey, options...)
 		if err != nil {
 			return err
 		}
 
-		if resp.Count == 0 {
-			break
+		// count is not filtered by min mod revision, check kvs as well
+		if resp.Count == 0 || len(resp.Kvs) == 0 {
+			return nil
 		}
 
 		valid := 0
 		for _, kvs := range resp.Kvs {
-			if !bytes.HasPrefix(kvs.Key, prefixBS) {
+			if !bytes.HasPrefix(kvs.Key, prefix) {
 				continue
 			}
 			valid++
-			entry := &commonpb.KeyDataPair{Key: string(kvs.Key), Data: kvs.Value}
-			bs, err = proto.Marshal(entry)
-			if err != nil {
-				fmt.Println("failed to marshal kv pair", err.Error())
+			currentKey = string(append(kvs.Key, 0))
+			if filter != nil && !filter(kvs.Key, kvs.Value) {
+				continue
+			}
+			if err := fn(kvs.Key, kvs.Value); err != nil {
 				return err
 			}
-			writeBackupBytes(w, bs)
-
-			currentKey = string(append(kvs.Key, 0))
 		}
-		i += valid
-
-		progress := i * 100 / int(cnt)
-		fmt.Fprintf(progressDisplay, progressFmt, progress, i, cnt)
+		// all keys left are out of prefix
+		if valid == 0 {
+			return nil
+		}
 	}
-	w.Flush()
-	progressDisplay.Stop()
-
-	// write stopper
-	writeBackupBytes(w, nil)
+}
 
-	w.Flush()
+// deletedKeysSince returns keys of the prefix existing at revision since but not at rev, the value at since is passed to the filter.
+func (kv *etcdKV) deletedKeysSince(ctx context.Context, prefix string, since, rev int64, filter func(key, value []byte) bool) ([]string, error) {
+	existing := make(map[string]struct{})
+	err := kv.rangeBackupEntries(ctx, []byte(prefix), []clientv3.OpOption{clientv3.WithFromKey(), clientv3.WithLimit(1000), clientv3.WithRev(rev), clientv3.WithKeysOnly()}, nil,
+		func(key, _ []byte) error {
+			existing[string(key)] = struct{}{}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
 
-	fmt.Printf("backup etcd for prefix %s done\n", prefix)
-	return nil
+	var deleted []string
+	err = kv.rangeBackupEntries(ctx, []byte(prefix), []clientv3.OpOption{clientv3.WithFromKey(), clientv3.WithLimit(1000), clientv3.WithRev(since)}, filter,
+		func(key, _ []byte) error {
+			if _, ok := existing[string(key)]; !ok {
+				deleted = append(deleted, string(key))
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read base revision %d, take a full backup instead if it is compacted", since)
+	}
+	return deleted, nil
 }
 
 func (kv *etcdKV) WalkWithPrefix(ctx context.Context, prefix string, paginationSize int, fn func([]byte, []byte) error) error {
+	return kv.walkWithPrefix(ctx, prefix, paginationSize, 0, fn)
+}
+
+// walkWithPrefix walks the prefix at revision, zero for the latest revision.
+func (kv *etcdKV) walkWithPrefix(ctx context.Context, prefix string, paginationSize int, revision int64, fn func([]byte, []byte) error) error {
 	prefix = path.Join(kv.rootPath, prefix)
 
 	batch := int64(paginationSize)
@@ -324,6 +461,9 @@ func (kv *etcdKV) WalkWithPrefix(ctx context.Context, prefix string, paginationS
 		clientv3.WithLimit(batch),
 		clientv3.WithRange(clientv3.GetPrefixRangeEnd(prefix)),
 	}
+	if revision > 0 {
+		opts = append(opts, clientv3.WithRev(revision))
+	}
 
 	key := prefix
 	for {
@@ -574,7 +714,15 @@ func (kv *txnTiKV) GetAllRootPath(ctx context.Context) ([]string, error) {
 	return apps, nil
 }
 
-func (kv *txnTiKV) BackupKV(base, prefix string, w *bufio.Writer, ignoreRevision bool, batchSize int64) error {
+func (kv *txnTiKV) BackupKV(base, prefix string, w *bufio.Writer, ignoreRevision bool, batchSize int64, opts ...BackupOption) error {
+	opt := &backupOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+	if opt.sinceRevision > 0 {
+		return errors.New("incremental backup is not supported for tikv meta")
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
 	defer cancel()
 	txn, err := kv.client.Begin()
@@ -645,13 +793,15 @@ func (kv *txnTiKV) BackupKV(base, prefix string, w *bufio.Writer, ignoreRevision
 		if !bytes.HasPrefix(iter.Key(), prefixBS) {
 			continue
 		}
-		entry := &commonpb.KeyDataPair{Key: string(iter.Key()), Data: iter.Value()}
-		bs, err = proto.Marshal(entry)
-		if err != nil {
-			fmt.Println("failed to marshal kv pair", err.Error())
-			return err
+		if opt.filter == nil || opt.filter(iter.Key(), iter.Value()) {
+			entry := &commonpb.KeyDataPair{Key: string(iter.Key()), Data: iter.Value()}
+			bs, err = proto.Marshal(entry)
+			if err != nil {
+				fmt.Println("failed to marshal kv pair", err.Error())
+				return err
+			}
+			writeBackupBytes(w, bs)
 		}
-		writeBackupBytes(w, bs)
 		err = iter.Next()
 		if err != nil {
 			return errors.Wrap(err, fmt.Sprintf("Failed to iterate for BackupKV() for prefix: %s, base: %s", prefix, base))
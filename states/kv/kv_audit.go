@@ -105,8 +105,8 @@ func (c *FileAuditKV) Close() {
 	c.cli.Close()
 }
 
-func (c *FileAuditKV) BackupKV(base, prefix string, w *bufio.Writer, ignoreRevision bool, batchSize int64) error {
-	return c.cli.BackupKV(base, prefix, w, ignoreRevision, batchSize)
+func (c *FileAuditKV) BackupKV(base, prefix string, w *bufio.Writer, ignoreRevision bool, batchSize int64, opts ...BackupOption) error {
+	return c.cli.BackupKV(base, prefix, w, ignoreRevision, batchSize, opts...)
 }
 
 func (c *FileAuditKV) writeHeader(op models.AuditOpType, entriesNum int32) {
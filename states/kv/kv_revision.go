@@ -0,0 +1,300 @@
+package kv
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// ErrReadOnlyRevision is returned for writes while reading meta at a past revision.
+var ErrReadOnlyRevision = errors.New("meta is read-only while pinned to a past revision, use `set revision --latest` first")
+
+// implementation assertion
+var _ MetaKV = (*RevisionKV)(nil)
+
+// RevisionKV reads etcd at a pinned revision so that every command sees meta as it was at that time.
+// Zero revision means latest, writes are rejected while a revision is pinned.
+type RevisionKV struct {
+	cli      MetaKV
+	revision atomic.Int64
+}
+
+// NewRevisionKV wraps the kv, it behaves the same as the wrapped one until a revision is pinned.
+func NewRevisionKV(cli MetaKV) *RevisionKV {
+	return &RevisionKV{cli: cli}
+}
+
+// Revision returns the pinned revision, zero if reading latest.
+func (kv *RevisionKV) Revision() int64 {
+	return kv.revision.Load()
+}
+
+// SetRevision pins the revision, it must not be compacted yet. Zero resets to latest.
+func (kv *RevisionKV) SetRevision(ctx context.Context, revision int64) error {
+	if revision == 0 {
+		kv.revision.Store(0)
+		return nil
+	}
+	etcd, ok := kv.cli.(*etcdKV)
+	if !ok {
+		return errors.New("reading at revision is only supported with etcd meta")
+	}
+	current, err := etcd.currentRevision(ctx)
+	if err != nil {
+		return err
+	}
+	if revision > current {
+		return errors.Newf("revision %d is newer than current revision %d", revision, current)
+	}
+	// probe the revision to fail early if it's compacted.
+	if _, err := etcd.client.Get(ctx, "\x00", clientv3.WithRev(revision), clientv3.WithKeysOnly()); err != nil {
+		return errors.Wrapf(err, "revision %d is not readable", revision)
+	}
+	kv.revision.Store(revision)
+	return nil
+}
+
+// CurrentRevision returns the latest revision of the etcd cluster.
+func (kv *RevisionKV) CurrentRevision(ctx context.Context) (int64, error) {
+	etcd, ok := kv.cli.(*etcdKV)
+	if !ok {
+		return 0, errors.New("revision is only supported with etcd meta")
+	}
+	return etcd.currentRevision(ctx)
+}
+
+// RevisionAt finds the last revision written before t by binary searching the saved tso timestamp key,
+// which milvus rootcoord updates every few seconds.
+func (kv *RevisionKV) RevisionAt(ctx context.Context, tsoKey string, t time.Time) (int64, error) {
+	etcd, ok := kv.cli.(*etcdKV)
+	if !ok {
+		return 0, errors.New("revision is only supported with etcd meta")
+	}
+	hi, err := etcd.currentRevision(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// savedAt returns the tso time saved at revision, compacted revisions are reported as zero time.
+	savedAt := func(rev int64) (time.Time, bool, error) {
+		resp, err := etcd.client.Get(ctx, tsoKey, clientv3.WithRev(rev))
+		if errors.Is(err, rpctypes.ErrCompacted) {
+			return time.Time{}, false, nil
+		}
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if len(resp.Kvs) == 0 || len(resp.Kvs[0].Value) != 8 {
+			return time.Time{}, true, nil
+		}
+		return time.Unix(0, int64(binary.BigEndian.Uint64(resp.Kvs[0].Value))), true, nil
+	}
+
+	var lo, found int64 = 1, 0
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+		ts, readable, err := savedAt(mid)
+		if err != nil {
+			return 0, err
+		}
+		if !readable || !ts.After(t) {
+			if readable {
+				found = mid
+			}
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+	if found == 0 {
+		return 0, errors.Newf("no retained revision found before %s, it may be compacted", t.Format(time.RFC3339))
+	}
+	return found, nil
+}
+
+// KeyRevision is one retained version of a key.
+type KeyRevision struct {
+	Revision int64
+	Deleted  bool
+	Value    []byte
+}
+
+// History lists all retained versions of the key, oldest first, and the compact revision if history is truncated.
+// It replays the key with a watch from the oldest retained revision, which also reports deletions.
+func (kv *RevisionKV) History(ctx context.Context, key string) ([]KeyRevision, int64, error) {
+	etcd, ok := kv.cli.(*etcdKV)
+	if !ok {
+		return nil, 0, errors.New("key history is only supported with etcd meta")
+	}
+	key = joinPath(etcd.rootPath, key)
+	resp, err := etcd.client.Get(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	current := resp.Header.Revision
+	// the watch is done once the latest version is received, or a progress notify passes current revision.
+	var lastModRevision int64
+	if len(resp.Kvs) > 0 {
+		lastModRevision = resp.Kvs[0].ModRevision
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var compactRevision int64
+	startRev := int64(1)
+	for {
+		history, compacted, err := etcd.replayKey(ctx, key, startRev, lastModRevision, current)
+		if err != nil {
+			return nil, 0, err
+		}
+		if compacted > 0 {
+			compactRevision, startRev = compacted, compacted
+			continue
+		}
+		return history, compactRevision, nil
+	}
+}
+
+// replayKey watches the key from startRev, it returns the compact revision if startRev is already compacted.
+func (kv *etcdKV) replayKey(ctx context.Context, key string, startRev, lastModRevision, current int64) ([]KeyRevision, int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wch := kv.client.Watch(clientv3.WithRequireLeader(ctx), key, clientv3.WithRev(startRev))
+
+	// progress notify is only sent once the watcher catches up, keep requesting until then.
+	go func() {
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				kv.client.RequestProgress(ctx)
+			}
+		}
+	}()
+
+	var history []KeyRevision
+	for resp := range wch {
+		if resp.CompactRevision > 0 {
+			return nil, resp.CompactRevision, nil
+		}
+		if err := resp.Err(); err != nil {
+			return nil, 0, err
+		}
+		for _, event := range resp.Events {
+			history = append(history, KeyRevision{
+				Revision: event.Kv.ModRevision,
+				Deleted:  event.Type == mvccpb.DELETE,
+				Value:    event.Kv.Value,
+			})
+			if lastModRevision > 0 && event.Kv.ModRevision >= lastModRevision {
+				return history, 0, nil
+			}
+		}
+		if resp.IsProgressNotify() && resp.Header.Revision >= current {
+			return history, 0, nil
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, errors.Wrap(err, "watch did not catch up with current revision")
+	}
+	return history, 0, nil
+}
+
+func (kv *RevisionKV) loadOptions(opts []LoadOption) []LoadOption {
+	if rev := kv.revision.Load(); rev > 0 {
+		return append(opts, WithRevision(rev))
+	}
+	return opts
+}
+
+func (kv *RevisionKV) Load(ctx context.Context, key string, opts ...LoadOption) (string, error) {
+	return kv.cli.Load(ctx, key, kv.loadOptions(opts)...)
+}
+
+func (kv *RevisionKV) LoadWithPrefix(ctx context.Context, key string, opts ...LoadOption) ([]string, []string, error) {
+	return kv.cli.LoadWithPrefix(ctx, key, kv.loadOptions(opts)...)
+}
+
+func (kv *RevisionKV) Save(ctx context.Context, key, value string) error {
+	if kv.revision.Load() > 0 {
+		return ErrReadOnlyRevision
+	}
+	return kv.cli.Save(ctx, key, value)
+}
+
+func (kv *RevisionKV) MultiSave(ctx context.Context, keys, values []string) error {
+	if kv.revision.Load() > 0 {
+		return ErrReadOnlyRevision
+	}
+	return kv.cli.MultiSave(ctx, keys, values)
+}
+
+func (kv *RevisionKV) Remove(ctx context.Context, key string) error {
+	if kv.revision.Load() > 0 {
+		return ErrReadOnlyRevision
+	}
+	return kv.cli.Remove(ctx, key)
+}
+
+func (kv *RevisionKV) RemoveWithPrefix(ctx context.Context, key string) error {
+	if kv.revision.Load() > 0 {
+		return ErrReadOnlyRevision
+	}
+	return kv.cli.RemoveWithPrefix(ctx, key)
+}
+
+func (kv *RevisionKV) removeWithPrevKV(ctx context.Context, key string) (*mvccpb.KeyValue, error) {
+	if kv.revision.Load() > 0 {
+		return nil, ErrReadOnlyRevision
+	}
+	return kv.cli.removeWithPrevKV(ctx, key)
+}
+
+func (kv *RevisionKV) removeWithPrefixAndPrevKV(ctx context.Context, prefix string) ([]*mvccpb.KeyValue, error) {
+	if kv.revision.Load() > 0 {
+		return nil, ErrReadOnlyRevision
+	}
+	return kv.cli.removeWithPrefixAndPrevKV(ctx, prefix)
+}
+
+func (kv *RevisionKV) GetAllRootPath(ctx context.Context) ([]string, error) {
+	return kv.cli.GetAllRootPath(ctx)
+}
+
+// BackupKV backups the meta at the pinned revision if any.
+func (kv *RevisionKV) BackupKV(base, prefix string, w *bufio.Writer, ignoreRevision bool, batchSize int64, opts ...BackupOption) error {
+	if rev := kv.revision.Load(); rev > 0 {
+		opts = append(opts, WithBackupRevision(rev))
+	}
+	return kv.cli.BackupKV(base, prefix, w, ignoreRevision, batchSize, opts...)
+}
+
+func (kv *RevisionKV) WalkWithPrefix(ctx context.Context, prefix string, paginationSize int, fn func([]byte, []byte) error) error {
+	rev := kv.revision.Load()
+	if etcd, ok := kv.cli.(*etcdKV); ok && rev > 0 {
+		return etcd.walkWithPrefix(ctx, prefix, paginationSize, rev, fn)
+	}
+	return kv.cli.WalkWithPrefix(ctx, prefix, paginationSize, fn)
+}
+
+func (kv *RevisionKV) Close() {
+	kv.cli.Close()
+}
+
+func (kv *etcdKV) currentRevision(ctx context.Context) (int64, error) {
+	resp, err := kv.client.Get(ctx, "\x00", clientv3.WithKeysOnly(), clientv3.WithLimit(1))
+	if err != nil {
+		return 0, err
+	}
+	return resp.Header.Revision, nil
+}
@@ -2,16 +2,24 @@ package kv
 
 import (
 	"bufio"
+	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.etcd.io/etcd/api/v3/mvccpb"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/milvus-io/birdwatcher/models"
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 )
 
 func TestTiKVLoad(te *testing.T) {
@@ -332,3 +340,107 @@ func TestBackupKV(t *testing.T) {
 		assert.NoError(t, err)
 	}
 }
+
+func TestIncrementalBackupKV(t *testing.T) {
+	ctx := context.TODO()
+	kv := NewEtcdKV(etcdClient)
+	defer kv.RemoveWithPrefix(ctx, "inc")
+
+	for _, key := range []string{"inc/meta/a", "inc/meta/b", "inc/meta/c", "inc/meta/skip"} {
+		require.NoError(t, kv.Save(ctx, key, "v1"))
+	}
+	resp, err := etcdClient.Get(ctx, "inc")
+	require.NoError(t, err)
+	base := resp.Header.Revision
+
+	require.NoError(t, kv.Remove(ctx, "inc/meta/b"))
+	require.NoError(t, kv.Remove(ctx, "inc/meta/c"))
+	require.NoError(t, kv.Save(ctx, "inc/meta/c", "v2"))
+	require.NoError(t, kv.Save(ctx, "inc/meta/d", "v2"))
+	require.NoError(t, kv.Remove(ctx, "inc/meta/skip"))
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	filter := func(key, _ []byte) bool { return !strings.HasSuffix(string(key), "skip") }
+	require.NoError(t, kv.BackupKV("inc/meta", "", w, false, 100, WithBackupSince(base), WithBackupFilter(filter)))
+
+	frames := readTestBackupFrames(t, buf.Bytes())
+	// part header, 2 entries, stopper, deletes part header, 1 deleted key, stopper
+	require.Len(t, frames, 7)
+
+	readPart := func(frame []byte) map[string]string {
+		ph := &models.PartHeader{}
+		require.NoError(t, proto.Unmarshal(frame, ph))
+		meta := make(map[string]string)
+		require.NoError(t, json.Unmarshal(ph.Extra, &meta))
+		return meta
+	}
+	readKey := func(frame []byte) string {
+		entry := &commonpb.KeyDataPair{}
+		require.NoError(t, proto.Unmarshal(frame, entry))
+		return entry.GetKey()
+	}
+
+	meta := readPart(frames[0])
+	assert.Equal(t, "2", meta["cnt"])
+	assert.Equal(t, fmt.Sprintf("%d", base), meta["baseRev"])
+	assert.Equal(t, "inc/meta/c", readKey(frames[1]))
+	assert.Equal(t, "inc/meta/d", readKey(frames[2]))
+	assert.Empty(t, frames[3])
+
+	meta = readPart(frames[4])
+	assert.Equal(t, "true", meta["deletes"])
+	assert.Equal(t, "1", meta["cnt"])
+	assert.Equal(t, "inc/meta/b", readKey(frames[5]))
+	assert.Empty(t, frames[6])
+}
+
+func TestRevisionBackupKV(t *testing.T) {
+	ctx := context.TODO()
+	kv := NewEtcdKV(etcdClient)
+	defer kv.RemoveWithPrefix(ctx, "pinned")
+
+	require.NoError(t, kv.Save(ctx, "pinned/meta/a", "v1"))
+	require.NoError(t, kv.Save(ctx, "pinned/meta/b", "v1"))
+	resp, err := etcdClient.Get(ctx, "pinned")
+	require.NoError(t, err)
+	rev := resp.Header.Revision
+	require.NoError(t, kv.Save(ctx, "pinned/meta/a", "v2"))
+	require.NoError(t, kv.Save(ctx, "pinned/meta/c", "v2"))
+
+	revKV := NewRevisionKV(kv)
+	require.NoError(t, revKV.SetRevision(ctx, rev))
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	require.NoError(t, revKV.BackupKV("pinned/meta", "", w, false, 100))
+	assert.Error(t, revKV.BackupKV("pinned/meta", "", bufio.NewWriter(&bytes.Buffer{}), true, 100))
+
+	frames := readTestBackupFrames(t, buf.Bytes())
+	// part header, 2 entries, stopper
+	require.Len(t, frames, 4)
+	ph := &models.PartHeader{}
+	require.NoError(t, proto.Unmarshal(frames[0], ph))
+	meta := make(map[string]string)
+	require.NoError(t, json.Unmarshal(ph.Extra, &meta))
+	assert.Equal(t, "2", meta["cnt"])
+	assert.Equal(t, fmt.Sprintf("%d", rev), meta["rev"])
+	for i, key := range []string{"pinned/meta/a", "pinned/meta/b"} {
+		entry := &commonpb.KeyDataPair{}
+		require.NoError(t, proto.Unmarshal(frames[i+1], entry))
+		assert.Equal(t, key, entry.GetKey())
+		assert.Equal(t, "v1", string(entry.GetData()))
+	}
+}
+
+func readTestBackupFrames(t *testing.T, data []byte) [][]byte {
+	var frames [][]byte
+	for len(data) > 0 {
+		require.GreaterOrEqual(t, len(data), 8)
+		n := int(binary.LittleEndian.Uint64(data[:8]))
+		data = data[8:]
+		require.GreaterOrEqual(t, len(data), n)
+		frames = append(frames, data[:n])
+		data = data[n:]
+	}
+	return frames
+}
@@ -6,6 +6,7 @@ import (
 
 type loadOption struct {
 	withKeysOnly bool
+	revision     int64
 }
 
 func (opt *loadOption) EtcdOptions() []clientv3.OpOption {
@@ -13,6 +14,9 @@ func (opt *loadOption) EtcdOptions() []clientv3.OpOption {
 	if opt.withKeysOnly {
 		result = append(result, clientv3.WithKeysOnly())
 	}
+	if opt.revision > 0 {
+		result = append(result, clientv3.WithRev(opt.revision))
+	}
 	return result
 }
 
@@ -27,3 +31,39 @@ func WithKeysOnly() LoadOption {
 		opt.withKeysOnly = true
 	}
 }
+
+// WithRevision reads the value at the etcd revision instead of the latest one.
+func WithRevision(revision int64) LoadOption {
+	return func(opt *loadOption) {
+		opt.revision = revision
+	}
+}
+
+type backupOption struct {
+	filter        func(key, value []byte) bool
+	sinceRevision int64
+	revision      int64
+}
+
+type BackupOption func(opt *backupOption)
+
+// WithBackupFilter only backups the entries accepted by the filter, the key is the full key.
+func WithBackupFilter(filter func(key, value []byte) bool) BackupOption {
+	return func(opt *backupOption) {
+		opt.filter = filter
+	}
+}
+
+// WithBackupSince only backups keys modified after the revision and records keys deleted since then.
+func WithBackupSince(revision int64) BackupOption {
+	return func(opt *backupOption) {
+		opt.sinceRevision = revision
+	}
+}
+
+// WithBackupRevision backups the entries at the revision instead of the latest one.
+func WithBackupRevision(revision int64) BackupOption {
+	return func(opt *backupOption) {
+		opt.revision = revision
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -19,26 +20,64 @@ import (
 )
 
 type LoadBackupParam struct {
-	framework.ParamBase `use:"load-backup [file]" desc:"load etcd backup file"`
-	backupFile          string
+	framework.ParamBase `use:"load-backup [file] [increments...]" desc:"load etcd backup file, incremental backups are applied in order after the base one"`
+	backupFiles         []string
 	UseWorkspace        bool   `name:"use-workspace" default:"false"`
 	WorkspaceName       string `name:"workspace-name" default:""`
+	// connection flags for backups in object storage, e.g. s3://bucket/prefix/bw_etcd_ALL.bak.gz
+	BackupStorageParam
 }
 
 func (p *LoadBackupParam) ParseArgs(args []string) error {
 	if len(args) == 0 {
 		return errors.New("no backup file provided")
 	}
-	if len(args) > 1 {
-		return errors.New("only one backup file is allowed")
-	}
 
-	p.backupFile = args[0]
+	p.backupFiles = args
 	return nil
 }
 
 func (app *ApplicationState) LoadBackupCommand(ctx context.Context, p *LoadBackupParam) error {
-	f, err := openBackupFile(p.backupFile)
+	if err := checkBackupChain(ctx, &p.BackupStorageParam, p.backupFiles); err != nil {
+		return err
+	}
+
+	if p.UseWorkspace {
+		if p.WorkspaceName == "" {
+			fileName := path.Base(p.backupFiles[0])
+			p.WorkspaceName = fileName
+		}
+		p.WorkspaceName = createWorkspaceFolder(app.config, p.WorkspaceName)
+	}
+
+	server, err := startEmbedEtcdServer(p.WorkspaceName, p.UseWorkspace)
+	if err != nil {
+		fmt.Println("failed to start embed etcd server:", err.Error())
+		return err
+	}
+	fmt.Println("using data dir:", server.Config().Dir)
+
+	nextState := getEmbedEtcdInstanceV2(app.core, server, app.config)
+	start := time.Now()
+	for _, file := range p.backupFiles {
+		if err := restoreBackupFile(ctx, &p.BackupStorageParam, file, nextState); err != nil {
+			nextState.Close()
+			return err
+		}
+	}
+	fmt.Println("load backup cost", time.Since(start))
+	err = nextState.setupWorkDir(server.Config().Dir)
+	if err != nil {
+		fmt.Println("failed to setup workspace for backup file", err.Error())
+		return err
+	}
+
+	app.SetTagNext(etcdTag, nextState)
+	return nil
+}
+
+func restoreBackupFile(ctx context.Context, sp *BackupStorageParam, file string, state *embedEtcdMockState) error {
+	f, err := sp.openBackup(ctx, file)
 	if err != nil {
 		return err
 	}
@@ -59,54 +98,86 @@ func (app *ApplicationState) LoadBackupCommand(ctx context.Context, p *LoadBacku
 		return err
 	}
 
-	if p.UseWorkspace {
-		if p.WorkspaceName == "" {
-			fileName := path.Base(p.backupFile)
-			p.WorkspaceName = fileName
-		}
-		p.WorkspaceName = createWorkspaceFolder(app.config, p.WorkspaceName)
-	}
-
-	server, err := startEmbedEtcdServer(p.WorkspaceName, p.UseWorkspace)
-	if err != nil {
-		fmt.Println("failed to start embed etcd server:", err.Error())
-		return err
-	}
-	fmt.Println("using data dir:", server.Config().Dir)
-
-	nextState := getEmbedEtcdInstanceV2(app.core, server, app.config)
-	start := time.Now()
 	switch header.Version {
 	case 1:
 		fmt.Printf("Found backup version: %d, instance name :%s\n", header.Version, header.Instance)
-		err = restoreFromV1File(nextState.client, rd, &header)
+		err = restoreFromV1File(state.client, rd, &header)
 		if err != nil {
 			fmt.Println("failed to restore v1 backup file", err.Error())
-			nextState.Close()
 			return err
 		}
-		nextState.SetInstance(header.Instance)
+		state.SetInstance(header.Instance)
 	case 2:
-		err = restoreV2File(rd, nextState)
+		err = restoreV2File(rd, state)
 		if err != nil {
 			fmt.Println("failed to restore v2 backup file", err.Error())
-			nextState.Close()
 			return err
 		}
 	default:
 		fmt.Printf("backup version %d not supported\n", header.Version)
-		nextState.Close()
-		return err
+		return errors.Newf("backup version %d not supported", header.Version)
 	}
-	fmt.Println("load backup cost", time.Since(start))
-	err = nextState.setupWorkDir(server.Config().Dir)
+	return nil
+}
+
+// checkBackupChain checks each incremental backup is based on the revision of the previous file.
+func checkBackupChain(ctx context.Context, sp *BackupStorageParam, files []string) error {
+	if len(files) == 1 {
+		return nil
+	}
+	var prevRev string
+	for i, file := range files {
+		meta, err := readBackupEtcdMeta(ctx, sp, file)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read backup %s", file)
+		}
+		if i > 0 {
+			if meta["baseRev"] == "" {
+				return errors.Newf("%s is not an incremental backup", file)
+			}
+			if meta["baseRev"] != prevRev {
+				return errors.Newf("%s is based on revision %s, but previous backup is at revision %s", file, meta["baseRev"], prevRev)
+			}
+		}
+		prevRev = meta["rev"]
+	}
+	return nil
+}
+
+// readBackupEtcdMeta reads the etcd part meta of a v2 backup file, including `rev` and `baseRev` for incremental backups.
+func readBackupEtcdMeta(ctx context.Context, sp *BackupStorageParam, file string) (map[string]string, error) {
+	f, err := sp.openBackup(ctx, file)
 	if err != nil {
-		fmt.Println("failed to setup workspace for backup file", err.Error())
-		return err
+		return nil, err
 	}
+	defer f.Close()
 
-	app.SetTagNext(etcdTag, nextState)
-	return nil
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	rd := bufio.NewReader(r)
+	var header models.BackupHeader
+	if err := readFixLengthHeader(rd, &header); err != nil {
+		return nil, err
+	}
+	if header.Version != 2 {
+		return nil, errors.Newf("backup version %d does not record revision", header.Version)
+	}
+	var ph models.PartHeader
+	if err := readFixLengthHeader(rd, &ph); err != nil {
+		return nil, err
+	}
+	if ph.PartType != models.PartType_EtcdBackup {
+		return nil, errors.Newf("first part is %s instead of etcd backup", ph.PartType.String())
+	}
+	meta := make(map[string]string)
+	if err := json.Unmarshal(ph.Extra, &meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
 }
 
 func openBackupFile(arg string) (*os.File, error) {
@@ -0,0 +1,104 @@
+package states
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/milvus-io/birdwatcher/configs"
+	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/models"
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+)
+
+// writeTestEtcdBackup writes a v2 backup file with one etcd part, and a deletes part if deletes provided.
+func writeTestEtcdBackup(t *testing.T, file string, meta map[string]string, entries map[string]string, deletes []string) {
+	f, err := os.Create(file)
+	require.NoError(t, err)
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	defer gw.Close()
+	w := bufio.NewWriter(gw)
+	defer w.Flush()
+
+	require.NoError(t, writeBackupHeader(w, 2))
+	writePart := func(meta map[string]string, kvs []*commonpb.KeyDataPair) {
+		extra, err := json.Marshal(meta)
+		require.NoError(t, err)
+		bs, err := proto.Marshal(&models.PartHeader{PartType: models.PartType_EtcdBackup, PartLen: -1, Extra: extra})
+		require.NoError(t, err)
+		writeBackupBytes(w, bs)
+		for _, kv := range kvs {
+			bs, err := proto.Marshal(kv)
+			require.NoError(t, err)
+			writeBackupBytes(w, bs)
+		}
+		writeBackupBytes(w, nil)
+	}
+
+	var kvs []*commonpb.KeyDataPair
+	for key, value := range entries {
+		kvs = append(kvs, &commonpb.KeyDataPair{Key: key, Data: []byte(value)})
+	}
+	writePart(meta, kvs)
+	if deletes == nil {
+		return
+	}
+	deleteMeta := map[string]string{"deletes": "true"}
+	for k, v := range meta {
+		if _, ok := deleteMeta[k]; !ok {
+			deleteMeta[k] = v
+		}
+	}
+	kvs = kvs[:0]
+	for _, key := range deletes {
+		kvs = append(kvs, &commonpb.KeyDataPair{Key: key})
+	}
+	writePart(deleteMeta, kvs)
+}
+
+func TestLoadBackupIncrements(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	baseFile := path.Join(dir, "base.bak.gz")
+	incFile := path.Join(dir, "inc.bak.gz")
+	badFile := path.Join(dir, "bad.bak.gz")
+
+	writeTestEtcdBackup(t, baseFile, map[string]string{"cnt": "3", "rev": "10", "instance": "by-dev", "metaPath": "meta"},
+		map[string]string{"by-dev/meta/a": "1", "by-dev/meta/b": "1", "by-dev/meta/c": "1"}, nil)
+	writeTestEtcdBackup(t, incFile, map[string]string{"cnt": "2", "rev": "20", "baseRev": "10", "instance": "by-dev", "metaPath": "meta"},
+		map[string]string{"by-dev/meta/b": "2", "by-dev/meta/d": "2"}, []string{"by-dev/meta/c"})
+	writeTestEtcdBackup(t, badFile, map[string]string{"cnt": "0", "rev": "30", "baseRev": "15", "instance": "by-dev", "metaPath": "meta"},
+		nil, []string{})
+
+	sp := &BackupStorageParam{}
+	require.NoError(t, checkBackupChain(ctx, sp, []string{baseFile, incFile}))
+	assert.Error(t, checkBackupChain(ctx, sp, []string{baseFile, badFile}))
+	assert.Error(t, checkBackupChain(ctx, sp, []string{incFile, baseFile}))
+
+	server, err := startEmbedEtcdServer("", false)
+	require.NoError(t, err)
+	config := &configs.Config{}
+	state := getEmbedEtcdInstanceV2(framework.NewCmdState("test", config), server, config)
+	defer state.Close()
+
+	for _, file := range []string{baseFile, incFile} {
+		require.NoError(t, restoreBackupFile(ctx, sp, file, state))
+	}
+
+	for key, expected := range map[string]string{"by-dev/meta/a": "1", "by-dev/meta/b": "2", "by-dev/meta/d": "2"} {
+		value, err := state.client.Load(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, expected, value, key)
+	}
+	_, err = state.client.Load(ctx, "by-dev/meta/c")
+	assert.Error(t, err)
+}
@@ -238,7 +238,7 @@ func getConfigurationSource(session *models.Session, conn *grpc.ClientConn) mgrp
 		client = querypb.NewQueryCoordClient(conn)
 	case "querynode":
 		client = querypb.NewQueryNodeClient(conn)
-	case "rootcoord":
+	case "rootcoord", "mixcoord":
 		client = rootcoordpb.NewRootCoordClient(conn)
 		//	case "proxy":
 		// client:= milvuspb.NewMilvusServiceClient(conn)
@@ -1,23 +1,45 @@
 package states
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"math"
 	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/cockroachdb/errors"
+	"github.com/jedib0t/go-pretty/v6/table"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/milvus-io/birdwatcher/framework"
 	"github.com/milvus-io/birdwatcher/models"
 	"github.com/milvus-io/birdwatcher/states/etcd/common"
 	"github.com/milvus-io/birdwatcher/states/kv"
+	"github.com/milvus-io/birdwatcher/states/mgrpc"
+)
+
+const (
+	// metricLabelRole and metricLabelNode are attached to every sample to tell where it comes from.
+	metricLabelRole = "role"
+	metricLabelNode = "node"
 )
 
 func getFetchMetricsCmd(cli kv.MetaKV, basePath string) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "fetch-metrics",
-		Short: "fetch metrics from milvus instances",
+		Short: "fetch raw metrics from milvus instances, use `metrics` to query parsed metrics",
 		Run: func(cmd *cobra.Command, args []string) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
@@ -28,9 +50,8 @@ func getFetchMetricsCmd(cli kv.MetaKV, basePath string) *cobra.Command {
 			}
 
 			for _, session := range sessions {
-				metrics, defaultMetrics, _ := fetchInstanceMetrics(session)
+				metrics, defaultMetrics, _ := fetchInstanceMetrics(ctx, session, getMetricsPort(ctx, session))
 				fmt.Println(session)
-				// TODO parse metrics
 				fmt.Println(string(metrics))
 				fmt.Println(string(defaultMetrics))
 			}
@@ -40,36 +61,526 @@ func getFetchMetricsCmd(cli kv.MetaKV, basePath string) *cobra.Command {
 	return cmd
 }
 
-func fetchInstanceMetrics(session *models.Session) ([]byte, []byte, error) {
+// getMetricsPort returns the metrics port from component configuration, fallback to default port.
+func getMetricsPort(ctx context.Context, session *models.Session) int64 {
+	dialCtx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+	conn, err := grpc.DialContext(dialCtx, session.Address, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	if err != nil {
+		return httpAPIListenPort
+	}
+	defer conn.Close()
+
+	source := getConfigurationSource(session, conn)
+	if source == nil {
+		return httpAPIListenPort
+	}
+	items, err := mgrpc.GetConfiguration(dialCtx, source, session.ServerID)
+	if err != nil {
+		return httpAPIListenPort
+	}
+	for _, item := range items {
+		if item.GetKey() == "commonmetricsport" {
+			port, err := strconv.ParseInt(item.GetValue(), 10, 64)
+			if err == nil && port > 0 {
+				return port
+			}
+		}
+	}
+	return httpAPIListenPort
+}
+
+func fetchInstanceMetrics(ctx context.Context, session *models.Session, port int64) ([]byte, []byte, error) {
+	metricsBs, err := fetchMetricsPath(ctx, session, port, "metrics")
+	if err != nil {
+		return nil, nil, err
+	}
+	defaultMetricsBs, err := fetchMetricsPath(ctx, session, port, "metrics_default")
+	if err != nil {
+		return nil, nil, err
+	}
+	return metricsBs, defaultMetricsBs, nil
+}
+
+func fetchMetricsPath(ctx context.Context, session *models.Session, port int64, metricsPath string) ([]byte, error) {
 	addr := session.Address
 	if strings.Contains(session.Address, ":") {
 		addr = strings.Split(addr, ":")[0]
 	}
 
-	url := fmt.Sprintf("http://%s:%d/metrics", addr, 9091)
-	fmt.Println(url)
-
+	url := fmt.Sprintf("http://%s:%d/%s", addr, port, metricsPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
 	// #nosec
-	resp, err := http.Get(url)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Newf("%s returns status %s", url, resp.Status)
 	}
+	return io.ReadAll(resp.Body)
+}
+
+type MetricsParam struct {
+	framework.DataSetParam `use:"metrics" desc:"query parsed prometheus metrics of milvus components"`
+	Name                   string   `name:"name" default:"" desc:"regular expression metric name shall match"`
+	Labels                 []string `name:"label" default:"" desc:"label matchers, like role=querynode, status!=fail, collection_id=~44.*"`
+	Component              string   `name:"component" default:"" desc:"component name to scrape, like querynode"`
+	Aggregate              string   `name:"agg" default:"sum" desc:"aggregation across series, sum, max, min, avg or none"`
+	By                     []string `name:"by" default:"" desc:"labels to group by when aggregating, role and node are available"`
+	Default                bool     `name:"default" default:"false" desc:"also scrape /metrics_default for go runtime & process metrics"`
+	Watch                  bool     `name:"watch" default:"false" desc:"scrape periodically and show rates for counters"`
+	Interval               int64    `name:"interval" default:"5" desc:"seconds between two scrapes in watch mode"`
+	Rounds                 int64    `name:"rounds" default:"0" desc:"number of rates to show in watch mode, 0 for until interrupted"`
+}
 
-	metricsBs, err := ioutil.ReadAll(resp.Body)
+// MetricsCommand scrapes metrics from all sessions and shows aggregated samples.
+func (s *InstanceState) MetricsCommand(ctx context.Context, p *MetricsParam) (*framework.PresetResultSet, error) {
+	query, err := newMetricQuery(p)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	url = fmt.Sprintf("http://%s:%d/metrics_default", addr, 9091)
-	// #nosec
-	resp, err = http.Get(url)
+	sessions, err := common.ListSessions(ctx, s.client, s.basePath)
 	if err != nil {
-		return nil, nil, err
+		return nil, errors.Wrap(err, "failed to list sessions")
 	}
+	sessions = lo.UniqBy(sessions, func(session *models.Session) string {
+		return session.Address
+	})
+	sessions = lo.Filter(sessions, func(session *models.Session, _ int) bool {
+		return p.Component == "" || strings.EqualFold(session.ServerName, p.Component)
+	})
+	if len(sessions) == 0 {
+		return nil, errors.New("no session matches")
+	}
+
+	scraper := newMetricsScraper(sessions, p.Default)
+	scraper.detectPorts(ctx)
+
+	if !p.Watch {
+		samples, _ := scraper.scrape(ctx)
+		rows := query.aggregate(lo.Filter(samples, func(sample metricSample, _ int) bool {
+			return query.match(sample)
+		}), nil)
+		return framework.NewPresetResultSet(&MetricsResult{Rows: rows}, framework.NameFormat(p.Format)), nil
+	}
+
+	if p.Interval <= 0 {
+		return nil, errors.New("interval shall be positive")
+	}
+	format := framework.NameFormat(p.Format)
+	if format == framework.FormatUnset {
+		format = s.GetGlobalFormat()
+	}
+	prev, prevTs := scraper.scrape(ctx)
+	for round := int64(0); p.Rounds == 0 || round < p.Rounds; round++ {
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-time.After(time.Duration(p.Interval) * time.Second):
+		}
+		curr, ts := scraper.scrape(ctx)
+		samples := lo.Filter(curr, func(sample metricSample, _ int) bool {
+			return query.match(sample)
+		})
+		rates := computeMetricRates(prev, curr, ts.Sub(prevTs))
+		rs := &MetricsResult{Rows: query.aggregate(samples, rates), Time: ts}
+		fmt.Println(printMetricsResult(rs, format))
+		prev, prevTs = curr, ts
+	}
+	return nil, nil
+}
+
+func printMetricsResult(rs *MetricsResult, format framework.Format) string {
+	if format == framework.FormatTable {
+		return framework.RenderTable(rs.TableHeaders(), rs.TableRows(), rs.TableTitle())
+	}
+	return rs.PrintAs(format)
+}
+
+// metricSample is one sample of the exposition format, histograms and summaries
+// are flattened into _bucket, _sum and _count samples.
+type metricSample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+	// Monotonic marks counter like samples which rates can be computed.
+	Monotonic bool
+}
+
+// seriesKey identifies the series of the sample across scrapes.
+func (s metricSample) seriesKey() string {
+	return s.Name + "{" + formatMetricLabels(s.Labels) + "}"
+}
 
-	defaultMetricsBs, err := ioutil.ReadAll(resp.Body)
+func formatMetricLabels(labels map[string]string) string {
+	keys := lo.Keys(labels)
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", key, labels[key]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseMetrics parses prometheus text exposition format.
+func parseMetrics(data []byte, extraLabels map[string]string) ([]metricSample, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(data))
 	if err != nil {
-		return nil, nil, err
+		return nil, errors.Wrap(err, "failed to parse metrics")
 	}
-	return metricsBs, defaultMetricsBs, nil
+
+	var samples []metricSample
+	for name, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string, len(metric.GetLabel())+len(extraLabels))
+			for k, v := range extraLabels {
+				labels[k] = v
+			}
+			for _, pair := range metric.GetLabel() {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+			add := func(suffix string, value float64, monotonic bool, extra ...string) {
+				sampleLabels := labels
+				if len(extra) > 0 {
+					sampleLabels = lo.Assign(labels, map[string]string{extra[0]: extra[1]})
+				}
+				samples = append(samples, metricSample{Name: name + suffix, Labels: sampleLabels, Value: value, Monotonic: monotonic})
+			}
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				add("", metric.GetCounter().GetValue(), true)
+			case dto.MetricType_GAUGE:
+				add("", metric.GetGauge().GetValue(), false)
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				for _, bucket := range histogram.GetBucket() {
+					add("_bucket", float64(bucket.GetCumulativeCount()), true, "le", strconv.FormatFloat(bucket.GetUpperBound(), 'g', -1, 64))
+				}
+				add("_sum", histogram.GetSampleSum(), true)
+				add("_count", float64(histogram.GetSampleCount()), true)
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				for _, quantile := range summary.GetQuantile() {
+					add("", quantile.GetValue(), false, "quantile", strconv.FormatFloat(quantile.GetQuantile(), 'g', -1, 64))
+				}
+				add("_sum", summary.GetSampleSum(), true)
+				add("_count", float64(summary.GetSampleCount()), true)
+			default:
+				add("", metric.GetUntyped().GetValue(), false)
+			}
+		}
+	}
+	return samples, nil
+}
+
+// computeMetricRates returns per second rates of monotonic series by series key.
+func computeMetricRates(prev, curr []metricSample, elapsed time.Duration) map[string]float64 {
+	if elapsed <= 0 {
+		return nil
+	}
+	prevValues := make(map[string]float64)
+	for _, sample := range prev {
+		if sample.Monotonic {
+			prevValues[sample.seriesKey()] = sample.Value
+		}
+	}
+	rates := make(map[string]float64)
+	for _, sample := range curr {
+		if !sample.Monotonic {
+			continue
+		}
+		key := sample.seriesKey()
+		prevValue, ok := prevValues[key]
+		if !ok {
+			continue
+		}
+		delta := sample.Value - prevValue
+		// counter reset, component restarted between scrapes
+		if delta < 0 {
+			delta = sample.Value
+		}
+		rates[key] = delta / elapsed.Seconds()
+	}
+	return rates
+}
+
+type labelMatcher struct {
+	name   string
+	negate bool
+	value  string
+	re     *regexp.Regexp
+}
+
+func (m labelMatcher) match(labels map[string]string) bool {
+	value := labels[m.name]
+	var matched bool
+	if m.re != nil {
+		matched = m.re.MatchString(value)
+	} else {
+		matched = value == m.value
+	}
+	return matched != m.negate
+}
+
+// parseLabelMatcher parses matcher in form of `k=v`, `k!=v`, `k=~re` or `k!~re`.
+func parseLabelMatcher(raw string) (labelMatcher, error) {
+	for _, op := range []string{"!=", "=~", "!~", "="} {
+		idx := strings.Index(raw, op)
+		if idx <= 0 {
+			continue
+		}
+		m := labelMatcher{name: raw[:idx], value: raw[idx+len(op):], negate: op[0] == '!'}
+		if strings.HasSuffix(op, "~") {
+			re, err := regexp.Compile("^(?:" + m.value + ")$")
+			if err != nil {
+				return labelMatcher{}, errors.Wrapf(err, "invalid label matcher %s", raw)
+			}
+			m.re = re
+		}
+		return m, nil
+	}
+	return labelMatcher{}, errors.Newf("invalid label matcher %s, expect k=v, k!=v, k=~re or k!~re", raw)
+}
+
+type metricQuery struct {
+	name     *regexp.Regexp
+	matchers []labelMatcher
+	agg      string
+	by       []string
+}
+
+func newMetricQuery(p *MetricsParam) (*metricQuery, error) {
+	q := &metricQuery{agg: strings.ToLower(p.Aggregate), by: lo.Compact(p.By)}
+	if p.Name != "" {
+		re, err := regexp.Compile(p.Name)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid name expression")
+		}
+		q.name = re
+	}
+	for _, raw := range lo.Compact(p.Labels) {
+		m, err := parseLabelMatcher(raw)
+		if err != nil {
+			return nil, err
+		}
+		q.matchers = append(q.matchers, m)
+	}
+	switch q.agg {
+	case "sum", "max", "min", "avg", "none":
+	default:
+		return nil, errors.Newf("unknown aggregation %s", p.Aggregate)
+	}
+	return q, nil
+}
+
+func (q *metricQuery) match(sample metricSample) bool {
+	if q.name != nil && !q.name.MatchString(sample.Name) {
+		return false
+	}
+	for _, m := range q.matchers {
+		if !m.match(sample.Labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// aggregate groups samples by name and `by` labels, value of monotonic sample is replaced by its rate if rates provided.
+func (q *metricQuery) aggregate(samples []metricSample, rates map[string]float64) []*metricRow {
+	groups := make(map[string]*metricRow)
+	for _, sample := range samples {
+		value := sample.Value
+		isRate := false
+		if rates != nil && sample.Monotonic {
+			rate, ok := rates[sample.seriesKey()]
+			if !ok {
+				continue
+			}
+			value, isRate = rate, true
+		}
+
+		labels := sample.Labels
+		if q.agg != "none" {
+			labels = lo.PickByKeys(sample.Labels, q.by)
+			// keep histogram buckets and summary quantiles apart
+			for _, special := range []string{"le", "quantile"} {
+				if v, ok := sample.Labels[special]; ok {
+					labels[special] = v
+				}
+			}
+		}
+		key := sample.Name + "{" + formatMetricLabels(labels) + "}"
+		row, ok := groups[key]
+		if !ok {
+			row = &metricRow{Name: sample.Name, Labels: labels, Rate: isRate, Value: value}
+			groups[key] = row
+			row.Series = 1
+			continue
+		}
+		row.Series++
+		switch q.agg {
+		case "sum", "avg":
+			row.Value += value
+		case "max":
+			row.Value = math.Max(row.Value, value)
+		case "min":
+			row.Value = math.Min(row.Value, value)
+		}
+	}
+
+	rows := lo.Values(groups)
+	for _, row := range rows {
+		if q.agg == "avg" {
+			row.Value /= float64(row.Series)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Name != rows[j].Name {
+			return rows[i].Name < rows[j].Name
+		}
+		return formatMetricLabels(rows[i].Labels) < formatMetricLabels(rows[j].Labels)
+	})
+	return rows
+}
+
+type metricsScraper struct {
+	sessions       []*models.Session
+	ports          map[int64]int64
+	includeDefault bool
+}
+
+func newMetricsScraper(sessions []*models.Session, includeDefault bool) *metricsScraper {
+	return &metricsScraper{sessions: sessions, ports: make(map[int64]int64), includeDefault: includeDefault}
+}
+
+func (s *metricsScraper) detectPorts(ctx context.Context) {
+	var wg sync.WaitGroup
+	var mut sync.Mutex
+	for _, session := range s.sessions {
+		wg.Add(1)
+		go func(session *models.Session) {
+			defer wg.Done()
+			port := getMetricsPort(ctx, session)
+			mut.Lock()
+			s.ports[session.ServerID] = port
+			mut.Unlock()
+		}(session)
+	}
+	wg.Wait()
+}
+
+// scrape fetches and parses metrics of all sessions concurrently, failed sessions are reported and skipped.
+func (s *metricsScraper) scrape(ctx context.Context) ([]metricSample, time.Time) {
+	var wg sync.WaitGroup
+	var mut sync.Mutex
+	var result []metricSample
+	ts := time.Now()
+	for _, session := range s.sessions {
+		wg.Add(1)
+		go func(session *models.Session) {
+			defer wg.Done()
+			paths := []string{"metrics"}
+			if s.includeDefault {
+				paths = append(paths, "metrics_default")
+			}
+			labels := map[string]string{
+				metricLabelRole: session.ServerName,
+				metricLabelNode: fmt.Sprintf("%s-%d", session.ServerName, session.ServerID),
+			}
+			for _, metricsPath := range paths {
+				data, err := fetchMetricsPath(ctx, session, s.ports[session.ServerID], metricsPath)
+				if err == nil {
+					var samples []metricSample
+					samples, err = parseMetrics(data, labels)
+					mut.Lock()
+					result = append(result, samples...)
+					mut.Unlock()
+				}
+				if err != nil {
+					fmt.Printf("failed to scrape %s of %s(%d), %s\n", metricsPath, session.ServerName, session.ServerID, err.Error())
+				}
+			}
+		}(session)
+	}
+	wg.Wait()
+	return result, ts
+}
+
+type metricRow struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+	// Rate is true when value is per second rate.
+	Rate   bool `json:"rate"`
+	Series int  `json:"series"`
+}
+
+func (row *metricRow) formatValue() string {
+	value := strconv.FormatFloat(row.Value, 'f', -1, 64)
+	if row.Rate {
+		value = strconv.FormatFloat(row.Value, 'f', 2, 64) + "/s"
+	}
+	return value
+}
+
+type MetricsResult struct {
+	Rows []*metricRow `json:"rows"`
+	// Time is the scrape time in watch mode.
+	Time time.Time `json:"time,omitempty"`
+}
+
+func (rs *MetricsResult) Entities() any {
+	return rs.Rows
+}
+
+func (rs *MetricsResult) TableTitle() string {
+	if rs.Time.IsZero() {
+		return ""
+	}
+	return rs.Time.Format(time.DateTime)
+}
+
+func (rs *MetricsResult) TableHeaders() table.Row {
+	return table.Row{"Name", "Labels", "Value", "Series"}
+}
+
+func (rs *MetricsResult) TableRows() []table.Row {
+	rows := make([]table.Row, 0, len(rs.Rows))
+	for _, row := range rs.Rows {
+		rows = append(rows, table.Row{row.Name, formatMetricLabels(row.Labels), row.formatValue(), row.Series})
+	}
+	return rows
+}
+
+func (rs *MetricsResult) PrintAs(format framework.Format) string {
+	switch format {
+	case framework.FormatDefault, framework.FormatPlain:
+		sb := &strings.Builder{}
+		if !rs.Time.IsZero() {
+			fmt.Fprintf(sb, "=== %s ===\n", rs.Time.Format(time.DateTime))
+		}
+		var lastName string
+		for _, row := range rs.Rows {
+			if row.Name != lastName {
+				fmt.Fprintln(sb, row.Name)
+				lastName = row.Name
+			}
+			fmt.Fprintf(sb, "\t{%s}\t%s\t(series: %d)\n", formatMetricLabels(row.Labels), row.formatValue(), row.Series)
+		}
+		if len(rs.Rows) == 0 {
+			fmt.Fprintln(sb, "no metric matches")
+		}
+		return sb.String()
+	case framework.FormatJSON:
+		return framework.MarshalJSON(rs)
+	}
+	return ""
 }
@@ -0,0 +1,103 @@
+package states
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMetricsText = `# HELP milvus_proxy_req_count count of requests
+# TYPE milvus_proxy_req_count counter
+milvus_proxy_req_count{function_name="Search",status="success"} 10
+milvus_proxy_req_count{function_name="Insert",status="success"} 4
+milvus_proxy_req_count{function_name="Search",status="fail"} 1
+# HELP milvus_querynode_segment_num number of segments
+# TYPE milvus_querynode_segment_num gauge
+milvus_querynode_segment_num{segment_state="Sealed"} 7
+# HELP milvus_proxy_sq_latency search latency
+# TYPE milvus_proxy_sq_latency histogram
+milvus_proxy_sq_latency_bucket{le="10"} 3
+milvus_proxy_sq_latency_bucket{le="+Inf"} 5
+milvus_proxy_sq_latency_sum 42
+milvus_proxy_sq_latency_count 5
+`
+
+func TestParseAndAggregateMetrics(t *testing.T) {
+	node1, err := parseMetrics([]byte(testMetricsText), map[string]string{metricLabelRole: "proxy", metricLabelNode: "proxy-1"})
+	require.NoError(t, err)
+	node2, err := parseMetrics([]byte(testMetricsText), map[string]string{metricLabelRole: "proxy", metricLabelNode: "proxy-2"})
+	require.NoError(t, err)
+	assert.Len(t, node1, 8)
+
+	q, err := newMetricQuery(&MetricsParam{Name: "req_count", Labels: []string{"status=success"}, Aggregate: "sum", By: []string{"function_name"}})
+	require.NoError(t, err)
+	var samples []metricSample
+	for _, sample := range append(node1, node2...) {
+		if q.match(sample) {
+			samples = append(samples, sample)
+		}
+	}
+	rows := q.aggregate(samples, nil)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "Insert", rows[0].Labels["function_name"])
+	assert.EqualValues(t, 8, rows[0].Value)
+	assert.EqualValues(t, 20, rows[1].Value)
+	assert.Equal(t, 2, rows[1].Series)
+
+	q, err = newMetricQuery(&MetricsParam{Name: "sq_latency_bucket", Aggregate: "max"})
+	require.NoError(t, err)
+	rows = q.aggregate(lo.Filter(node1, func(sample metricSample, _ int) bool {
+		return q.match(sample)
+	}), nil)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "+Inf", rows[0].Labels["le"])
+
+	_, err = newMetricQuery(&MetricsParam{Labels: []string{"status"}, Aggregate: "sum"})
+	assert.Error(t, err)
+	_, err = newMetricQuery(&MetricsParam{Aggregate: "median"})
+	assert.Error(t, err)
+}
+
+func TestComputeMetricRates(t *testing.T) {
+	labels := map[string]string{metricLabelNode: "proxy-1"}
+	prev, err := parseMetrics([]byte(testMetricsText), labels)
+	require.NoError(t, err)
+	curr, err := parseMetrics([]byte(`# TYPE milvus_proxy_req_count counter
+milvus_proxy_req_count{function_name="Search",status="success"} 30
+milvus_proxy_req_count{function_name="Insert",status="success"} 2
+# TYPE milvus_querynode_segment_num gauge
+milvus_querynode_segment_num{segment_state="Sealed"} 9
+`), labels)
+	require.NoError(t, err)
+
+	rates := computeMetricRates(prev, curr, 2*time.Second)
+	q, err := newMetricQuery(&MetricsParam{Aggregate: "sum", By: []string{"function_name"}})
+	require.NoError(t, err)
+	rows := q.aggregate(curr, rates)
+	require.Len(t, rows, 3)
+	// counter reset takes current value as delta
+	assert.EqualValues(t, 1, rows[0].Value)
+	assert.True(t, rows[0].Rate)
+	assert.EqualValues(t, 10, rows[1].Value)
+	// gauge keeps current value
+	assert.EqualValues(t, 9, rows[2].Value)
+	assert.False(t, rows[2].Rate)
+}
+
+func TestParseLabelMatcher(t *testing.T) {
+	m, err := parseLabelMatcher("collection_id=~44.*")
+	require.NoError(t, err)
+	assert.True(t, m.match(map[string]string{"collection_id": "449"}))
+	assert.False(t, m.match(map[string]string{"collection_id": "1449"}))
+
+	m, err = parseLabelMatcher("status!=fail")
+	require.NoError(t, err)
+	assert.True(t, m.match(map[string]string{"status": "success"}))
+	assert.True(t, m.match(map[string]string{}))
+
+	_, err = parseLabelMatcher("=value")
+	assert.Error(t, err)
+}
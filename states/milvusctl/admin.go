@@ -7,7 +7,6 @@ import (
 
 	"github.com/milvus-io/birdwatcher/framework"
 	"github.com/milvus-io/birdwatcher/internal/ops"
-	"github.com/milvus-io/milvus/client/v2/milvusclient"
 )
 
 // -----------------------------------------------------------------------------
@@ -111,11 +110,10 @@ func (s *MilvusctlState) UseDatabaseCommand(ctx context.Context, p *UseDatabaseP
 	if p.DB == "" {
 		return fmt.Errorf("--db is required")
 	}
-	if err := s.client.UseDatabase(ctx, milvusclient.NewUseDatabaseOption(p.DB)); err != nil {
+	if err := s.executeOp(ctx, &ops.UseDatabaseParams{DB: p.DB}); err != nil {
 		return err
 	}
 	s.clientcfg.DBName = p.DB
 	s.SetLabel(fmt.Sprintf("Milvusctl[%s]", s.endpoint()))
-	fmt.Printf("using database %q\n", p.DB)
 	return nil
 }
@@ -74,7 +74,7 @@ func (s *MilvusctlState) CreateCollectionCommand(ctx context.Context, p *CreateC
 			ShardNum:   int32(p.ShardNum),
 		})
 	}
-	sch, err := schemapkg.Build(schemapkg.BuilderOptions{
+	spec, err := schemapkg.BuildSpec(schemapkg.BuilderOptions{
 		Name:         p.Name,
 		PKType:       p.PKType,
 		AutoID:       p.AutoID,
@@ -88,12 +88,11 @@ func (s *MilvusctlState) CreateCollectionCommand(ctx context.Context, p *CreateC
 	if err != nil {
 		return err
 	}
-	opt := milvusclient.NewCreateCollectionOption(sch.CollectionName, sch).WithShardNum(int32(p.ShardNum))
-	if err := s.client.CreateCollection(ctx, opt); err != nil {
-		return err
-	}
-	fmt.Printf("collection %q created\n", sch.CollectionName)
-	return nil
+	return s.executeOp(ctx, &ops.CreateCollectionParams{
+		Name:     spec.Name,
+		Schema:   spec,
+		ShardNum: int32(p.ShardNum),
+	})
 }
 
 // -----------------------------------------------------------------------------
@@ -3,8 +3,10 @@ package milvusctl
 import (
 	"context"
 	"fmt"
+	"math/rand"
 
 	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/internal/scenario"
 	"github.com/milvus-io/milvus/client/v2/milvusclient"
 )
 
@@ -43,6 +45,11 @@ type MilvusctlState struct {
 	*framework.CmdState
 	clientcfg *milvusclient.ClientConfig
 	client    *milvusclient.Client
+
+	// recorder and rng are set between `record start` and `record stop`.
+	recorder   *scenario.Recorder
+	recordFile string
+	rng        *rand.Rand
 }
 
 // Label overrides default cmd label behavior
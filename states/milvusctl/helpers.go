@@ -12,14 +12,21 @@ import (
 
 // runCtx builds the shared RunContext used by every CLI command so ops
 // produced by CLI or YAML behave identically (same stdout, same client).
+// While recording, all commands share the session rng so a replay with the
+// recorded seed generates the same data.
 func (s *MilvusctlState) runCtx() *ops.RunContext {
-	return &ops.RunContext{Client: s.client, Stdout: os.Stdout}
+	return &ops.RunContext{Client: s.client, Stdout: os.Stdout, Rand: s.rng}
 }
 
 // executeOp is the one-line adapter used by every `*Command` method
 // so there's zero divergence between CLI and scenario execution paths.
 func (s *MilvusctlState) executeOp(ctx context.Context, op ops.Op) error {
 	_, err := op.Execute(ctx, s.runCtx())
+	if s.recorder != nil {
+		if rerr := s.recorder.Record(op, err); rerr != nil {
+			fmt.Printf("warning: failed to record op: %v\n", rerr)
+		}
+	}
 	return err
 }
 
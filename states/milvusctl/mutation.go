@@ -2,6 +2,8 @@ package milvusctl
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/milvus-io/birdwatcher/framework"
 	"github.com/milvus-io/birdwatcher/internal/ops"
@@ -64,3 +66,38 @@ func (s *MilvusctlState) DeleteCommand(ctx context.Context, p *DeleteParam) erro
 		Int64IDs: p.Int64IDs, StringIDs: p.StringIDs,
 	})
 }
+
+// -----------------------------------------------------------------------------
+// import file
+
+type ImportFileParam struct {
+	framework.ParamBase `use:"import file" desc:"insert rows streamed from a local parquet/jsonl/csv/npy file"`
+	Collection          string   `name:"collection" default:"" desc:"collection name"`
+	Partition           string   `name:"partition" default:"" desc:"optional partition name"`
+	File                string   `name:"file" default:"" desc:"source file, or a directory of <field>.npy files"`
+	Format              string   `name:"format" default:"" desc:"source format: parquet|jsonl|csv|npy, detected from extension when empty"`
+	Map                 []string `name:"map" desc:"field to source column mapping: field=column (repeatable)"`
+	BatchSize           int64    `name:"batch-size" default:"1000" desc:"rows per insert request"`
+	Concurrency         int64    `name:"concurrency" default:"1" desc:"number of concurrent insert requests"`
+	Limit               int64    `name:"limit" default:"0" desc:"max rows to import, 0 means all"`
+}
+
+func (s *MilvusctlState) ImportFileCommand(ctx context.Context, p *ImportFileParam) error {
+	if p.Collection == "" || p.File == "" {
+		return fmt.Errorf("--collection and --file are required")
+	}
+	mapping, err := parseKVString(strings.Join(p.Map, ","))
+	if err != nil {
+		return err
+	}
+	return s.executeOp(ctx, &ops.ImportFileParams{
+		Collection:  p.Collection,
+		Partition:   p.Partition,
+		Path:        p.File,
+		Format:      p.Format,
+		Mapping:     mapping,
+		BatchSize:   int(p.BatchSize),
+		Concurrency: int(p.Concurrency),
+		Limit:       p.Limit,
+	})
+}
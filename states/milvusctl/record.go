@@ -0,0 +1,75 @@
+package milvusctl
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/internal/scenario"
+)
+
+// -----------------------------------------------------------------------------
+// record start
+
+type RecordStartParam struct {
+	framework.ParamBase `use:"record start" desc:"start recording executed ops into a replayable scenario"`
+	File                string `name:"file" default:"" desc:"scenario YAML output path, defaults to scenario_<time>.yaml"`
+	Name                string `name:"name" default:"" desc:"scenario name"`
+	Seed                int64  `name:"seed" default:"0" desc:"rng seed for generators, 0 picks a random one"`
+}
+
+func (s *MilvusctlState) RecordStartCommand(ctx context.Context, p *RecordStartParam) error {
+	if s.recorder != nil {
+		return fmt.Errorf("already recording into %s, run `record stop` first", s.recordFile)
+	}
+	seed := p.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	file := p.File
+	if file == "" {
+		file = fmt.Sprintf("scenario_%s.yaml", time.Now().Format("2006_0102_150405"))
+	}
+	name := p.Name
+	if name == "" {
+		name = fmt.Sprintf("recorded session on %s", s.endpoint())
+	}
+	s.recorder = scenario.NewRecorder(name, seed)
+	s.recordFile = file
+	s.rng = rand.New(rand.NewSource(seed))
+	fmt.Printf("recording started, seed %d, output %s\n", seed, file)
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// record stop
+
+type RecordStopParam struct {
+	framework.ParamBase `use:"record stop" desc:"stop recording and write the scenario YAML"`
+	File                string `name:"file" default:"" desc:"override output path given to record start"`
+	Discard             bool   `name:"discard" default:"false" desc:"drop the recorded steps without writing"`
+}
+
+func (s *MilvusctlState) RecordStopCommand(ctx context.Context, p *RecordStopParam) error {
+	if s.recorder == nil {
+		return fmt.Errorf("not recording, run `record start` first")
+	}
+	rec, file := s.recorder, s.recordFile
+	if p.File != "" {
+		file = p.File
+	}
+	if p.Discard {
+		s.recorder, s.recordFile, s.rng = nil, "", nil
+		fmt.Printf("recording discarded, %d steps dropped\n", rec.Len())
+		return nil
+	}
+	// keep recording on failure so the session can be saved elsewhere
+	if err := rec.Save(file); err != nil {
+		return err
+	}
+	s.recorder, s.recordFile, s.rng = nil, "", nil
+	fmt.Printf("%d steps recorded to %s (seed %d), replay with `run scenario --file %s`\n", rec.Len(), file, rec.Seed(), file)
+	return nil
+}
@@ -2,11 +2,10 @@ package milvusctl
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/milvus-io/birdwatcher/framework"
 	"github.com/milvus-io/birdwatcher/internal/ops"
@@ -18,12 +17,19 @@ type RunScenarioParam struct {
 	File                string   `name:"file" default:"" desc:"scenario YAML path"`
 	Vars                []string `name:"var" desc:"override var, k=v (repeatable)"`
 	ContinueOnError     bool     `name:"continue-on-error" default:"false" desc:"override scenario stop_on_error"`
+	Report              string   `name:"report" default:"" desc:"write a structured run report to this path"`
+	ReportFormat        string   `name:"report-format" default:"" desc:"report format: json|junit, inferred from --report extension (.xml means junit) when empty"`
 }
 
 func (s *MilvusctlState) RunScenarioCommand(ctx context.Context, p *RunScenarioParam) error {
 	if p.File == "" {
 		return fmt.Errorf("--file is required")
 	}
+	switch p.ReportFormat {
+	case "", scenario.ReportFormatJSON, scenario.ReportFormatJUnit:
+	default:
+		return fmt.Errorf("--report-format must be json or junit")
+	}
 	sc, err := scenario.Load(p.File)
 	if err != nil {
 		return err
@@ -32,18 +38,29 @@ func (s *MilvusctlState) RunScenarioCommand(ctx context.Context, p *RunScenarioP
 	if err != nil {
 		return err
 	}
+	// leave Rand unset so the runner seeds it from the scenario `seed`,
+	// recorded sessions rely on that to replay identical data
 	rc := &ops.RunContext{
 		Client: s.client,
 		Vars:   overrides,
 		Stdout: os.Stdout,
-		Rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 	if p.ContinueOnError {
 		f := false
 		sc.StopOnError = &f
 	}
 	runner := &scenario.Runner{Out: os.Stdout}
-	return runner.Run(ctx, sc, rc)
+	if p.Report == "" {
+		return runner.Run(ctx, sc, rc)
+	}
+	report, runErr := runner.RunWithReport(ctx, sc, rc)
+	// a missing report shall fail the run, CI consuming the report would pass otherwise
+	if err := report.WriteFile(p.Report, p.ReportFormat); err != nil {
+		return errors.Join(runErr, fmt.Errorf("failed to write report: %w", err))
+	}
+	fmt.Printf("report written to %s: %d passed, %d failed, %d skipped, %d not run\n",
+		p.Report, report.Passed, report.Failed, report.Skipped, report.NotRun)
+	return runErr
 }
 
 func parseVarOverrides(raws []string) (map[string]any, error) {
@@ -0,0 +1,328 @@
+package states
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+	"go.uber.org/atomic"
+
+	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/models"
+	"github.com/milvus-io/birdwatcher/oss"
+	"github.com/milvus-io/birdwatcher/states/etcd/common"
+	"github.com/milvus-io/birdwatcher/storage"
+	storagecommon "github.com/milvus-io/birdwatcher/storage/common"
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/datapb"
+)
+
+// hashRoutingValue hashes pk or partition key value the same way as milvus proxy does.
+func hashRoutingValue(value any) (uint32, error) {
+	switch v := value.(type) {
+	case int64:
+		return Hash32Int64(v)
+	case string:
+		return HashString2Uint32(v), nil
+	}
+	return 0, errors.Newf("unsupported routing value type %T", value)
+}
+
+// routeChannelIndex returns index of the vchannel the pk shall be written into.
+func routeChannelIndex(pk any, shardNum int) (int, error) {
+	if shardNum <= 0 {
+		return 0, errors.New("collection has no vchannel")
+	}
+	hash, err := hashRoutingValue(pk)
+	if err != nil {
+		return 0, err
+	}
+	return int(hash % uint32(shardNum)), nil
+}
+
+// parseRoutingValue parses the string value with the data type of pk or partition key field.
+func parseRoutingValue(value string, dataType models.DataType) (any, error) {
+	switch dataType {
+	case models.DataTypeInt64:
+		return strconv.ParseInt(value, 10, 64)
+	case models.DataTypeVarChar, models.DataTypeString:
+		return value, nil
+	}
+	return nil, errors.Newf("unsupported data type %s", dataType.String())
+}
+
+// partitionKeyIndexes returns partition id to partition key hash index, parsed from partition name like `_default_3`.
+func partitionKeyIndexes(partitions []*models.Partition) map[int64]uint32 {
+	result := make(map[int64]uint32)
+	for _, partition := range partitions {
+		splits := strings.Split(partition.GetProto().PartitionName, "_")
+		if len(splits) < 2 {
+			continue
+		}
+		index, err := strconv.ParseInt(splits[len(splits)-1], 10, 64)
+		if err != nil || index < 0 || index >= int64(len(partitions)) {
+			continue
+		}
+		result[partition.GetProto().PartitionID] = uint32(index)
+	}
+	return result
+}
+
+type RoutePKParam struct {
+	framework.ParamBase `use:"route pk [value]" desc:"print the vchannel and partition a pk or partition key value shall be routed to"`
+	value               string
+	CollectionID        int64  `name:"collection" default:"0" desc:"collection id of the pk"`
+	PartitionKey        string `name:"partitionKey" default:"" desc:"partition key value, use pk value if pk is the partition key"`
+}
+
+func (p *RoutePKParam) ParseArgs(args []string) error {
+	if len(args) != 1 {
+		return errors.New("route pk requires exactly one pk value")
+	}
+	p.value = args[0]
+	return nil
+}
+
+func (s *InstanceState) RoutePKCommand(ctx context.Context, p *RoutePKParam) error {
+	collection, err := common.GetCollectionByIDVersion(ctx, s.client, s.basePath, p.CollectionID)
+	if err != nil {
+		return err
+	}
+	pkField, ok := collection.GetPKField()
+	if !ok {
+		return errors.New("pk field not found")
+	}
+	pk, err := parseRoutingValue(p.value, pkField.DataType)
+	if err != nil {
+		return err
+	}
+	channels := collection.GetProto().GetVirtualChannelNames()
+	idx, err := routeChannelIndex(pk, len(channels))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("PK %s=%v routes to vchannel %s (%d/%d)\n", pkField.Name, pk, channels[idx], idx, len(channels))
+
+	partKeyField, ok := lo.Find(collection.GetProto().GetSchema().GetFields(), func(field *schemapb.FieldSchema) bool {
+		return field.IsPartitionKey
+	})
+	if !ok {
+		if p.PartitionKey != "" {
+			fmt.Println("Collection has no partition key field, partitionKey ignored")
+		}
+		return nil
+	}
+	partKeyValue := p.PartitionKey
+	if partKeyValue == "" {
+		if !partKeyField.IsPrimaryKey {
+			fmt.Printf("Partition key field %s is not pk, provide --partitionKey to route partition\n", partKeyField.Name)
+			return nil
+		}
+		partKeyValue = p.value
+	}
+	partKey, err := parseRoutingValue(partKeyValue, models.DataType(partKeyField.DataType))
+	if err != nil {
+		return err
+	}
+	partitions, err := common.ListCollectionPartitions(ctx, s.client, s.basePath, p.CollectionID)
+	if err != nil {
+		return err
+	}
+	hash, err := hashRoutingValue(partKey)
+	if err != nil {
+		return err
+	}
+	target := hash % uint32(len(partitions))
+	for partitionID, index := range partitionKeyIndexes(partitions) {
+		if index != target {
+			continue
+		}
+		partition, _ := lo.Find(partitions, func(partition *models.Partition) bool {
+			return partition.GetProto().PartitionID == partitionID
+		})
+		fmt.Printf("Partition key %s=%v routes to partition %s(%d) (%d/%d)\n", partKeyField.Name, partKey, partition.GetProto().PartitionName, partitionID, target, len(partitions))
+		return nil
+	}
+	fmt.Printf("Partition key %s=%v routes to partition index %d, but no partition with the index found\n", partKeyField.Name, partKey, target)
+	return nil
+}
+
+type CheckPKRoutingParam struct {
+	framework.ParamBase `use:"check-pk-routing" desc:"check every pk in segments is hashed to the vchannel of the segment"`
+	CollectionID        int64  `name:"collection" default:"0" desc:"collection id to check"`
+	PartitionID         int64  `name:"partition" default:"0" desc:"partition id to check"`
+	SegmentID           int64  `name:"segment" default:"0" desc:"segment id to check"`
+	MinioAddress        string `name:"minioAddr" default:"" desc:"the minio address to override, leave empty to use milvus.yaml value"`
+	SkipBucketCheck     bool   `name:"skipBucketCheck" default:"false" desc:"skip bucket exist check due to permission issue"`
+	IncludeUnhealthy    bool   `name:"includeUnhealthy" default:"false" desc:"also check dropped segments"`
+	WorkerNum           int64  `name:"workerNum" default:"4" desc:"worker num"`
+	OutputLimit         int64  `name:"outputLimit" default:"10" desc:"max misrouted pks printed per segment"`
+}
+
+func (s *InstanceState) CheckPKRoutingCommand(ctx context.Context, p *CheckPKRoutingParam) error {
+	collection, err := common.GetCollectionByIDVersion(ctx, s.client, s.basePath, p.CollectionID)
+	if err != nil {
+		return err
+	}
+	channels := collection.GetProto().GetVirtualChannelNames()
+	if len(channels) == 0 {
+		return errors.New("collection has no vchannel")
+	}
+	if shardNum := collection.GetProto().GetShardsNum(); shardNum != int32(len(channels)) {
+		fmt.Printf("Warning: collection shard num %d mismatches vchannel number %d, routing with vchannel number\n", shardNum, len(channels))
+	}
+	channelIdx := make(map[string]int)
+	for idx, channel := range channels {
+		channelIdx[channel] = idx
+	}
+	schema := collection.GetProto().GetSchema()
+	fields := lo.SliceToMap(lo.Filter(schema.GetFields(), func(field *schemapb.FieldSchema, _ int) bool {
+		return field.IsPrimaryKey || field.FieldID == 1
+	}), func(field *schemapb.FieldSchema) (int64, *schemapb.FieldSchema) {
+		return field.FieldID, field
+	})
+
+	segments, err := common.ListSegments(ctx, s.client, s.basePath, func(segment *models.Segment) bool {
+		return segment.CollectionID == p.CollectionID &&
+			(p.PartitionID == 0 || segment.PartitionID == p.PartitionID) &&
+			(p.SegmentID == 0 || segment.ID == p.SegmentID) &&
+			segment.Level != datapb.SegmentLevel_L0 &&
+			(p.IncludeUnhealthy || segment.State != commonpb.SegmentState_Dropped)
+	})
+	if err != nil {
+		return err
+	}
+
+	params := []oss.MinioConnectParam{oss.WithSkipCheckBucket(p.SkipBucketCheck)}
+	if p.MinioAddress != "" {
+		params = append(params, oss.WithMinioAddr(p.MinioAddress))
+	}
+	resolvedStore, err := s.GetObjectStore(ctx, params...)
+	if err != nil {
+		return err
+	}
+	getObject := func(binlogPath string) (storagecommon.ReadSeeker, error) {
+		return resolvedStore.Store.Open(ctx, oss.ResolveObjectKey(resolvedStore.RootPath, binlogPath))
+	}
+
+	fmt.Printf("Checking %d segments of collection %d with %d vchannels\n", len(segments), p.CollectionID, len(channels))
+	var mut sync.Mutex
+	var results []*pkRoutingTask
+	var failed []string
+	var skipped int
+	taskCh := make(chan *models.Segment)
+	wg := sync.WaitGroup{}
+	for i := 0; i < int(max(p.WorkerNum, 1)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for segment := range taskCh {
+				if err := segment.LoadBinlogs(); err != nil {
+					mut.Lock()
+					failed = append(failed, fmt.Sprintf("failed to load binlog meta of segment %d: %s", segment.ID, err.Error()))
+					mut.Unlock()
+					continue
+				}
+				// segment without binlogs, e.g. growing one, has nothing to scan yet
+				if len(segment.GetBinlogs()) == 0 {
+					mut.Lock()
+					skipped++
+					mut.Unlock()
+					continue
+				}
+				expected, ok := channelIdx[segment.GetInsertChannel()]
+				if !ok {
+					mut.Lock()
+					failed = append(failed, fmt.Sprintf("segment %d channel %s not in collection vchannels", segment.ID, segment.GetInsertChannel()))
+					mut.Unlock()
+					continue
+				}
+				task := &pkRoutingTask{segment: segment, expected: expected, shardNum: len(channels), limit: p.OutputLimit}
+				iter := storage.NewSegmentIterator(segment, schema, nil, fields, getObject, task)
+				err := iter.Range(ctx)
+				mut.Lock()
+				if err != nil {
+					failed = append(failed, fmt.Sprintf("failed to scan segment %d: %s", segment.ID, err.Error()))
+				} else {
+					results = append(results, task)
+				}
+				mut.Unlock()
+			}
+		}()
+	}
+	for _, segment := range segments {
+		taskCh <- segment
+	}
+	close(taskCh)
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].segment.ID < results[j].segment.ID })
+	var total, misrouted int64
+	for _, task := range results {
+		total += task.Counter()
+		misrouted += task.misrouted.Load()
+		if task.misrouted.Load() == 0 {
+			continue
+		}
+		fmt.Printf("Segment %d (partition %d, channel %s): %d/%d rows misrouted\n",
+			task.segment.ID, task.segment.PartitionID, task.segment.GetInsertChannel(), task.misrouted.Load(), task.Counter())
+		for _, sample := range task.samples {
+			fmt.Printf("\tPK %v shall be in %s\n", sample.pk, channels[sample.channel])
+		}
+	}
+	for _, msg := range failed {
+		fmt.Println(msg)
+	}
+	fmt.Printf("Checked %d rows in %d segments, %d rows misrouted\n", total, len(results), misrouted)
+	if skipped > 0 {
+		fmt.Printf("Skipped %d segments without binlogs\n", skipped)
+	}
+	if len(failed) > 0 {
+		return errors.Newf("%d segments not checked", len(failed))
+	}
+	return nil
+}
+
+type misroutedPK struct {
+	pk      any
+	channel int
+}
+
+// pkRoutingTask is a storage scan task checking pk hash of rows in one segment.
+type pkRoutingTask struct {
+	segment  *models.Segment
+	expected int
+	shardNum int
+	limit    int64
+
+	counter   atomic.Int64
+	misrouted atomic.Int64
+	samples   []misroutedPK
+}
+
+func (t *pkRoutingTask) Scan(pk storagecommon.PrimaryKey, _ *storagecommon.BatchInfo, _ int, _ map[int64]any) error {
+	t.counter.Inc()
+	idx, err := routeChannelIndex(pk.GetValue(), t.shardNum)
+	if err != nil {
+		return err
+	}
+	if idx == t.expected {
+		return nil
+	}
+	if t.misrouted.Inc() <= t.limit {
+		t.samples = append(t.samples, misroutedPK{pk: pk.GetValue(), channel: idx})
+	}
+	return nil
+}
+
+func (t *pkRoutingTask) Counter() int64 {
+	return t.counter.Load()
+}
+
+func (t *pkRoutingTask) Summary() {}
@@ -0,0 +1,45 @@
+package states
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/birdwatcher/models"
+	storagecommon "github.com/milvus-io/birdwatcher/storage/common"
+)
+
+func TestRouteChannelIndex(t *testing.T) {
+	idx, err := routeChannelIndex(int64(3), 4)
+	require.NoError(t, err)
+	assert.Equal(t, 3, idx)
+	idx, err = routeChannelIndex(int64(100), 4)
+	require.NoError(t, err)
+	assert.Equal(t, 0, idx)
+	idx, err = routeChannelIndex("milvus", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, idx)
+
+	_, err = routeChannelIndex(int64(1), 0)
+	assert.Error(t, err)
+	_, err = routeChannelIndex(1.0, 2)
+	assert.Error(t, err)
+
+	v, err := parseRoutingValue("42", models.DataTypeInt64)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), v)
+	_, err = parseRoutingValue("abc", models.DataTypeInt64)
+	assert.Error(t, err)
+}
+
+func TestPKRoutingTask(t *testing.T) {
+	task := &pkRoutingTask{expected: 0, shardNum: 4, limit: 1}
+	for _, pk := range []int64{1, 2, 3, 100, 3} {
+		require.NoError(t, task.Scan(storagecommon.NewInt64PrimaryKey(pk), nil, 0, nil))
+	}
+	assert.EqualValues(t, 5, task.Counter())
+	assert.EqualValues(t, 2, task.misrouted.Load())
+	require.Len(t, task.samples, 1)
+	assert.Equal(t, misroutedPK{pk: int64(3), channel: 3}, task.samples[0])
+}
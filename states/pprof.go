@@ -22,7 +22,10 @@ import (
 type PprofParam struct {
 	framework.ParamBase `use:"pprof" desc:"get pprof from online components"`
 	Type                string `name:"type" default:"goroutine" desc:"pprof metric type to fetch"`
-	Port                int64  `name:"port" default:"9091" desc:"metrics port milvus component is using"`
+	Port                int64  `name:"port" default:"0" desc:"metrics port milvus component is using, detected from configuration if not set"`
+	Interval            string `name:"interval" default:"60s" desc:"interval between two captures of a series, like 60s or 5m"`
+	Count               int64  `name:"count" default:"1" desc:"number of captures, a series is captured if more than one"`
+	Debug               int64  `name:"debug" default:"0" desc:"pprof debug level, 2 for goroutine stack dump text"`
 }
 
 func (s *InstanceState) GetPprofCommand(ctx context.Context, p *PprofParam) error {
@@ -31,6 +34,16 @@ func (s *InstanceState) GetPprofCommand(ctx context.Context, p *PprofParam) erro
 	default:
 		return errors.New("invalid pprof metric type provided")
 	}
+	if p.Count < 1 {
+		return errors.New("count shall be at least 1")
+	}
+	interval, err := time.ParseDuration(p.Interval)
+	if err != nil {
+		return errors.Wrap(err, "invalid interval")
+	}
+	if p.Count > 1 && interval <= 0 {
+		return errors.New("interval shall be positive to capture a series")
+	}
 
 	sessions, err := common.ListSessions(ctx, s.client, s.basePath)
 	if err != nil {
@@ -59,42 +72,90 @@ func (s *InstanceState) GetPprofCommand(ctx context.Context, p *PprofParam) erro
 		return session.ServerID
 	})
 
+	ports := make(map[int64]int64)
+	for id, sessions := range groups {
+		ports[id] = p.Port
+		if p.Port == 0 {
+			ports[id] = getMetricsPort(ctx, sessions[0])
+		}
+	}
+
+	for seq := int64(0); seq < p.Count; seq++ {
+		if seq > 0 {
+			select {
+			case <-ctx.Done():
+				fmt.Printf("capture interrupted, %d captures written to archive file %s\n", seq, filePath)
+				return nil
+			case <-time.After(interval):
+			}
+		}
+		// single capture keeps the legacy entry name without sequence
+		suffix := ""
+		if p.Count > 1 {
+			suffix = fmt.Sprintf(".%03d", seq)
+		}
+		if err := capturePprof(ctx, tw, groups, ports, p.Type, p.Debug, suffix); err != nil {
+			return err
+		}
+		if p.Count > 1 {
+			fmt.Printf("capture %d/%d done\n", seq+1, p.Count)
+		}
+	}
+
+	fmt.Printf("pprof metrics fetch done, write to archive file %s\n", filePath)
+
+	return nil
+}
+
+// capturePprof fetches one profile from each server and writes them into the archive.
+func capturePprof(ctx context.Context, tw *tar.Writer, groups map[int64][]*models.Session, ports map[int64]int64, pprofType string, debug int64, suffix string) error {
 	type pprofResult struct {
 		id       int64
 		sessions []*models.Session
 		data     []byte
 		err      error
+		ts       time.Time
 	}
 
-	ch := make(chan pprofResult, len(sessions))
+	ch := make(chan pprofResult, len(groups))
 	signal := make(chan error, 1)
 
 	go func() {
+		var writeErr error
 		for result := range ch {
-			if result.err != nil {
-				fmt.Println()
-			}
 			session := result.sessions[0]
 			serverName := session.ServerName
 			// set to mixture if there are multiple sessions in group
 			if len(result.sessions) > 1 {
 				serverName = "mixture"
 			}
-			tw.WriteHeader(&tar.Header{
+			if result.err != nil {
+				fmt.Printf("failed to fetch %s pprof from %s-%d, %s\n", pprofType, serverName, session.ServerID, result.err.Error())
+				continue
+			}
+			if writeErr != nil {
+				continue
+			}
+			writeErr = tw.WriteHeader(&tar.Header{
 				Typeflag: tar.TypeReg,
 
-				Name: fmt.Sprintf("%s_%d_%s", serverName, session.ServerID, p.Type),
-				Size: int64(len(result.data)),
-				Mode: 0o600,
+				Name:    fmt.Sprintf("%s_%d_%s%s", serverName, session.ServerID, pprofType, suffix),
+				Size:    int64(len(result.data)),
+				Mode:    0o600,
+				ModTime: result.ts,
 			})
+			if writeErr != nil {
+				continue
+			}
 
-			_, err = tw.Write(result.data)
-			if err != nil {
-				signal <- err
+			_, writeErr = tw.Write(result.data)
+			if writeErr != nil {
+				continue
 			}
 
-			fmt.Printf("%s pprof from %s-%d fetched, added into archive file\n", p.Type, serverName, session.ServerID)
+			fmt.Printf("%s pprof from %s-%d fetched, added into archive file\n", pprofType, serverName, session.ServerID)
 		}
+		signal <- writeErr
 		close(signal)
 	}()
 
@@ -110,18 +171,25 @@ func (s *InstanceState) GetPprofCommand(ctx context.Context, p *PprofParam) erro
 
 			result := pprofResult{
 				sessions: sessions,
+				ts:       time.Now(),
 			}
 			addr := sessions[0].IP()
-			// TODO add auto detection from configuration API
-			url := fmt.Sprintf("http://%s:%d/debug/pprof/%s?debug=0", addr, p.Port, p.Type)
+			url := fmt.Sprintf("http://%s:%d/debug/pprof/%s?debug=%d", addr, ports[id], pprofType, debug)
 
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				result.err = err
+				ch <- result
+				return
+			}
 			// #nosec
-			resp, err := http.Get(url)
+			resp, err := http.DefaultClient.Do(req)
 			if err != nil {
 				result.err = err
 				ch <- result
 				return
 			}
+			defer resp.Body.Close()
 
 			bs, err := io.ReadAll(resp.Body)
 			if err != nil {
@@ -137,13 +205,9 @@ func (s *InstanceState) GetPprofCommand(ctx context.Context, p *PprofParam) erro
 	wg.Wait()
 	close(ch)
 
-	for err := range signal {
-		if err != nil {
-			fmt.Println("failed to write pprof:", err.Error())
-		}
+	if err := <-signal; err != nil {
+		fmt.Println("failed to write pprof:", err.Error())
+		return err
 	}
-
-	fmt.Printf("pprof metrics fetch done, write to archive file %s\n", filePath)
-
 	return nil
 }
@@ -0,0 +1,302 @@
+package states
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/pprof/profile"
+	"github.com/samber/lo"
+
+	"github.com/milvus-io/birdwatcher/framework"
+)
+
+type PprofDiffParam struct {
+	framework.ParamBase `use:"pprof diff [base] [target]" desc:"diff heap or goroutine profiles of archives captured by pprof, first and last capture of a series if only one archive provided"`
+	archives            []string
+	Node                string `name:"node" default:"" desc:"only diff profiles whose entry name contains the value, like querynode_5"`
+	SampleIndex         string `name:"sampleIndex" default:"" desc:"sample type to compare, like inuse_space or alloc_objects, default inuse_space for heap"`
+	Top                 int64  `name:"top" default:"10" desc:"number of functions and stacks to show per node"`
+}
+
+func (p *PprofDiffParam) ParseArgs(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New("pprof diff requires one series archive or two archives")
+	}
+	p.archives = args
+	return nil
+}
+
+// PprofDiffCommand compares profiles from the pprof archives per node.
+func (app *ApplicationState) PprofDiffCommand(ctx context.Context, p *PprofDiffParam) error {
+	base, err := readPprofArchive(p.archives[0])
+	if err != nil {
+		return err
+	}
+	target := base
+	if len(p.archives) == 2 {
+		target, err = readPprofArchive(p.archives[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	nodes := lo.Filter(lo.Keys(target), func(node string, _ int) bool {
+		return strings.Contains(node, p.Node)
+	})
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		baseEntries, ok := base[node]
+		if !ok {
+			fmt.Printf("%s not found in base archive, skip\n", node)
+			continue
+		}
+		from, to := baseEntries[0], target[node][len(target[node])-1]
+		if len(p.archives) == 1 && len(baseEntries) < 2 {
+			fmt.Printf("%s has only one capture, skip\n", node)
+			continue
+		}
+		diff, err := diffPprofEntries(from, to, p.SampleIndex)
+		if err != nil {
+			fmt.Printf("failed to diff %s: %s\n", node, err.Error())
+			continue
+		}
+		fmt.Printf("=== %s: %s -> %s ===\n", node, from.ts.Format(time.DateTime), to.ts.Format(time.DateTime))
+		fmt.Print(diff.format(int(p.Top)))
+	}
+	return nil
+}
+
+type pprofEntry struct {
+	seq  int
+	ts   time.Time
+	data []byte
+}
+
+// readPprofArchive reads profiles of the archive grouped by node entry name, sorted by capture sequence.
+func readPprofArchive(file string) (map[string][]*pprofEntry, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s is not a pprof archive", file)
+	}
+	defer gr.Close()
+
+	result := make(map[string][]*pprofEntry)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read archive %s", file)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		node, seq := parsePprofEntryName(header.Name)
+		result[node] = append(result[node], &pprofEntry{seq: seq, ts: header.ModTime, data: data})
+	}
+	for _, entries := range result {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].seq < entries[j].seq
+		})
+	}
+	return result, nil
+}
+
+// parsePprofEntryName splits entry name like `querynode_5_heap.003` into node and sequence.
+func parsePprofEntryName(name string) (string, int) {
+	idx := strings.LastIndex(name, ".")
+	if idx < 0 {
+		return name, 0
+	}
+	seq, err := strconv.Atoi(name[idx+1:])
+	if err != nil {
+		return name, 0
+	}
+	return name[:idx], seq
+}
+
+func diffPprofEntries(from, to *pprofEntry, sampleIndex string) (*pprofDiff, error) {
+	base, err := profile.Parse(bytes.NewReader(from.data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse base profile")
+	}
+	target, err := profile.Parse(bytes.NewReader(to.data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse target profile")
+	}
+	baseIdx, err := profileSampleIndex(base, sampleIndex)
+	if err != nil {
+		return nil, err
+	}
+	targetIdx, err := profileSampleIndex(target, base.SampleType[baseIdx].Type)
+	if err != nil {
+		return nil, err
+	}
+	return diffProfileSummary(summarizeProfile(base, baseIdx), summarizeProfile(target, targetIdx), base.SampleType[baseIdx]), nil
+}
+
+// profileSampleIndex returns index of the sample type, default inuse_space if present or the last one.
+func profileSampleIndex(prof *profile.Profile, name string) (int, error) {
+	if len(prof.SampleType) == 0 {
+		return 0, errors.New("profile has no sample type")
+	}
+	if name == "" {
+		name = "inuse_space"
+		if _, ok := lo.Find(prof.SampleType, func(st *profile.ValueType) bool { return st.Type == name }); !ok {
+			return len(prof.SampleType) - 1, nil
+		}
+	}
+	for idx, st := range prof.SampleType {
+		if st.Type == name {
+			return idx, nil
+		}
+	}
+	return 0, errors.Newf("sample type %s not found, available: %s", name, strings.Join(lo.Map(prof.SampleType, func(st *profile.ValueType, _ int) string {
+		return st.Type
+	}), ","))
+}
+
+type profileSummary struct {
+	flat   map[string]int64
+	cum    map[string]int64
+	stacks map[string]int64
+	total  int64
+}
+
+// sampleFunctions returns function names of the sample from leaf to root.
+func sampleFunctions(sample *profile.Sample) []string {
+	var names []string
+	for _, loc := range sample.Location {
+		for _, line := range loc.Line {
+			if line.Function != nil {
+				names = append(names, line.Function.Name)
+			}
+		}
+		if len(loc.Line) == 0 {
+			names = append(names, fmt.Sprintf("0x%x", loc.Address))
+		}
+	}
+	return names
+}
+
+func summarizeProfile(prof *profile.Profile, idx int) *profileSummary {
+	summary := &profileSummary{
+		flat:   make(map[string]int64),
+		cum:    make(map[string]int64),
+		stacks: make(map[string]int64),
+	}
+	for _, sample := range prof.Sample {
+		value := sample.Value[idx]
+		summary.total += value
+		names := sampleFunctions(sample)
+		if len(names) == 0 {
+			continue
+		}
+		summary.flat[names[0]] += value
+		for _, name := range lo.Uniq(names) {
+			summary.cum[name] += value
+		}
+		summary.stacks[strings.Join(names, "\n")] += value
+	}
+	return summary
+}
+
+type funcDelta struct {
+	Name string
+	Flat int64
+	Cum  int64
+}
+
+type stackDelta struct {
+	Frames []string
+	Delta  int64
+	Value  int64
+}
+
+type pprofDiff struct {
+	sampleType *profile.ValueType
+	total      int64
+	delta      int64
+	funcs      []funcDelta
+	stacks     []stackDelta
+}
+
+func diffProfileSummary(base, target *profileSummary, sampleType *profile.ValueType) *pprofDiff {
+	diff := &pprofDiff{sampleType: sampleType, total: target.total, delta: target.total - base.total}
+	names := lo.Uniq(append(lo.Keys(base.cum), lo.Keys(target.cum)...))
+	for _, name := range names {
+		d := funcDelta{Name: name, Flat: target.flat[name] - base.flat[name], Cum: target.cum[name] - base.cum[name]}
+		if d.Flat > 0 || d.Cum > 0 {
+			diff.funcs = append(diff.funcs, d)
+		}
+	}
+	sort.Slice(diff.funcs, func(i, j int) bool {
+		if diff.funcs[i].Flat != diff.funcs[j].Flat {
+			return diff.funcs[i].Flat > diff.funcs[j].Flat
+		}
+		if diff.funcs[i].Cum != diff.funcs[j].Cum {
+			return diff.funcs[i].Cum > diff.funcs[j].Cum
+		}
+		return diff.funcs[i].Name < diff.funcs[j].Name
+	})
+
+	for stack, value := range target.stacks {
+		if delta := value - base.stacks[stack]; delta > 0 {
+			diff.stacks = append(diff.stacks, stackDelta{Frames: strings.Split(stack, "\n"), Delta: delta, Value: value})
+		}
+	}
+	sort.Slice(diff.stacks, func(i, j int) bool {
+		if diff.stacks[i].Delta != diff.stacks[j].Delta {
+			return diff.stacks[i].Delta > diff.stacks[j].Delta
+		}
+		return strings.Join(diff.stacks[i].Frames, ",") < strings.Join(diff.stacks[j].Frames, ",")
+	})
+	return diff
+}
+
+func (d *pprofDiff) formatValue(v int64) string {
+	if d.sampleType.Unit == "bytes" {
+		if v < 0 {
+			return "-" + hrSize(-v)
+		}
+		return hrSize(v)
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+func (d *pprofDiff) format(top int) string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "%s: total %s, delta %+d (%s)\n", d.sampleType.Type, d.formatValue(d.total), d.delta, d.formatValue(d.delta))
+	fmt.Fprintln(sb, "Top growing functions:")
+	fmt.Fprintf(sb, "\t%12s %12s  %s\n", "flat", "cum", "function")
+	for _, f := range lo.Subset(d.funcs, 0, uint(top)) {
+		fmt.Fprintf(sb, "\t%12s %12s  %s\n", d.formatValue(f.Flat), d.formatValue(f.Cum), f.Name)
+	}
+	fmt.Fprintln(sb, "Top growing stacks:")
+	for _, stack := range lo.Subset(d.stacks, 0, uint(top)) {
+		fmt.Fprintf(sb, "\t+%s (now %s)\n", d.formatValue(stack.Delta), d.formatValue(stack.Value))
+		for _, frame := range stack.Frames {
+			fmt.Fprintf(sb, "\t\t%s\n", frame)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,81 @@
+package states
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGoroutineProfile(t *testing.T, counts map[string]int64) []byte {
+	prof := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "goroutine", Unit: "count"}},
+		PeriodType: &profile.ValueType{Type: "goroutine", Unit: "count"},
+	}
+	var id uint64
+	for leaf, count := range counts {
+		var locs []*profile.Location
+		for _, name := range []string{leaf, "main.worker"} {
+			id++
+			fn := &profile.Function{ID: id, Name: name}
+			loc := &profile.Location{ID: id, Line: []profile.Line{{Function: fn}}}
+			prof.Function = append(prof.Function, fn)
+			prof.Location = append(prof.Location, loc)
+			locs = append(locs, loc)
+		}
+		prof.Sample = append(prof.Sample, &profile.Sample{Location: locs, Value: []int64{count}})
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, prof.Write(buf))
+	return buf.Bytes()
+}
+
+func TestPprofDiff(t *testing.T) {
+	file := path.Join(t.TempDir(), "bw_pprof_goroutine.tar.gz")
+	f, err := os.Create(file)
+	require.NoError(t, err)
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	now := time.Now()
+	for idx, counts := range []map[string]int64{
+		{"sync.(*Mutex).Lock": 2, "time.Sleep": 5},
+		{"sync.(*Mutex).Lock": 40, "time.Sleep": 5},
+	} {
+		data := newTestGoroutineProfile(t, counts)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "querynode_5_goroutine." + []string{"000", "001"}[idx], Size: int64(len(data)), Mode: 0o600, ModTime: now}))
+		_, err := tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	require.NoError(t, f.Close())
+
+	archive, err := readPprofArchive(file)
+	require.NoError(t, err)
+	require.Len(t, archive["querynode_5_goroutine"], 2)
+
+	diff, err := diffPprofEntries(archive["querynode_5_goroutine"][0], archive["querynode_5_goroutine"][1], "")
+	require.NoError(t, err)
+	assert.EqualValues(t, 38, diff.delta)
+	require.Len(t, diff.funcs, 2)
+	assert.Equal(t, "sync.(*Mutex).Lock", diff.funcs[0].Name)
+	assert.EqualValues(t, 38, diff.funcs[0].Flat)
+	assert.Equal(t, "main.worker", diff.funcs[1].Name)
+	assert.EqualValues(t, 38, diff.funcs[1].Cum)
+	require.Len(t, diff.stacks, 1)
+	assert.Equal(t, []string{"sync.(*Mutex).Lock", "main.worker"}, diff.stacks[0].Frames)
+
+	_, err = diffPprofEntries(archive["querynode_5_goroutine"][0], archive["querynode_5_goroutine"][1], "inuse_space")
+	assert.Error(t, err)
+
+	node, seq := parsePprofEntryName("mixture_1_heap")
+	assert.Equal(t, "mixture_1_heap", node)
+	assert.Equal(t, 0, seq)
+}
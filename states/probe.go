@@ -150,28 +150,6 @@ func (s *InstanceState) ProbePKCommand(ctx context.Context, p *ProbePKParam) err
 	}
 
 	pkf, _ := coll.GetPKField()
-	var datatype schemapb.DataType
-	var val *planpb.GenericValue
-	switch pkf.DataType {
-	case models.DataTypeVarChar:
-		datatype = schemapb.DataType_VarChar
-		val = &planpb.GenericValue{
-			Val: &planpb.GenericValue_StringVal{
-				StringVal: p.PK,
-			},
-		}
-	case models.DataTypeInt64:
-		datatype = schemapb.DataType_Int64
-		pkv, err := strconv.ParseInt(p.PK, 10, 64)
-		if err != nil {
-			return err
-		}
-		val = &planpb.GenericValue{
-			Val: &planpb.GenericValue_Int64Val{
-				Int64Val: pkv,
-			},
-		}
-	}
 
 	var outputFields []int64
 	fieldIDName := make(map[int64]string)
@@ -188,30 +166,11 @@ func (s *InstanceState) ProbePKCommand(ctx context.Context, p *ProbePKParam) err
 	if _, has := outputSet["$ts"]; has {
 		outputFields = append(outputFields, 1)
 	}
-	plan := &planpb.PlanNode{
-		Node: &planpb.PlanNode_Predicates{
-			Predicates: &planpb.Expr{
-				Expr: &planpb.Expr_TermExpr{
-					TermExpr: &planpb.TermExpr{
-						ColumnInfo: &planpb.ColumnInfo{
-							FieldId:      pkf.FieldID,
-							DataType:     datatype,
-							IsAutoID:     pkf.AutoID,
-							IsPrimaryKey: pkf.IsPrimaryKey,
-						},
-						Values: []*planpb.GenericValue{
-							val,
-						},
-						IsInField: true,
-					},
-				},
-			},
-		},
-		OutputFieldIds: outputFields,
+	bs, err := getPKTermPlan(pkf, p.PK, outputFields)
+	if err != nil {
+		return err
 	}
 
-	bs, _ := proto.Marshal(plan)
-
 	sessions, err := common.ListSessions(ctx, s.client, s.basePath)
 	if err != nil {
 		fmt.Println("failed to list online sessions", err.Error())
@@ -306,6 +265,58 @@ func (s *InstanceState) ProbePKCommand(ctx context.Context, p *ProbePKParam) err
 	return nil
 }
 
+// getPKTermPlan returns serialized plan of `pk in [value]` expression.
+func getPKTermPlan(pkf models.FieldSchema, pk string, outputFields []int64) ([]byte, error) {
+	var datatype schemapb.DataType
+	var val *planpb.GenericValue
+	switch pkf.DataType {
+	case models.DataTypeVarChar:
+		datatype = schemapb.DataType_VarChar
+		val = &planpb.GenericValue{
+			Val: &planpb.GenericValue_StringVal{
+				StringVal: pk,
+			},
+		}
+	case models.DataTypeInt64:
+		datatype = schemapb.DataType_Int64
+		pkv, err := strconv.ParseInt(pk, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		val = &planpb.GenericValue{
+			Val: &planpb.GenericValue_Int64Val{
+				Int64Val: pkv,
+			},
+		}
+	default:
+		return nil, fmt.Errorf("unsupported pk data type %s", pkf.DataType.String())
+	}
+
+	plan := &planpb.PlanNode{
+		Node: &planpb.PlanNode_Predicates{
+			Predicates: &planpb.Expr{
+				Expr: &planpb.Expr_TermExpr{
+					TermExpr: &planpb.TermExpr{
+						ColumnInfo: &planpb.ColumnInfo{
+							FieldId:      pkf.FieldID,
+							DataType:     datatype,
+							IsAutoID:     pkf.AutoID,
+							IsPrimaryKey: pkf.IsPrimaryKey,
+						},
+						Values: []*planpb.GenericValue{
+							val,
+						},
+						IsInField: true,
+					},
+				},
+			},
+		},
+		OutputFieldIds: outputFields,
+	}
+
+	return proto.Marshal(plan)
+}
+
 func GetSizeOfIDs(data *schemapb.IDs) int {
 	result := 0
 	if data.IdField == nil {
@@ -0,0 +1,339 @@
+package states
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/samber/lo"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/models"
+	"github.com/milvus-io/birdwatcher/states/etcd/common"
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/internalpb"
+	"github.com/milvus-io/milvus/pkg/v2/proto/querypb"
+)
+
+type ProbeSearchParam struct {
+	framework.DataSetParam `use:"probe search" desc:"probe search latency and replica consistency of shard leaders"`
+	CollectionID           int64  `name:"collection" default:"0" desc:"collection id to probe, all loaded collections if not set"`
+	Iterations             int64  `name:"iterations" default:"10" desc:"number of search requests sent to each shard leader"`
+	Timeout                int64  `name:"timeout" default:"5" desc:"timeout in seconds of each request"`
+	PK                     string `name:"pk" default:"" desc:"pk value to check visibility on all replicas with query"`
+}
+
+// ProbeSearchCommand sends identical mock search requests to all replica leaders of each shard,
+// measures latency and compares result ids between replicas.
+func (s *InstanceState) ProbeSearchCommand(ctx context.Context, p *ProbeSearchParam) (*framework.PresetResultSet, error) {
+	if p.Iterations < 1 {
+		return nil, errors.New("iterations shall be positive")
+	}
+	loaded, err := common.ListCollectionLoadedInfo(ctx, s.client, s.basePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list loaded collection")
+	}
+	loaded = lo.Filter(loaded, func(info *models.CollectionLoaded, _ int) bool {
+		return p.CollectionID == 0 || info.GetProto().GetCollectionID() == p.CollectionID
+	})
+	if len(loaded) == 0 {
+		return nil, errors.New("no loaded collection")
+	}
+
+	sessions, err := common.ListSessions(ctx, s.client, s.basePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list online sessions")
+	}
+	qc, err := getQueryCoordClient(sessions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect querycoord")
+	}
+	qns, err := getQueryNodeClients(sessions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect querynodes")
+	}
+	if len(qns) == 0 {
+		return nil, errors.New("no querynode online")
+	}
+
+	timeout := time.Duration(p.Timeout) * time.Second
+	rs := &ProbeSearchResult{}
+	for _, info := range loaded {
+		collectionID := info.GetProto().GetCollectionID()
+		req, err := getMockSearchRequest(ctx, s.client, s.basePath, info)
+		if err != nil {
+			fmt.Printf("failed to generate mock request for collection %d: %s\n", collectionID, err.Error())
+			continue
+		}
+		var pkPlan []byte
+		var pkFieldID int64
+		if p.PK != "" {
+			coll, err := common.GetCollectionByIDVersion(ctx, s.client, s.basePath, collectionID)
+			if err != nil {
+				return nil, err
+			}
+			pkf, _ := coll.GetPKField()
+			pkFieldID = pkf.FieldID
+			pkPlan, err = getPKTermPlan(pkf, p.PK, []int64{pkFieldID})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		leaders, err := qc.GetShardLeaders(ctx, &querypb.GetShardLeadersRequest{
+			Base:         &commonpb.MsgBase{},
+			CollectionID: collectionID,
+		})
+		if err != nil {
+			fmt.Printf("querycoord get shard leaders of collection %d error: %s\n", collectionID, err.Error())
+			continue
+		}
+
+		for _, shard := range leaders.GetShards() {
+			rows := make(map[int64]*probeLeaderRow)
+			for _, nodeID := range shard.GetNodeIds() {
+				rows[nodeID] = &probeLeaderRow{CollectionID: collectionID, Channel: shard.GetChannelName(), NodeID: nodeID}
+			}
+			for iter := int64(0); iter < p.Iterations; iter++ {
+				// same mvcc timestamp for all replicas, so results shall be identical
+				ts := ComposeTS(time.Now().Add(-time.Second).UnixMilli(), 0)
+				results := make(map[int64]string)
+				for _, nodeID := range shard.GetNodeIds() {
+					row := rows[nodeID]
+					qn, ok := qns[nodeID]
+					if !ok {
+						row.fail("leader not online")
+						continue
+					}
+					searchReq := proto.Clone(req).(*querypb.SearchRequest)
+					searchReq.DmlChannels = []string{shard.GetChannelName()}
+					searchReq.Req.Base.TargetID = nodeID
+					searchReq.Req.MvccTimestamp = ts
+					searchReq.Req.GuaranteeTimestamp = ts
+
+					reqCtx, cancel := context.WithTimeout(ctx, timeout)
+					start := time.Now()
+					resp, err := qn.Search(reqCtx, searchReq)
+					elapsed := time.Since(start)
+					cancel()
+					if err != nil {
+						row.fail(err.Error())
+						continue
+					}
+					if resp.GetStatus().GetErrorCode() != commonpb.ErrorCode_Success {
+						row.fail(fmt.Sprintf("%s: %s", resp.GetStatus().GetErrorCode().String(), resp.GetStatus().GetReason()))
+						continue
+					}
+					ids, err := searchResultIDs(resp)
+					if err != nil {
+						row.fail(err.Error())
+						continue
+					}
+					row.latencies = append(row.latencies, elapsed)
+					results[nodeID] = ids
+				}
+				for nodeID := range divergentReplicas(results) {
+					rows[nodeID].Divergent++
+				}
+			}
+
+			if p.PK != "" {
+				probePKVisibility(ctx, qns, shard, collectionID, pkPlan, pkFieldID, timeout, rows)
+			}
+			for _, nodeID := range shard.GetNodeIds() {
+				rows[nodeID].summarize()
+				rs.Rows = append(rs.Rows, rows[nodeID])
+			}
+		}
+	}
+	rs.checkPK = p.PK != ""
+	return framework.NewPresetResultSet(rs, framework.NameFormat(p.Format)), nil
+}
+
+// probePKVisibility queries the pk on all replica leaders of the shard, the pk shall be visible on all of them or none.
+func probePKVisibility(ctx context.Context, qns map[int64]querypb.QueryNodeClient, shard *querypb.ShardLeadersList,
+	collectionID int64, plan []byte, pkFieldID int64, timeout time.Duration, rows map[int64]*probeLeaderRow,
+) {
+	ts := ComposeTS(time.Now().Add(-time.Second).UnixMilli(), 0)
+	found := make(map[int64]bool)
+	for _, nodeID := range shard.GetNodeIds() {
+		qn, ok := qns[nodeID]
+		if !ok {
+			rows[nodeID].PKVisible = "error: leader not online"
+			continue
+		}
+		reqCtx, cancel := context.WithTimeout(ctx, timeout)
+		result, err := qn.Query(reqCtx, &querypb.QueryRequest{
+			DmlChannels: []string{shard.GetChannelName()},
+			Scope:       querypb.DataScope_All,
+			Req: &internalpb.RetrieveRequest{
+				Base:               &commonpb.MsgBase{MsgType: commonpb.MsgType_Retrieve, TargetID: nodeID, MsgID: time.Now().Unix()},
+				CollectionID:       collectionID,
+				SerializedExprPlan: plan,
+				OutputFieldsId:     []int64{pkFieldID},
+				Limit:              -1,
+				MvccTimestamp:      ts,
+				GuaranteeTimestamp: ts,
+			},
+		})
+		cancel()
+		if err != nil {
+			rows[nodeID].PKVisible = "error: " + err.Error()
+			continue
+		}
+		if result.GetStatus().GetErrorCode() != commonpb.ErrorCode_Success {
+			rows[nodeID].PKVisible = "error: " + result.GetStatus().GetReason()
+			continue
+		}
+		found[nodeID] = GetSizeOfIDs(result.GetIds()) > 0
+	}
+	anyFound := lo.Contains(lo.Values(found), true)
+	for nodeID, ok := range found {
+		switch {
+		case ok:
+			rows[nodeID].PKVisible = "visible"
+		case anyFound:
+			rows[nodeID].PKVisible = "missing"
+		default:
+			rows[nodeID].PKVisible = "not found"
+		}
+	}
+}
+
+// searchResultIDs returns the result ids of search response in a comparable form.
+func searchResultIDs(resp *internalpb.SearchResults) (string, error) {
+	if len(resp.GetSlicedBlob()) == 0 {
+		return "", nil
+	}
+	data := &schemapb.SearchResultData{}
+	if err := proto.Unmarshal(resp.GetSlicedBlob(), data); err != nil {
+		return "", errors.Wrap(err, "failed to unmarshal search result")
+	}
+	switch ids := data.GetIds().GetIdField().(type) {
+	case *schemapb.IDs_IntId:
+		return fmt.Sprint(ids.IntId.GetData()), nil
+	case *schemapb.IDs_StrId:
+		return fmt.Sprint(ids.StrId.GetData()), nil
+	}
+	return "", nil
+}
+
+// divergentReplicas returns replicas whose result differs from the majority,
+// ties are broken by the result of smallest node id.
+func divergentReplicas(results map[int64]string) map[int64]struct{} {
+	counts := make(map[string]int)
+	nodes := lo.Keys(results)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+	var majority string
+	for _, nodeID := range nodes {
+		ids := results[nodeID]
+		counts[ids]++
+		if counts[ids] > counts[majority] {
+			majority = ids
+		}
+	}
+	divergent := make(map[int64]struct{})
+	for nodeID, ids := range results {
+		if ids != majority {
+			divergent[nodeID] = struct{}{}
+		}
+	}
+	return divergent
+}
+
+type probeLeaderRow struct {
+	CollectionID int64   `json:"collection_id"`
+	Channel      string  `json:"channel"`
+	NodeID       int64   `json:"node_id"`
+	Success      int     `json:"success"`
+	Failed       int     `json:"failed"`
+	AvgMs        float64 `json:"avg_ms"`
+	P99Ms        float64 `json:"p99_ms"`
+	MaxMs        float64 `json:"max_ms"`
+	Divergent    int     `json:"divergent"`
+	PKVisible    string  `json:"pk_visible,omitempty"`
+	LastError    string  `json:"last_error,omitempty"`
+
+	latencies []time.Duration
+}
+
+func (row *probeLeaderRow) fail(reason string) {
+	row.Failed++
+	row.LastError = reason
+}
+
+func (row *probeLeaderRow) summarize() {
+	row.Success = len(row.latencies)
+	if row.Success == 0 {
+		return
+	}
+	sorted := lo.Map(row.latencies, func(d time.Duration, _ int) float64 {
+		return float64(d.Microseconds()) / 1000
+	})
+	sort.Float64s(sorted)
+	row.AvgMs = lo.Sum(sorted) / float64(len(sorted))
+	row.P99Ms = sorted[(len(sorted)*99+99)/100-1]
+	row.MaxMs = sorted[len(sorted)-1]
+}
+
+type ProbeSearchResult struct {
+	Rows []*probeLeaderRow `json:"rows"`
+
+	checkPK bool
+}
+
+func (rs *ProbeSearchResult) Entities() any {
+	return rs.Rows
+}
+
+func (rs *ProbeSearchResult) TableHeaders() table.Row {
+	headers := table.Row{"Collection", "Channel", "Leader", "Success", "Failed", "Avg(ms)", "P99(ms)", "Max(ms)", "Divergent"}
+	if rs.checkPK {
+		headers = append(headers, "PK")
+	}
+	return headers
+}
+
+func (rs *ProbeSearchResult) TableRows() []table.Row {
+	rows := make([]table.Row, 0, len(rs.Rows))
+	for _, row := range rs.Rows {
+		r := table.Row{row.CollectionID, row.Channel, row.NodeID, row.Success, row.Failed,
+			fmt.Sprintf("%.2f", row.AvgMs), fmt.Sprintf("%.2f", row.P99Ms), fmt.Sprintf("%.2f", row.MaxMs), row.Divergent}
+		if rs.checkPK {
+			r = append(r, row.PKVisible)
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+func (rs *ProbeSearchResult) PrintAs(format framework.Format) string {
+	switch format {
+	case framework.FormatDefault, framework.FormatPlain:
+		sb := &strings.Builder{}
+		for _, row := range rs.Rows {
+			fmt.Fprintf(sb, "Collection %d Shard %s Leader[%d]: success %d, failed %d, latency avg %.2fms p99 %.2fms max %.2fms",
+				row.CollectionID, row.Channel, row.NodeID, row.Success, row.Failed, row.AvgMs, row.P99Ms, row.MaxMs)
+			if row.Divergent > 0 {
+				fmt.Fprintf(sb, ", DIVERGENT in %d iterations", row.Divergent)
+			}
+			if rs.checkPK {
+				fmt.Fprintf(sb, ", pk %s", row.PKVisible)
+			}
+			fmt.Fprintln(sb)
+			if row.LastError != "" {
+				fmt.Fprintf(sb, "\tlast error: %s\n", row.LastError)
+			}
+		}
+		return sb.String()
+	case framework.FormatJSON:
+		return framework.MarshalJSON(rs)
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+package states
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDivergentReplicas(t *testing.T) {
+	assert.Empty(t, divergentReplicas(map[int64]string{1: "[1 2]", 2: "[1 2]"}))
+	assert.Equal(t, map[int64]struct{}{3: {}}, divergentReplicas(map[int64]string{1: "[1 2]", 2: "[1 2]", 3: "[1 3]"}))
+	// tie broken by smallest node id
+	assert.Equal(t, map[int64]struct{}{5: {}}, divergentReplicas(map[int64]string{5: "[2]", 4: "[1]"}))
+}
+
+func TestProbeLeaderRowSummarize(t *testing.T) {
+	row := &probeLeaderRow{}
+	for i := 1; i <= 10; i++ {
+		row.latencies = append(row.latencies, time.Duration(i)*time.Millisecond)
+	}
+	row.fail("timeout")
+	row.summarize()
+	assert.Equal(t, 10, row.Success)
+	assert.Equal(t, 1, row.Failed)
+	assert.InDelta(t, 5.5, row.AvgMs, 1e-6)
+	assert.InDelta(t, 10, row.P99Ms, 1e-6)
+	assert.InDelta(t, 10, row.MaxMs, 1e-6)
+	assert.Equal(t, "timeout", row.LastError)
+}
@@ -0,0 +1,432 @@
+package states
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+
+	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/models"
+	"github.com/milvus-io/birdwatcher/oss"
+	"github.com/milvus-io/birdwatcher/states/etcd/common"
+	"github.com/milvus-io/birdwatcher/storage"
+	storagecommon "github.com/milvus-io/birdwatcher/storage/common"
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
+	"github.com/milvus-io/milvus/pkg/v2/util/funcutil"
+	"github.com/milvus-io/milvus/pkg/v2/util/tsoutil"
+)
+
+type ReconcileWALParam struct {
+	framework.ParamBase `use:"reconcile-wal" desc:"reconcile insert/delete pks in wal against flushed segment binlogs and deltalogs"`
+	WALName             string `name:"wal_name" default:"Pulsar" desc:"wal name to scan"`
+	MQAddr              string `name:"mq_addr" default:"" desc:"mq address, see consumev2"`
+	VChannel            string `name:"vchannel" default:"" desc:"the vchannel to reconcile"`
+	EndTime             string `name:"end_time" default:"" desc:"end of the window, RFC3339 or raw time tick, capped by channel checkpoint"`
+	MinioAddress        string `name:"minioAddr" default:"" desc:"override minio address"`
+	SkipBucketCheck     bool   `name:"skipBucketCheck" default:"false" desc:"skip bucket exist check due to permission issue"`
+	WorkerNum           int64  `name:"workerNum" default:"4" desc:"worker num reading segments"`
+	OutputLimit         int64  `name:"outputLimit" default:"10" desc:"pks shown per category"`
+	WALStartParam
+}
+
+// ReconcileWALCommand collects insert/delete pks of the vchannel from wal within the window and checks them against storage.
+// The window ends at the channel checkpoint, data after it may not be flushed yet.
+func (s *InstanceState) ReconcileWALCommand(ctx context.Context, p *ReconcileWALParam) error {
+	if p.VChannel == "" {
+		return errors.New("vchannel must be provided")
+	}
+	collectionID := collectionIDOfVChannel(p.VChannel)
+	collection, err := common.GetCollectionByIDVersion(ctx, s.client, s.basePath, collectionID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get collection %d of vchannel %s", collectionID, p.VChannel)
+	}
+	pkField, ok := collection.GetPKField()
+	if !ok {
+		return errors.New("pk field not found")
+	}
+
+	checkpoints, err := common.ListChannelCheckpoint(ctx, s.client, s.basePath, func(pos *models.MsgPosition) bool {
+		return pos.GetProto().GetChannelName() == p.VChannel
+	})
+	if err != nil {
+		return err
+	}
+	if len(checkpoints) == 0 {
+		return errors.Newf("channel checkpoint of %s not found", p.VChannel)
+	}
+	endTT := checkpoints[0].GetProto().GetTimestamp()
+	if p.EndTime != "" {
+		tt, err := parseStartTimeTick(p.EndTime)
+		if err != nil {
+			return err
+		}
+		endTT = min(endTT, tt)
+	}
+	fmt.Printf("Reconciling %s of collection %d, window ends at %v (channel checkpoint %v)\n",
+		p.VChannel, collectionID, tsoutil.PhysicalTime(endTT), tsoutil.PhysicalTime(checkpoints[0].GetProto().GetTimestamp()))
+
+	walRecords, err := s.collectWALPKs(ctx, p, endTT)
+	if err != nil {
+		return err
+	}
+	if walRecords.messages == 0 {
+		fmt.Println("No insert/delete message found in wal within the window")
+		return nil
+	}
+	fmt.Printf("WAL: %d inserted rows, %d deleted rows in %d messages, from %v\n",
+		walRecords.insertRows, walRecords.deleteRows, walRecords.messages, tsoutil.PhysicalTime(walRecords.firstTT))
+
+	storageRecords, err := s.collectStoragePKs(ctx, p, collection, pkField)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Storage: %d rows, %d deletes in %d segments\n",
+		storageRecords.rows, storageRecords.deleted, storageRecords.segments)
+
+	if walRecords.lastTT < endTT {
+		fmt.Printf("WAL scan stopped early at %v, reconciling up to it\n", tsoutil.PhysicalTime(walRecords.lastTT))
+	}
+	reconcilePKs(walRecords, storageRecords, walRecords.firstTT, walRecords.lastTT).print(p.OutputLimit)
+	return nil
+}
+
+// pkRecords keeps pk => timestamps of one side, a pk may be written more than once (upsert).
+type pkRecords struct {
+	mut     sync.Mutex
+	inserts map[any][]uint64
+	deletes map[any][]uint64
+
+	// wal side stats
+	messages   int
+	insertRows int
+	deleteRows int
+	firstTT    uint64
+	// time tick of the last message consumed, the wal side is complete up to it
+	lastTT uint64
+
+	// storage side stats
+	segments int
+	rows     int
+	deleted  int
+}
+
+func newPKRecords() *pkRecords {
+	return &pkRecords{
+		inserts: make(map[any][]uint64),
+		deletes: make(map[any][]uint64),
+	}
+}
+
+func (r *pkRecords) addInsert(pk any, ts uint64) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.inserts[pk] = append(r.inserts[pk], ts)
+}
+
+func (r *pkRecords) addDelete(pk any, ts uint64) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.deletes[pk] = append(r.deletes[pk], ts)
+}
+
+// deletedAfter reports whether the pk is deleted at or after ts.
+func (r *pkRecords) deletedAfter(pk any, ts uint64) bool {
+	return lo.ContainsBy(r.deletes[pk], func(dts uint64) bool { return dts >= ts })
+}
+
+func (s *InstanceState) collectWALPKs(ctx context.Context, p *ReconcileWALParam, endTT uint64) (*pkRecords, error) {
+	readOption, err := p.readOption(p.WALName, p.MQAddr, p.VChannel)
+	if err != nil {
+		return nil, err
+	}
+	sigChan := SetupSignalHandling()
+	defer CleanupSignalHandling(sigChan)
+
+	scanner, err := NewWALScannerWithOption(ctx, p.WALName, funcutil.ToPhysicalChannel(p.VChannel), p.MQAddr, readOption)
+	if err != nil {
+		return nil, err
+	}
+	defer scanner.Scanner.Close()
+
+	records := newPKRecords()
+	decoder := s.newMessageDecoder(-1)
+	var collect func(msg message.ImmutableMessage)
+	collect = func(msg message.ImmutableMessage) {
+		var body *decodedBody
+		switch msg.MessageType() {
+		case message.MessageTypeInsert:
+			body = decoder.decodeInsert(ctx, msg)
+			for _, pk := range body.PKs {
+				records.addInsert(pk, msg.TimeTick())
+			}
+			records.insertRows += len(body.PKs)
+		case message.MessageTypeDelete:
+			body = decoder.decodeDelete(msg)
+			for _, pk := range body.PKs {
+				records.addDelete(pk, msg.TimeTick())
+			}
+			records.deleteRows += len(body.PKs)
+		case message.MessageTypeTxn:
+			if txnMsg := message.AsImmutableTxnMessage(msg); txnMsg != nil {
+				txnMsg.RangeOver(func(sub message.ImmutableMessage) error {
+					collect(sub)
+					return nil
+				})
+			}
+			return
+		default:
+			return
+		}
+		if body.Error != "" {
+			fmt.Printf("failed to decode %s message %s: %s\n", msg.MessageType(), msg.MessageID(), body.Error)
+		}
+		if records.firstTT == 0 {
+			records.firstTT = msg.TimeTick()
+		}
+		records.messages++
+	}
+
+	fmt.Println("Scanning wal, press Ctrl+C to stop scanning and reconcile what is collected...")
+	eof := scanner.EOF
+	var drained <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-sigChan:
+			return records, nil
+		case <-eof:
+			eof = nil
+			drained = time.After(offlineDrainTimeout)
+		case <-drained:
+			return records, nil
+		case msg, ok := <-scanner.MessageChan:
+			if !ok {
+				return nil, errors.New("scanner closed")
+			}
+			if drained != nil {
+				drained = time.After(offlineDrainTimeout)
+			}
+			if msg.TimeTick() > endTT {
+				records.lastTT = endTT
+				return records, nil
+			}
+			records.lastTT = max(records.lastTT, msg.TimeTick())
+			if msg.VChannel() != p.VChannel {
+				continue
+			}
+			collect(msg)
+		}
+	}
+}
+
+func (s *InstanceState) collectStoragePKs(ctx context.Context, p *ReconcileWALParam, collection *models.Collection, pkField models.FieldSchema) (*pkRecords, error) {
+	segments, err := common.ListSegments(ctx, s.client, s.basePath, func(segment *models.Segment) bool {
+		return segment.CollectionID == collection.GetProto().GetID() &&
+			segment.InsertChannel == p.VChannel &&
+			segment.State != commonpb.SegmentState_Dropped
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	params := []oss.MinioConnectParam{oss.WithSkipCheckBucket(p.SkipBucketCheck)}
+	if p.MinioAddress != "" {
+		params = append(params, oss.WithMinioAddr(p.MinioAddress))
+	}
+	resolvedStore, err := s.GetObjectStore(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+	getObject := func(binlogPath string) (storagecommon.ReadSeeker, error) {
+		return resolvedStore.Store.Open(ctx, oss.ResolveObjectKey(resolvedStore.RootPath, binlogPath))
+	}
+
+	fields := make(map[int64]*schemapb.FieldSchema)
+	for _, fieldSchema := range collection.GetProto().GetSchema().GetFields() {
+		// timestamp field id
+		if fieldSchema.GetFieldID() == 1 || fieldSchema.GetIsPrimaryKey() {
+			fields[fieldSchema.GetFieldID()] = fieldSchema
+		}
+	}
+
+	records := newPKRecords()
+	records.segments = len(segments)
+	workFn := func(segment *models.Segment) error {
+		// empty logs of a failed load would report every wal insert of the segment as lost
+		if err := segment.LoadBinlogs(); err != nil {
+			return errors.Wrapf(err, "failed to load binlog meta of segment %d", segment.ID)
+		}
+		for _, deltaFieldBinlog := range segment.GetDeltalogs() {
+			for _, deltaBinlog := range deltaFieldBinlog.Binlogs {
+				deltaObj, err := getObject(deltaBinlog.LogPath)
+				if err != nil {
+					return err
+				}
+				reader, err := storage.NewDeltalogReader(deltaObj)
+				if err != nil {
+					return err
+				}
+				for {
+					deltaData, err := reader.NextEventReader(schemapb.DataType(pkField.DataType))
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					if err != nil {
+						return err
+					}
+					deltaData.Range(func(pk storagecommon.PrimaryKey, ts uint64) bool {
+						records.addDelete(pk.GetValue(), ts)
+						return true
+					})
+				}
+			}
+		}
+		if len(segment.GetBinlogs()) == 0 {
+			return nil
+		}
+		iter := storage.NewSegmentIterator(segment,
+			collection.GetProto().GetSchema(),
+			nil,
+			fields,
+			getObject,
+			&pkCollectTask{records: records})
+		if err := iter.Range(ctx); err != nil {
+			return errors.Wrapf(err, "failed to read segment %d", segment.ID)
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var workErr error
+	taskCh := make(chan *models.Segment)
+	for i := 0; i < int(max(p.WorkerNum, 1)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for segment := range taskCh {
+				if err := workFn(segment); err != nil {
+					errOnce.Do(func() { workErr = err })
+				}
+			}
+		}()
+	}
+	for _, segment := range segments {
+		taskCh <- segment
+	}
+	close(taskCh)
+	wg.Wait()
+	if workErr != nil {
+		return nil, workErr
+	}
+	for _, tss := range records.inserts {
+		records.rows += len(tss)
+	}
+	for _, tss := range records.deletes {
+		records.deleted += len(tss)
+	}
+	return records, nil
+}
+
+// pkCollectTask is a storage scan task collecting pk and timestamp of every row.
+type pkCollectTask struct {
+	records *pkRecords
+	counter int64
+}
+
+func (t *pkCollectTask) Scan(pk storagecommon.PrimaryKey, _ *storagecommon.BatchInfo, _ int, values map[int64]any) error {
+	ts, ok := values[1].(int64)
+	if !ok {
+		return errors.Newf("unexpected timestamp value type %T", values[1])
+	}
+	t.records.addInsert(pk.GetValue(), uint64(ts))
+	t.counter++
+	return nil
+}
+
+func (t *pkCollectTask) Counter() int64 { return t.counter }
+
+func (t *pkCollectTask) Summary() {}
+
+type pkDiff struct {
+	pk any
+	ts uint64
+}
+
+type reconcileResult struct {
+	insertNotInStorage []pkDiff // wal insert not found in binlog and not deleted later
+	deleteNotApplied   []pkDiff // wal delete not in deltalog while the row is still in binlog
+	rowNotInWAL        []pkDiff // binlog row within the scanned window without wal insert
+}
+
+// reconcilePKs compares both sides, storage rows are only checked within [fromTT, toTT] which is covered by the wal scan.
+func reconcilePKs(wal, stored *pkRecords, fromTT, toTT uint64) *reconcileResult {
+	result := &reconcileResult{}
+	for pk, tss := range wal.inserts {
+		for _, ts := range tss {
+			if lo.Contains(stored.inserts[pk], ts) {
+				continue
+			}
+			// compaction drops deleted rows
+			if wal.deletedAfter(pk, ts) || stored.deletedAfter(pk, ts) {
+				continue
+			}
+			result.insertNotInStorage = append(result.insertNotInStorage, pkDiff{pk: pk, ts: ts})
+		}
+	}
+	for pk, tss := range wal.deletes {
+		for _, ts := range tss {
+			if lo.Contains(stored.deletes[pk], ts) {
+				continue
+			}
+			alive := lo.ContainsBy(stored.inserts[pk], func(its uint64) bool { return its < ts })
+			if alive && !stored.deletedAfter(pk, ts) {
+				result.deleteNotApplied = append(result.deleteNotApplied, pkDiff{pk: pk, ts: ts})
+			}
+		}
+	}
+	for pk, tss := range stored.inserts {
+		for _, ts := range tss {
+			if ts < fromTT || ts > toTT {
+				continue
+			}
+			if !lo.Contains(wal.inserts[pk], ts) {
+				result.rowNotInWAL = append(result.rowNotInWAL, pkDiff{pk: pk, ts: ts})
+			}
+		}
+	}
+	for _, diffs := range [][]pkDiff{result.insertNotInStorage, result.deleteNotApplied, result.rowNotInWAL} {
+		sort.Slice(diffs, func(i, j int) bool { return diffs[i].ts < diffs[j].ts })
+	}
+	return result
+}
+
+func (r *reconcileResult) print(limit int64) {
+	printDiffs := func(title string, diffs []pkDiff) {
+		if len(diffs) == 0 {
+			fmt.Printf("✅ %s: 0\n", title)
+			return
+		}
+		fmt.Printf("❌ %s: %d\n", title, len(diffs))
+		for i, diff := range diffs {
+			if int64(i) >= limit {
+				fmt.Printf("    ... %d more\n", len(diffs)-i)
+				break
+			}
+			fmt.Printf("    pk %v ts %d (%v)\n", diff.pk, diff.ts, tsoutil.PhysicalTime(diff.ts))
+		}
+	}
+	fmt.Println("=== Reconcile Result ===")
+	printDiffs("Inserted in WAL but absent from binlogs", r.insertNotInStorage)
+	printDiffs("Deleted in WAL but not applied in deltalogs", r.deleteNotApplied)
+	printDiffs("Rows in binlogs but absent from WAL (bulk import or WAL trimmed)", r.rowNotInWAL)
+}
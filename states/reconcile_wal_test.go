@@ -0,0 +1,31 @@
+package states
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcilePKs(t *testing.T) {
+	wal := newPKRecords()
+	wal.addInsert(int64(1), 100) // flushed
+	wal.addInsert(int64(2), 101) // lost
+	wal.addInsert(int64(3), 102) // deleted later and compacted
+	wal.addDelete(int64(3), 110)
+	wal.addDelete(int64(4), 111) // delete not applied
+
+	stored := newPKRecords()
+	stored.addInsert(int64(1), 100)
+	stored.addInsert(int64(4), 90)  // before the wal window
+	stored.addInsert(int64(5), 105) // not in wal
+	stored.addInsert(int64(6), 200) // after the window
+
+	result := reconcilePKs(wal, stored, 100, 150)
+	assert.Equal(t, []pkDiff{{pk: int64(2), ts: 101}}, result.insertNotInStorage)
+	assert.Equal(t, []pkDiff{{pk: int64(4), ts: 111}}, result.deleteNotApplied)
+	assert.Equal(t, []pkDiff{{pk: int64(5), ts: 105}}, result.rowNotInWAL)
+
+	stored.addDelete(int64(4), 111)
+	result = reconcilePKs(wal, stored, 100, 150)
+	assert.Empty(t, result.deleteNotApplied)
+}
@@ -0,0 +1,359 @@
+package states
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/samber/lo"
+
+	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/states/etcd/common"
+	"github.com/milvus-io/milvus/pkg/v2/proto/streamingpb"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
+	"github.com/milvus-io/milvus/pkg/v2/util/tsoutil"
+)
+
+type ReplicateStatusParam struct {
+	framework.ParamBase `use:"replicate status" desc:"report replication lag and missing ranges per pchannel between source and target wal"`
+	SourceWALName       string   `name:"source_wal_name" default:"Pulsar" desc:"wal name of the source cluster"`
+	SourceMQAddr        string   `name:"source_mq_addr" default:"" desc:"mq address of the source cluster, see consumev2"`
+	TargetWALName       string   `name:"target_wal_name" default:"Pulsar" desc:"wal name of the target cluster"`
+	TargetMQAddr        string   `name:"target_mq_addr" default:"" desc:"mq address of the target cluster, see consumev2"`
+	TargetCluster       string   `name:"target_cluster" default:"" desc:"target cluster id, required if more than one target is configured"`
+	PChannels           []string `name:"pchannels" default:"" desc:"source pchannels to check, all replicating pchannels if not set"`
+	Duration            string   `name:"duration" default:"10s" desc:"how long to observe live wals"`
+	MaxRanges           int64    `name:"max_ranges" default:"10" desc:"missing ranges shown per pchannel"`
+	// lag is measured between the scan cursors, which only reflects replication lag when both start at the heads.
+	Start     string `name:"start" default:"latest" desc:"start position: latest, earliest or timestamp, lag is only accurate with latest"`
+	StartTime string `name:"start_time" default:"" desc:"start time when start=timestamp, RFC3339 or raw time tick"`
+}
+
+// replicateChannelStatus tracks one source pchannel and its replicating target pchannel.
+type replicateChannelStatus struct {
+	meta    *streamingpb.ReplicatePChannelMeta
+	aligner *pchannelAligner
+
+	// messages with replicate time tick below lowerBound are not compared,
+	// for latest start it's decided once both sides delivered the first message.
+	lowerBound  uint64
+	waitFirst   bool
+	sourceFirst uint64
+	targetFirst uint64
+	sourceBuf   []message.ImmutableMessage
+	targetBuf   []message.ImmutableMessage
+
+	sourceCount    int
+	targetCount    int
+	sourceLast     message.ImmutableMessage
+	replicatedLast message.ImmutableMessage
+}
+
+func (st *replicateChannelStatus) onSource(msg message.ImmutableMessage) {
+	st.sourceCount++
+	// self controlled messages like time tick are not replicated, the lag would grow on an idle channel otherwise
+	if !msg.MessageType().IsSelfControlled() {
+		st.sourceLast = msg
+	}
+	if st.waitFirst {
+		if st.sourceFirst == 0 {
+			st.sourceFirst = msg.TimeTick()
+		}
+		st.sourceBuf = append(st.sourceBuf, msg)
+		st.tryStart()
+		return
+	}
+	st.pushSource(msg)
+}
+
+func (st *replicateChannelStatus) onTarget(msg message.ImmutableMessage) {
+	st.targetCount++
+	h := msg.ReplicateHeader()
+	if h != nil {
+		st.replicatedLast = msg
+	}
+	if st.waitFirst {
+		if st.targetFirst == 0 && h != nil {
+			st.targetFirst = h.TimeTick
+		}
+		st.targetBuf = append(st.targetBuf, msg)
+		st.tryStart()
+		return
+	}
+	st.pushTarget(msg)
+}
+
+func (st *replicateChannelStatus) tryStart() {
+	if st.sourceFirst == 0 || st.targetFirst == 0 {
+		return
+	}
+	st.waitFirst = false
+	st.lowerBound = max(st.lowerBound, st.sourceFirst, st.targetFirst)
+	for _, msg := range st.sourceBuf {
+		st.pushSource(msg)
+	}
+	for _, msg := range st.targetBuf {
+		st.pushTarget(msg)
+	}
+	st.sourceBuf, st.targetBuf = nil, nil
+}
+
+func (st *replicateChannelStatus) pushSource(msg message.ImmutableMessage) {
+	if msg.MessageType().IsSelfControlled() || msg.TimeTick() < st.lowerBound {
+		return
+	}
+	st.aligner.pushReference(msg)
+}
+
+func (st *replicateChannelStatus) pushTarget(msg message.ImmutableMessage) {
+	if msg.MessageType().IsSelfControlled() {
+		return
+	}
+	if h := msg.ReplicateHeader(); h != nil && h.TimeTick < st.lowerBound {
+		return
+	}
+	st.aligner.pushCompared(msg)
+}
+
+// lag returns the time tick lag between source head and the last replicated message, false if unknown.
+func (st *replicateChannelStatus) lag() (time.Duration, bool) {
+	if st.sourceLast == nil || st.replicatedLast == nil {
+		return 0, false
+	}
+	sourceTT := st.sourceLast.TimeTick()
+	replicatedTT := st.replicatedLast.ReplicateHeader().TimeTick
+	if replicatedTT >= sourceTT {
+		return 0, true
+	}
+	return tsoutil.PhysicalTime(sourceTT).Sub(tsoutil.PhysicalTime(replicatedTT)), true
+}
+
+// ReplicateStatusCommand reads source and target wals of every replicating pchannel at the same time,
+// aligns replicated messages by replicate header and reports lag and missing ranges.
+func (s *InstanceState) ReplicateStatusCommand(ctx context.Context, p *ReplicateStatusParam) error {
+	duration, err := time.ParseDuration(p.Duration)
+	if err != nil {
+		return errors.Wrapf(err, "invalid duration %s", p.Duration)
+	}
+	if strings.ToLower(p.Start) == "msgid" {
+		return errors.New("message ids differ between clusters, use start=timestamp to scan a window")
+	}
+	startParam := &WALStartParam{Start: p.Start, StartTime: p.StartTime}
+	if strings.ToLower(p.Start) != "latest" {
+		fmt.Println("Warning: scanning from history, time tick lag is measured between scan cursors instead of wal heads")
+	}
+
+	cfg, err := common.ListReplicateConfiguration(ctx, s.client, s.basePath)
+	if err != nil {
+		return err
+	}
+	metas, err := common.ListReplicatePChannel(ctx, s.client, s.basePath)
+	if err != nil {
+		return err
+	}
+
+	targets := make(map[string]struct{})
+	for _, meta := range metas {
+		targets[meta.GetTargetCluster().GetClusterId()] = struct{}{}
+	}
+	targetCluster := p.TargetCluster
+	if targetCluster == "" {
+		if len(targets) != 1 {
+			return errors.Newf("%d target clusters found, please specify one with --target_cluster", len(targets))
+		}
+		for id := range targets {
+			targetCluster = id
+		}
+	}
+	inConfig := false
+	for _, cluster := range cfg.GetReplicateConfiguration().GetClusters() {
+		if cluster.GetClusterId() == targetCluster {
+			inConfig = true
+		}
+	}
+	if !inConfig {
+		fmt.Printf("Warning: target cluster %s is not in current replicate configuration\n", targetCluster)
+	}
+
+	var startTT uint64
+	if strings.ToLower(p.Start) == "timestamp" {
+		startTT, err = parseStartTimeTick(p.StartTime)
+		if err != nil {
+			return err
+		}
+	}
+
+	pchannels := lo.Compact(p.PChannels)
+	var statuses []*replicateChannelStatus
+	for _, meta := range metas {
+		if meta.GetTargetCluster().GetClusterId() != targetCluster {
+			continue
+		}
+		if len(pchannels) > 0 && !lo.Contains(pchannels, meta.GetSourceChannelName()) {
+			continue
+		}
+		statuses = append(statuses, &replicateChannelStatus{
+			meta:       meta,
+			aligner:    newPChannelAligner(meta.GetTargetChannelName(), int(p.MaxRanges)),
+			lowerBound: max(meta.GetInitializedCheckpoint().GetTimeTick(), startTT),
+			waitFirst:  strings.ToLower(p.Start) == "latest",
+		})
+	}
+	if len(statuses) == 0 {
+		fmt.Printf("no replicating pchannel found for target cluster %s\n", targetCluster)
+		return nil
+	}
+
+	sigChan := SetupSignalHandling()
+	defer CleanupSignalHandling(sigChan)
+
+	// scanners are laid out as source, target of each status.
+	scanners := make([]*WALScanner, 0, len(statuses)*2)
+	for _, st := range statuses {
+		sourceOption, err := startParam.readOption(p.SourceWALName, p.SourceMQAddr, "")
+		if err != nil {
+			return err
+		}
+		source, err := NewWALScannerWithOption(ctx, p.SourceWALName, st.meta.GetSourceChannelName(), p.SourceMQAddr, sourceOption)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create scanner for source pchannel %s", st.meta.GetSourceChannelName())
+		}
+		defer source.Scanner.Close()
+		targetOption, err := startParam.readOption(p.TargetWALName, p.TargetMQAddr, "")
+		if err != nil {
+			return err
+		}
+		target, err := NewWALScannerWithOption(ctx, p.TargetWALName, st.meta.GetTargetChannelName(), p.TargetMQAddr, targetOption)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create scanner for target pchannel %s", st.meta.GetTargetChannelName())
+		}
+		defer target.Scanner.Close()
+		scanners = append(scanners, source, target)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type indexedMessage struct {
+		index int
+		msg   message.ImmutableMessage
+	}
+	merged := make(chan indexedMessage)
+	for i, scanner := range scanners {
+		go func(i int, ch <-chan message.ImmutableMessage) {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg, ok := <-ch:
+					if !ok {
+						return
+					}
+					select {
+					case merged <- indexedMessage{index: i, msg: msg}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}(i, scanner.MessageChan)
+	}
+
+	fmt.Printf("Observing %d pchannels replicating to %s for %s, press Ctrl+C to stop earlier...\n", len(statuses), targetCluster, duration)
+	deadline := time.After(duration)
+	lastProgress := time.Now()
+	ticker := time.NewTicker(offlineDrainTimeout / 2)
+	defer ticker.Stop()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sigChan:
+			break loop
+		case <-deadline:
+			break loop
+		case <-ticker.C:
+			if allExhausted(scanners) && time.Since(lastProgress) > offlineDrainTimeout {
+				break loop
+			}
+		case im := <-merged:
+			lastProgress = time.Now()
+			st := statuses[im.index/2]
+			if im.index%2 == 0 {
+				st.onSource(im.msg)
+			} else {
+				st.onTarget(im.msg)
+			}
+		}
+	}
+
+	for _, st := range statuses {
+		st.aligner.finish()
+	}
+	printReplicateStatus(targetCluster, statuses)
+	return nil
+}
+
+func printReplicateStatus(targetCluster string, statuses []*replicateChannelStatus) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(fmt.Sprintf("Replicate Status to %s", targetCluster))
+	t.AppendHeader(table.Row{"SourcePChannel", "TargetPChannel", "Source/Target Msgs", "SourceLastMsgID", "SourceLastTime",
+		"LastReplicatedMsgID", "LastReplicatedTime", "TimeTickLag", "WallClockLag", "Matched", "Missing", "Extra", "Pending"})
+	for _, st := range statuses {
+		sourceID, sourceTime := "-", "-"
+		if st.sourceLast != nil {
+			sourceID = st.sourceLast.MessageID().String()
+			sourceTime = tsoutil.PhysicalTime(st.sourceLast.TimeTick()).Format(time.RFC3339Nano)
+		}
+		replicatedID, replicatedTime, wallLag := "-", "-", "-"
+		if st.replicatedLast != nil {
+			h := st.replicatedLast.ReplicateHeader()
+			replicatedID = h.MessageID.String()
+			replicatedTime = tsoutil.PhysicalTime(h.TimeTick).Format(time.RFC3339Nano)
+			wallLag = time.Since(tsoutil.PhysicalTime(h.TimeTick)).Truncate(time.Millisecond).String()
+		}
+		ttLag := "-"
+		if lag, ok := st.lag(); ok {
+			ttLag = lag.String()
+		}
+		total := map[string]int{}
+		for key, cnt := range st.aligner.counts {
+			total[key.category] += cnt
+		}
+		t.AppendRow(table.Row{
+			st.meta.GetSourceChannelName(),
+			st.meta.GetTargetChannelName(),
+			fmt.Sprintf("%d/%d", st.sourceCount, st.targetCount),
+			sourceID, sourceTime,
+			replicatedID, replicatedTime,
+			ttLag, wallLag,
+			st.aligner.matched,
+			total[divergenceMissing],
+			total[divergenceExtra],
+			fmt.Sprintf("%d/%d", st.aligner.pendingRef, st.aligner.pendingCmp),
+		})
+	}
+	t.Render()
+
+	for _, st := range statuses {
+		ranges := st.aligner.ranges
+		if len(ranges) == 0 {
+			continue
+		}
+		fmt.Printf("\n❌ %s -> %s missing %d ranges:\n", st.meta.GetSourceChannelName(), st.meta.GetTargetChannelName(), len(ranges))
+		for i, r := range ranges {
+			if i >= st.aligner.maxDiffs {
+				fmt.Printf("    ... %d more\n", len(ranges)-i)
+				break
+			}
+			fmt.Printf("    %d messages, MessageID %s - %s, Time %v - %v\n", r.count,
+				r.from.msg.MessageID(), r.to.msg.MessageID(),
+				tsoutil.PhysicalTime(r.from.timeTick), tsoutil.PhysicalTime(r.to.timeTick))
+		}
+	}
+}
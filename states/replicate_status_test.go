@@ -0,0 +1,75 @@
+package states
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
+	"github.com/milvus-io/milvus/pkg/v2/util/tsoutil"
+)
+
+type testMessageID struct {
+	message.MessageID
+	id string
+}
+
+func (id testMessageID) String() string { return id.id }
+
+// testReplicateMessage implements the message methods used by replicate status, others are not expected to be called.
+type testReplicateMessage struct {
+	message.ImmutableMessage
+	id       string
+	msgType  message.MessageType
+	timeTick uint64
+	header   *message.ReplicateHeader
+}
+
+func (m *testReplicateMessage) MessageID() message.MessageID              { return testMessageID{id: m.id} }
+func (m *testReplicateMessage) MessageType() message.MessageType          { return m.msgType }
+func (m *testReplicateMessage) TimeTick() uint64                          { return m.timeTick }
+func (m *testReplicateMessage) VChannel() string                          { return "v0" }
+func (m *testReplicateMessage) ReplicateHeader() *message.ReplicateHeader { return m.header }
+
+func TestReplicateChannelStatus(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tt := func(d time.Duration) uint64 { return tsoutil.ComposeTSByTime(start.Add(d), 0) }
+	source := func(id string, msgType message.MessageType, timeTick uint64) *testReplicateMessage {
+		return &testReplicateMessage{id: id, msgType: msgType, timeTick: timeTick}
+	}
+	replicated := func(src *testReplicateMessage, timeTick uint64) *testReplicateMessage {
+		return &testReplicateMessage{
+			id: "target-" + src.id, msgType: src.msgType, timeTick: timeTick,
+			header: &message.ReplicateHeader{MessageID: src.MessageID(), TimeTick: src.timeTick, VChannel: "v0"},
+		}
+	}
+
+	st := &replicateChannelStatus{aligner: newPChannelAligner("target", 10), waitFirst: true}
+	_, ok := st.lag()
+	assert.False(t, ok)
+
+	insert := source("1", message.MessageTypeInsert, tt(0))
+	st.onSource(insert)
+	st.onTarget(replicated(insert, tt(time.Second)))
+	assert.False(t, st.waitFirst)
+
+	// idle source only appends time ticks, replication is caught up
+	for i := 1; i <= 10; i++ {
+		st.onSource(source("tt", message.MessageTypeTimeTick, tt(time.Duration(i)*time.Minute)))
+	}
+	lag, ok := st.lag()
+	require.True(t, ok)
+	assert.Zero(t, lag)
+	assert.Equal(t, 11, st.sourceCount)
+
+	// a new insert not replicated yet
+	st.onSource(source("2", message.MessageTypeInsert, tt(11*time.Minute)))
+	lag, ok = st.lag()
+	require.True(t, ok)
+	assert.Equal(t, 11*time.Minute, lag)
+
+	st.aligner.finish()
+	assert.Equal(t, 1, st.aligner.matched)
+}
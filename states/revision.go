@@ -0,0 +1,179 @@
+package states
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/internal/protodiff"
+	"github.com/milvus-io/birdwatcher/states/etcd/common"
+)
+
+type SetRevisionParam struct {
+	framework.ParamBase `use:"set revision" desc:"read meta at a past etcd revision for all commands, within compaction retention"`
+	Revision            int64  `name:"revision" default:"0" desc:"etcd revision to read at"`
+	At                  string `name:"at" default:"" desc:"read at the last revision before the time, RFC3339 or 2006-01-02 15:04:05 in local time"`
+	Latest              bool   `name:"latest" default:"false" desc:"back to read latest revision"`
+}
+
+// SetRevisionCommand pins the revision of the instance meta kv, writes are rejected until back to latest.
+func (s *InstanceState) SetRevisionCommand(ctx context.Context, p *SetRevisionParam) error {
+	var revision int64
+	switch {
+	case p.Latest:
+	case p.Revision > 0:
+		revision = p.Revision
+	case p.At != "":
+		t, err := parseLocalTime(p.At)
+		if err != nil {
+			return err
+		}
+		// rootcoord tso allocator saves its time window under `{rootPath}/kv/gid/timestamp`.
+		revision, err = s.revisionKV.RevisionAt(ctx, path.Join(s.instanceName, "kv", "gid", "timestamp"), t)
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.New("one of --revision, --at or --latest must be provided")
+	}
+
+	if err := s.revisionKV.SetRevision(ctx, revision); err != nil {
+		return err
+	}
+	if revision == 0 {
+		s.SetLabel(fmt.Sprintf("Milvus(%s)", s.instanceName))
+		fmt.Println("Reading latest revision")
+		return nil
+	}
+	s.SetLabel(fmt.Sprintf("Milvus(%s)@%d", s.instanceName, revision))
+	fmt.Printf("Reading meta at revision %d, meta is read-only until `set revision --latest`\n", revision)
+	return nil
+}
+
+type ShowRevisionParam struct {
+	framework.ParamBase `use:"show revision" desc:"display pinned and current etcd revision"`
+}
+
+func (s *InstanceState) ShowRevisionCommand(ctx context.Context, p *ShowRevisionParam) error {
+	current, err := s.revisionKV.CurrentRevision(ctx)
+	if err != nil {
+		return err
+	}
+	if pinned := s.revisionKV.Revision(); pinned > 0 {
+		fmt.Printf("Pinned revision: %d\n", pinned)
+	} else {
+		fmt.Println("Pinned revision: latest")
+	}
+	fmt.Printf("Current revision: %d\n", current)
+	return nil
+}
+
+type HistoryKeyParam struct {
+	framework.ParamBase `use:"history key" desc:"list all retained revisions of a meta key with decoded diffs"`
+	Limit               int64 `name:"limit" default:"0" desc:"only show the latest n revisions, 0 for all"`
+	key                 string
+}
+
+func (p *HistoryKeyParam) ParseArgs(args []string) error {
+	if len(args) != 1 {
+		return errors.New("history key requires exactly one key argument")
+	}
+	p.key = args[0]
+	return nil
+}
+
+// HistoryKeyCommand replays the key from the oldest retained revision.
+// The key is relative to meta path, e.g. `datacoord-meta/s/1/2/3`, full keys are accepted as well.
+func (s *InstanceState) HistoryKeyCommand(ctx context.Context, p *HistoryKeyParam) error {
+	relKey := strings.TrimPrefix(strings.TrimPrefix(p.key, s.basePath), "/")
+	fullKey := path.Join(s.basePath, relKey)
+
+	history, compactRevision, err := s.revisionKV.History(ctx, fullKey)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		fmt.Printf("no retained revision found for %s\n", fullKey)
+		return nil
+	}
+	if compactRevision > 0 {
+		fmt.Printf("history before revision %d is compacted\n", compactRevision)
+	}
+
+	decoder, known := common.LookupMetaDecoder(relKey)
+	if known {
+		fmt.Printf("Key %s, category %s, %d revisions\n", fullKey, decoder.Category, len(history))
+	} else {
+		fmt.Printf("Key %s, unknown meta type, %d revisions\n", fullKey, len(history))
+	}
+
+	skip := 0
+	if p.Limit > 0 && int(p.Limit) < len(history) {
+		skip = len(history) - int(p.Limit)
+	}
+	var prev []byte
+	prevDeleted := true
+	for i, item := range history {
+		if i >= skip {
+			fmt.Printf("\n=== Revision %d ===\n", item.Revision)
+			switch {
+			case item.Deleted:
+				fmt.Println("key deleted")
+			case prevDeleted:
+				fmt.Println("key created")
+				printKeyValue(relKey, item.Value)
+			default:
+				printKeyDiff(relKey, prev, item.Value)
+			}
+		}
+		prev, prevDeleted = item.Value, item.Deleted
+	}
+	return nil
+}
+
+func printKeyValue(relKey string, value []byte) {
+	msg, ok := common.DecodeMetaValue(relKey, value)
+	if !ok || msg == nil {
+		fmt.Printf("%q\n", value)
+		return
+	}
+	for _, change := range protodiff.Diff(nil, msg) {
+		fmt.Println(change.New)
+	}
+}
+
+func printKeyDiff(relKey string, prev, value []byte) {
+	oldMsg, oldOK := common.DecodeMetaValue(relKey, prev)
+	newMsg, newOK := common.DecodeMetaValue(relKey, value)
+	if !oldOK || !newOK || oldMsg == nil || newMsg == nil {
+		if string(prev) == string(value) {
+			fmt.Println("value unchanged")
+			return
+		}
+		fmt.Printf("- %q\n+ %q\n", prev, value)
+		return
+	}
+	changes := protodiff.Diff(oldMsg, newMsg)
+	if len(changes) == 0 {
+		fmt.Println("value unchanged")
+	}
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+}
+
+func parseLocalTime(input string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, input); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", input, time.Local)
+	if err != nil {
+		return time.Time{}, errors.Newf("invalid time %s, expect RFC3339 or 2006-01-02 15:04:05", input)
+	}
+	return t, nil
+}
@@ -8,10 +8,12 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/cockroachdb/errors"
 
 	"github.com/milvus-io/birdwatcher/wal/adaptor"
+	"github.com/milvus-io/birdwatcher/wal/offline"
 	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	"github.com/milvus-io/milvus/pkg/v2/proto/messagespb"
 	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
@@ -24,17 +26,60 @@ import (
 	"github.com/milvus-io/milvus/pkg/v2/util/tsoutil"
 )
 
-const messageCipherHeader = "_ch"
+const (
+	messageCipherHeader = "_ch"
+
+	// offlineWALName is the wal name of dump files captured by `consumev2 --dump`.
+	offlineWALName = "offline"
+	// offlineDrainTimeout is how long to wait for in-flight messages after an offline wal reached the end of its dump.
+	offlineDrainTimeout = time.Second
+)
 
 // WALScanner represents a scanner for a single pchannel
 type WALScanner struct {
 	ChannelName string
 	Scanner     adaptor.Scanner
 	MessageChan <-chan message.ImmutableMessage
+	// EOF is closed when an offline wal reached the end of its dump, nil for live wals.
+	EOF <-chan struct{}
 }
 
-// NewWALScanner creates a new WAL scanner for a given pchannel
+// NewWALScanner creates a new WAL scanner for a given pchannel, delivering all messages from the earliest position.
 func NewWALScanner(ctx context.Context, walName, topic string, mqAddr string) (*WALScanner, error) {
+	return NewWALScannerWithOption(ctx, walName, topic, mqAddr, adaptor.ReadOption{
+		DeliverPolicy: options.DeliverPolicyAll(),
+	})
+}
+
+// NewWALScannerWithOption creates a new WAL scanner for a given pchannel with the provided deliver policy and filters.
+// wal name `offline` replays a dump file captured by NewWALDumpScanner, mqAddr is the dump file path then.
+func NewWALScannerWithOption(ctx context.Context, walName, topic string, mqAddr string, readOption adaptor.ReadOption) (*WALScanner, error) {
+	return newWALScanner(ctx, walName, topic, mqAddr, readOption, "")
+}
+
+// NewWALDumpScanner works like NewWALScannerWithOption and also persists every raw message read from the WAL into dumpFile.
+func NewWALDumpScanner(ctx context.Context, walName, topic string, mqAddr string, readOption adaptor.ReadOption, dumpFile string) (*WALScanner, error) {
+	return newWALScanner(ctx, walName, topic, mqAddr, readOption, dumpFile)
+}
+
+func newWALScanner(ctx context.Context, walName, topic string, mqAddr string, readOption adaptor.ReadOption, dumpFile string) (*WALScanner, error) {
+	if strings.EqualFold(walName, offlineWALName) {
+		if dumpFile != "" {
+			return nil, errors.New("cannot dump an offline wal, copy the dump file instead")
+		}
+		wal, err := offline.Open(mqAddr, topic)
+		if err != nil {
+			return nil, err
+		}
+		scanner := adaptor.NewScanner(wal, readOption)
+		return &WALScanner{
+			ChannelName: topic,
+			Scanner:     scanner,
+			MessageChan: scanner.Chan(),
+			EOF:         wal.EOF(),
+		}, nil
+	}
+
 	mqIP := mqAddr
 	mqPort := ""
 	if host, port, err := net.SplitHostPort(mqAddr); err == nil {
@@ -43,9 +88,11 @@ func NewWALScanner(ctx context.Context, walName, topic string, mqAddr string) (*
 	}
 
 	var walNameEnum message.WALName
+	var walNameProto commonpb.WALName
 	switch walName {
 	case commonpb.WALName_Pulsar.String(), strings.ToLower(commonpb.WALName_Pulsar.String()):
 		walNameEnum = message.WALNamePulsar
+		walNameProto = commonpb.WALName_Pulsar
 		if mqIP != "" {
 			paramtable.Get().Save(paramtable.Get().PulsarCfg.Address.Key, mqIP)
 			defer paramtable.Get().Reset(paramtable.Get().PulsarCfg.Address.Key)
@@ -56,6 +103,7 @@ func NewWALScanner(ctx context.Context, walName, topic string, mqAddr string) (*
 		}
 	case commonpb.WALName_Kafka.String(), strings.ToLower(commonpb.WALName_Kafka.String()):
 		walNameEnum = message.WALNameKafka
+		walNameProto = commonpb.WALName_Kafka
 		// port is not set, use default port 9092
 		if mqAddr != "" && mqPort == "" {
 			addr := fmt.Sprintf("%s:%d", mqAddr, 9092)
@@ -68,9 +116,21 @@ func NewWALScanner(ctx context.Context, walName, topic string, mqAddr string) (*
 			defer paramtable.Get().Reset(paramtable.Get().KafkaCfg.Address.Key)
 		}
 	case commonpb.WALName_RocksMQ.String(), strings.ToLower(commonpb.WALName_RocksMQ.String()):
-		return nil, errors.Newf("rocksmq is not supported yet")
+		// mqAddr is the copied rocksmq data directory
+		walNameEnum = message.WALNameRocksmq
+		walNameProto = commonpb.WALName_RocksMQ
+		if err := setupRocksMQ(mqAddr); err != nil {
+			return nil, err
+		}
 	case commonpb.WALName_WoodPecker.String(), strings.ToLower(commonpb.WALName_WoodPecker.String()):
-		return nil, errors.Newf("woodpecker is not supported yet")
+		// mqAddr is the woodpecker segment file location, local directory or object storage
+		walNameEnum = message.WALNameWoodpecker
+		walNameProto = commonpb.WALName_WoodPecker
+		reset, err := setupWoodpecker(mqAddr)
+		if err != nil {
+			return nil, err
+		}
+		defer reset()
 	default:
 		return nil, errors.Newf("invalid wal name: %s", walName)
 	}
@@ -91,10 +151,17 @@ func NewWALScanner(ctx context.Context, walName, topic string, mqAddr string) (*
 		return nil, err
 	}
 
-	readOption := adaptor.ReadOption{
-		DeliverPolicy: options.DeliverPolicyAll(),
+	var roWAL walimpls.ROWALImpls = wal
+	if dumpFile != "" {
+		dumpingWAL, err := offline.NewDumpingWAL(wal, walNameProto.String(), dumpFile)
+		if err != nil {
+			wal.Close()
+			return nil, err
+		}
+		roWAL = dumpingWAL
 	}
-	scanner := adaptor.NewScanner(wal, readOption)
+
+	scanner := adaptor.NewScanner(roWAL, readOption)
 	msgChan := scanner.Chan()
 
 	return &WALScanner{
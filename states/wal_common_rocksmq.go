@@ -0,0 +1,56 @@
+//go:build WROCKSMQ
+// +build WROCKSMQ
+
+package states
+
+import (
+	"os"
+	"path"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/milvus-io/milvus/pkg/v2/mq/mqimpl/rocksmq/server"
+	_ "github.com/milvus-io/milvus/pkg/v2/streaming/walimpls/impls/rmq"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+)
+
+var (
+	rocksmqMut  sync.Mutex
+	rocksmqPath string
+)
+
+// setupRocksMQ starts the embedded rocksmq server on a copied local data directory.
+// The rocksmq server is a process wide singleton, so only one data directory can be opened per birdwatcher process.
+// Never point it to the directory of a running milvus, rocksdb takes the lock and writes consumer info into it.
+func setupRocksMQ(dataPath string) error {
+	if dataPath == "" {
+		return errors.New("rocksmq data directory must be provided as mq address")
+	}
+	dataPath = path.Clean(dataPath)
+	rocksmqMut.Lock()
+	defer rocksmqMut.Unlock()
+	if rocksmqPath != "" {
+		if rocksmqPath != dataPath {
+			return errors.Newf("rocksmq is already opened on %s, restart birdwatcher to open %s", rocksmqPath, dataPath)
+		}
+		return nil
+	}
+	info, err := os.Stat(dataPath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return errors.Newf("rocksmq data path %s is not a directory", dataPath)
+	}
+
+	// disable retention, otherwise the copied data may be trimmed while reading.
+	params := paramtable.Get()
+	params.Save(params.RocksmqCfg.RetentionTimeInMinutes.Key, "-1")
+	params.Save(params.RocksmqCfg.RetentionSizeInMB.Key, "-1")
+	if err := server.InitRocksMQ(dataPath); err != nil {
+		return errors.Wrapf(err, "failed to open rocksmq on %s", dataPath)
+	}
+	rocksmqPath = dataPath
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !WROCKSMQ
+// +build !WROCKSMQ
+
+package states
+
+import (
+	"github.com/cockroachdb/errors"
+)
+
+// setupRocksMQ needs cgo rocksdb, build birdwatcher with `make birdwatcher_wrocksmq` to read rocksmq data.
+func setupRocksMQ(dataPath string) error {
+	return errors.New("rocksmq is not compiled in, rebuild birdwatcher with -tags WROCKSMQ")
+}
@@ -0,0 +1,109 @@
+package states
+
+import (
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	_ "github.com/milvus-io/milvus/pkg/v2/streaming/walimpls/impls/wp"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+)
+
+// woodpeckerAddr is the parsed mq address of woodpecker wal.
+type woodpeckerAddr struct {
+	StorageType string // local or minio
+	RootPath    string
+	Address     string
+	Port        string
+	Bucket      string
+	AccessKey   string
+	SecretKey   string
+	UseSSL      bool
+	Etcd        string
+}
+
+// parseWoodpeckerAddr parses the woodpecker segment file location, in forms of
+//
+//	/path/to/woodpecker or file:///path/to/woodpecker for local storage
+//	minio://ak:sk@host:9000/bucket/root/path or s3://... for object storage
+//
+// an optional `etcd=host:port` query points to the etcd keeping woodpecker log meta, `ssl=true` enables tls for object storage.
+func parseWoodpeckerAddr(addr string) (*woodpeckerAddr, error) {
+	if addr == "" {
+		return nil, errors.New("woodpecker storage location must be provided as mq address")
+	}
+	if !strings.Contains(addr, "://") {
+		return &woodpeckerAddr{StorageType: "local", RootPath: path.Clean(addr)}, nil
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid woodpecker address %s", addr)
+	}
+	result := &woodpeckerAddr{
+		Etcd:   u.Query().Get("etcd"),
+		UseSSL: u.Query().Get("ssl") == "true",
+	}
+	switch u.Scheme {
+	case "file":
+		result.StorageType = "local"
+		result.RootPath = path.Clean(u.Path)
+	case "minio", "s3":
+		result.StorageType = "minio"
+		host, port, err := net.SplitHostPort(u.Host)
+		if err != nil {
+			host, port = u.Host, "9000"
+		}
+		result.Address, result.Port = host, port
+		bucket, rootPath, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+		if bucket == "" {
+			return nil, errors.Newf("bucket missing in woodpecker address %s", addr)
+		}
+		result.Bucket, result.RootPath = bucket, rootPath
+		if u.User != nil {
+			result.AccessKey = u.User.Username()
+			result.SecretKey, _ = u.User.Password()
+		}
+	default:
+		return nil, errors.Newf("unsupported woodpecker storage scheme %s", u.Scheme)
+	}
+	return result, nil
+}
+
+// setupWoodpecker saves the woodpecker storage config into paramtable, the returned function resets them.
+func setupWoodpecker(mqAddr string) (func(), error) {
+	addr, err := parseWoodpeckerAddr(mqAddr)
+	if err != nil {
+		return nil, err
+	}
+	params := paramtable.Get()
+	var keys []string
+	save := func(key, value string) {
+		if value == "" {
+			return
+		}
+		params.Save(key, value)
+		keys = append(keys, key)
+	}
+	save(params.WoodpeckerCfg.StorageType.Key, addr.StorageType)
+	save(params.WoodpeckerCfg.RootPath.Key, addr.RootPath)
+	if addr.StorageType == "minio" {
+		save(params.MinioCfg.Address.Key, addr.Address)
+		save(params.MinioCfg.Port.Key, addr.Port)
+		save(params.MinioCfg.BucketName.Key, addr.Bucket)
+		save(params.MinioCfg.RootPath.Key, addr.RootPath)
+		save(params.MinioCfg.AccessKeyID.Key, addr.AccessKey)
+		save(params.MinioCfg.SecretAccessKey.Key, addr.SecretKey)
+		if addr.UseSSL {
+			save(params.MinioCfg.UseSSL.Key, "true")
+		}
+	}
+	save(params.EtcdCfg.Endpoints.Key, addr.Etcd)
+	return func() {
+		for _, key := range keys {
+			params.Reset(key)
+		}
+	}, nil
+}
@@ -0,0 +1,353 @@
+package states
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/milvus-io/birdwatcher/framework"
+	"github.com/milvus-io/birdwatcher/wal/utility"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
+	"github.com/milvus-io/milvus/pkg/v2/util/tsoutil"
+)
+
+type WALStatsParam struct {
+	framework.DataSetParam `use:"wal stats" desc:"aggregate wal traffic per vchannel, collection and message type"`
+	WALName                string `name:"wal_name" default:"Pulsar" desc:"wal name to scan"`
+	PChannel               string `name:"pchannel" desc:"the pchannel to scan"`
+	MQAddr                 string `name:"mq_addr" default:"" desc:"mq address, see consumev2"`
+	VChannel               string `name:"vchannel" default:"" desc:"only aggregate messages of the vchannel"`
+	EndTime                string `name:"end_time" default:"" desc:"stop at the first message after end time, RFC3339 or raw time tick"`
+	Limit                  int64  `name:"limit" default:"0" desc:"stop after scanning the number of messages, 0 means no limit"`
+	WALStartParam
+}
+
+// WALStatsCommand scans a range of the pchannel and aggregates the traffic, it stops at the end of the range,
+// end of an offline dump or Ctrl+C, and reports what is scanned so far.
+func (s *InstanceState) WALStatsCommand(ctx context.Context, p *WALStatsParam) (*framework.PresetResultSet, error) {
+	if p.PChannel == "" {
+		return nil, errors.New("pchannel must be provided")
+	}
+	readOption, err := p.readOption(p.WALName, p.MQAddr, p.VChannel)
+	if err != nil {
+		return nil, err
+	}
+	var endTimeTick uint64
+	if p.EndTime != "" {
+		if endTimeTick, err = parseStartTimeTick(p.EndTime); err != nil {
+			return nil, err
+		}
+	}
+
+	stats := newWALStats(p.PChannel)
+	readOption.TxnObserver = stats.observeTxn
+
+	sigChan := SetupSignalHandling()
+	defer CleanupSignalHandling(sigChan)
+	fmt.Println("Scanning wal, press Ctrl+C to stop and show the stats so far...")
+
+	scanner, err := NewWALScannerWithOption(ctx, p.WALName, p.PChannel, p.MQAddr, readOption)
+	if err != nil {
+		return nil, err
+	}
+	defer scanner.Scanner.Close()
+
+	eof := scanner.EOF
+	var drained <-chan time.Time
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-sigChan:
+			break loop
+		case <-eof:
+			eof = nil
+			drained = time.After(offlineDrainTimeout)
+		case <-drained:
+			break loop
+		case msg, ok := <-scanner.MessageChan:
+			if !ok {
+				return nil, errors.New("scanner closed")
+			}
+			if drained != nil {
+				drained = time.After(offlineDrainTimeout)
+			}
+			if endTimeTick > 0 && msg.TimeTick() > endTimeTick {
+				break loop
+			}
+			stats.add(msg)
+			if p.Limit > 0 && stats.Messages >= p.Limit {
+				break loop
+			}
+		}
+	}
+	stats.finish()
+	return framework.NewPresetResultSet(stats, framework.NameFormat(p.Format)), nil
+}
+
+// WALTrafficStats is the traffic of one message type on a vchannel.
+type WALTrafficStats struct {
+	VChannel     string `json:"vchannel"`
+	CollectionID int64  `json:"collection_id"`
+	Type         string `json:"type"`
+	Count        int64  `json:"count"`
+	Bytes        int64  `json:"bytes"`
+	Rows         int64  `json:"rows"`
+}
+
+// WALTimeTickStats is the time tick progress of a vchannel, the gap is the physical time between two adjacent messages.
+type WALTimeTickStats struct {
+	VChannel      string        `json:"vchannel"`
+	Messages      int64         `json:"messages"`
+	FirstTimeTick uint64        `json:"first_time_tick"`
+	LastTimeTick  uint64        `json:"last_time_tick"`
+	MaxGap        time.Duration `json:"max_gap"`
+	AvgGap        time.Duration `json:"avg_gap"`
+	totalGap      time.Duration
+}
+
+// WALTxnStats is the statistics of txns in the same final state.
+type WALTxnStats struct {
+	State       string        `json:"state"`
+	Count       int64         `json:"count"`
+	Messages    int64         `json:"messages"`
+	Bytes       int64         `json:"bytes"`
+	MinDuration time.Duration `json:"min_duration"`
+	MaxDuration time.Duration `json:"max_duration"`
+	AvgDuration time.Duration `json:"avg_duration"`
+	total       time.Duration
+}
+
+// WALStats is the result set of `wal stats`.
+type WALStats struct {
+	PChannel  string              `json:"pchannel"`
+	Messages  int64               `json:"messages"`
+	Bytes     int64               `json:"bytes"`
+	From      time.Time           `json:"from"`
+	To        time.Time           `json:"to"`
+	Traffic   []*WALTrafficStats  `json:"traffic"`
+	TimeTicks []*WALTimeTickStats `json:"time_ticks"`
+	Txns      []*WALTxnStats      `json:"txns"`
+
+	traffic   map[walTrafficKey]*WALTrafficStats
+	timeTicks map[string]*WALTimeTickStats
+	txnMut    sync.Mutex // txn events come from the scanner goroutine.
+	txns      map[string]*WALTxnStats
+	// txnClosed drops txn events observed after finish, the scanner keeps running until closed.
+	txnClosed bool
+}
+
+type walTrafficKey struct {
+	vchannel string
+	msgType  message.MessageType
+}
+
+func newWALStats(pchannel string) *WALStats {
+	return &WALStats{
+		PChannel:  pchannel,
+		traffic:   make(map[walTrafficKey]*WALTrafficStats),
+		timeTicks: make(map[string]*WALTimeTickStats),
+		txns:      make(map[string]*WALTxnStats),
+	}
+}
+
+func (s *WALStats) add(msg message.ImmutableMessage) {
+	s.Messages++
+	if s.From.IsZero() {
+		s.From = tsoutil.PhysicalTime(msg.TimeTick())
+	}
+	s.To = tsoutil.PhysicalTime(msg.TimeTick())
+	s.observeTimeTick(msg)
+
+	if msg.MessageType() == message.MessageTypeTxn {
+		// account the body messages, txn begin/commit are reported by txn stats.
+		txnMsg := message.AsImmutableTxnMessage(msg)
+		if txnMsg != nil {
+			txnMsg.RangeOver(func(sub message.ImmutableMessage) error {
+				s.addTraffic(sub)
+				return nil
+			})
+			return
+		}
+	}
+	s.addTraffic(msg)
+}
+
+func (s *WALStats) addTraffic(msg message.ImmutableMessage) {
+	key := walTrafficKey{vchannel: msg.VChannel(), msgType: msg.MessageType()}
+	stats, ok := s.traffic[key]
+	if !ok {
+		stats = &WALTrafficStats{
+			VChannel:     msg.VChannel(),
+			CollectionID: collectionIDOfVChannel(msg.VChannel()),
+			Type:         msg.MessageType().String(),
+		}
+		s.traffic[key] = stats
+	}
+	size := int64(msg.EstimateSize())
+	stats.Count++
+	stats.Bytes += size
+	s.Bytes += size
+
+	switch msg.MessageType() {
+	case message.MessageTypeInsert:
+		if insertMsg, err := message.AsImmutableInsertMessageV1(msg); err == nil {
+			for _, partition := range insertMsg.Header().GetPartitions() {
+				stats.Rows += int64(partition.GetRows())
+			}
+		}
+	case message.MessageTypeDelete:
+		if deleteMsg, err := message.AsImmutableDeleteMessageV1(msg); err == nil {
+			stats.Rows += int64(deleteMsg.Header().GetRows())
+		}
+	}
+}
+
+func (s *WALStats) observeTimeTick(msg message.ImmutableMessage) {
+	stats, ok := s.timeTicks[msg.VChannel()]
+	if !ok {
+		stats = &WALTimeTickStats{VChannel: msg.VChannel(), FirstTimeTick: msg.TimeTick()}
+		s.timeTicks[msg.VChannel()] = stats
+	}
+	stats.Messages++
+	if stats.Messages > 1 && msg.TimeTick() > stats.LastTimeTick {
+		gap := tsoutil.PhysicalTime(msg.TimeTick()).Sub(tsoutil.PhysicalTime(stats.LastTimeTick))
+		stats.totalGap += gap
+		stats.MaxGap = max(stats.MaxGap, gap)
+	}
+	stats.LastTimeTick = max(stats.LastTimeTick, msg.TimeTick())
+}
+
+func (s *WALStats) observeTxn(event utility.TxnEvent) {
+	s.txnMut.Lock()
+	defer s.txnMut.Unlock()
+	if s.txnClosed {
+		return
+	}
+	stats, ok := s.txns[event.State]
+	if !ok {
+		stats = &WALTxnStats{State: event.State}
+		s.txns[event.State] = stats
+	}
+	duration := tsoutil.PhysicalTime(event.EndTimeTick).Sub(tsoutil.PhysicalTime(event.BeginTimeTick))
+	if stats.Count == 0 || duration < stats.MinDuration {
+		stats.MinDuration = duration
+	}
+	stats.MaxDuration = max(stats.MaxDuration, duration)
+	stats.total += duration
+	stats.Count++
+	stats.Messages += int64(event.Messages)
+	stats.Bytes += int64(event.Bytes)
+}
+
+// finish freezes the aggregation into sorted slices, traffic is sorted by bytes to show the heaviest collection first.
+func (s *WALStats) finish() {
+	for _, stats := range s.traffic {
+		s.Traffic = append(s.Traffic, stats)
+	}
+	sort.Slice(s.Traffic, func(i, j int) bool {
+		if s.Traffic[i].Bytes != s.Traffic[j].Bytes {
+			return s.Traffic[i].Bytes > s.Traffic[j].Bytes
+		}
+		return s.Traffic[i].VChannel < s.Traffic[j].VChannel
+	})
+	for _, stats := range s.timeTicks {
+		if stats.Messages > 1 {
+			stats.AvgGap = stats.totalGap / time.Duration(stats.Messages-1)
+		}
+		s.TimeTicks = append(s.TimeTicks, stats)
+	}
+	sort.Slice(s.TimeTicks, func(i, j int) bool { return s.TimeTicks[i].VChannel < s.TimeTicks[j].VChannel })
+
+	s.txnMut.Lock()
+	defer s.txnMut.Unlock()
+	s.txnClosed = true
+	for _, stats := range s.txns {
+		stats.AvgDuration = stats.total / time.Duration(stats.Count)
+		s.Txns = append(s.Txns, stats)
+	}
+	sort.Slice(s.Txns, func(i, j int) bool { return s.Txns[i].State < s.Txns[j].State })
+}
+
+func (s *WALStats) Entities() any {
+	return s
+}
+
+func (s *WALStats) TableHeaders() table.Row {
+	return table.Row{"VChannel", "Collection", "Type", "Count", "Bytes", "Rows", "Bytes%"}
+}
+
+func (s *WALStats) TableRows() []table.Row {
+	rows := make([]table.Row, 0, len(s.Traffic))
+	for _, stats := range s.Traffic {
+		rows = append(rows, table.Row{displayVChannel(stats.VChannel), stats.CollectionID, stats.Type, stats.Count, stats.Bytes, stats.Rows, s.bytesRatio(stats.Bytes)})
+	}
+	return rows
+}
+
+func (s *WALStats) PrintAs(format framework.Format) string {
+	switch format {
+	case framework.FormatDefault, framework.FormatPlain:
+		sb := &strings.Builder{}
+		fmt.Fprintf(sb, "PChannel %s: %d messages, %s, from %s to %s\n",
+			s.PChannel, s.Messages, hrSize(s.Bytes), s.From.Format(time.RFC3339), s.To.Format(time.RFC3339))
+
+		fmt.Fprintln(sb, "\n=== Traffic ===")
+		tw := tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VChannel\tCollection\tType\tCount\tBytes\tRows\tBytes%")
+		for _, stats := range s.Traffic {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%s\t%d\t%s\n",
+				displayVChannel(stats.VChannel), stats.CollectionID, stats.Type, stats.Count, hrSize(stats.Bytes), stats.Rows, s.bytesRatio(stats.Bytes))
+		}
+		tw.Flush()
+
+		fmt.Fprintln(sb, "\n=== Time Tick ===")
+		tw = tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VChannel\tMessages\tFirst\tLast\tMaxGap\tAvgGap")
+		for _, stats := range s.TimeTicks {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%v\t%v\n",
+				displayVChannel(stats.VChannel), stats.Messages,
+				tsoutil.PhysicalTime(stats.FirstTimeTick).Format(time.RFC3339), tsoutil.PhysicalTime(stats.LastTimeTick).Format(time.RFC3339),
+				stats.MaxGap, stats.AvgGap)
+		}
+		tw.Flush()
+
+		if len(s.Txns) > 0 {
+			fmt.Fprintln(sb, "\n=== Txn ===")
+			tw = tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "State\tCount\tMessages\tBytes\tMin\tAvg\tMax")
+			for _, stats := range s.Txns {
+				fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%v\t%v\t%v\n",
+					stats.State, stats.Count, stats.Messages, hrSize(stats.Bytes), stats.MinDuration, stats.AvgDuration, stats.MaxDuration)
+			}
+			tw.Flush()
+		}
+		return sb.String()
+	case framework.FormatJSON:
+		return framework.MarshalJSON(s)
+	}
+	return ""
+}
+
+func (s *WALStats) bytesRatio(bytes int64) string {
+	if s.Bytes == 0 {
+		return "0.00%"
+	}
+	return fmt.Sprintf("%.2f%%", float64(bytes)*100/float64(s.Bytes))
+}
+
+// displayVChannel shows the pchannel level messages, e.g. time tick, which has no vchannel.
+func displayVChannel(vchannel string) string {
+	if vchannel == "" {
+		return "(pchannel)"
+	}
+	return vchannel
+}
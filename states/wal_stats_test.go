@@ -0,0 +1,85 @@
+package states
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/birdwatcher/wal/utility"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
+	"github.com/milvus-io/milvus/pkg/v2/util/tsoutil"
+)
+
+// testWALMessage implements the message methods used by wal stats, others are not expected to be called.
+type testWALMessage struct {
+	message.ImmutableMessage
+	vchannel string
+	msgType  message.MessageType
+	timeTick uint64
+	size     int
+}
+
+func (m *testWALMessage) MessageType() message.MessageType { return m.msgType }
+func (m *testWALMessage) TimeTick() uint64                 { return m.timeTick }
+func (m *testWALMessage) VChannel() string                 { return m.vchannel }
+func (m *testWALMessage) EstimateSize() int                { return m.size }
+
+func TestWALStats(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tt := func(d time.Duration) uint64 { return tsoutil.ComposeTSByTime(start.Add(d), 0) }
+	const v0, v1 = "by-dev-rootcoord-dml_0_100v0", "by-dev-rootcoord-dml_0_200v0"
+
+	stats := newWALStats("by-dev-rootcoord-dml_0")
+	for _, msg := range []*testWALMessage{
+		{vchannel: v0, msgType: message.MessageTypeFlush, timeTick: tt(0), size: 10},
+		{vchannel: v1, msgType: message.MessageTypeFlush, timeTick: tt(time.Second), size: 20},
+		{vchannel: v0, msgType: message.MessageTypeFlush, timeTick: tt(time.Second), size: 10},
+		{vchannel: v0, msgType: message.MessageTypeCreateSegment, timeTick: tt(4 * time.Second), size: 5},
+		{vchannel: "", msgType: message.MessageTypeTimeTick, timeTick: tt(5 * time.Second), size: 1},
+	} {
+		stats.add(msg)
+	}
+	stats.observeTxn(utility.TxnEvent{State: utility.TxnStateCommitted, BeginTimeTick: tt(0), EndTimeTick: tt(time.Second), Messages: 2, Bytes: 30})
+	stats.observeTxn(utility.TxnEvent{State: utility.TxnStateCommitted, BeginTimeTick: tt(0), EndTimeTick: tt(3 * time.Second), Messages: 1, Bytes: 10})
+	stats.observeTxn(utility.TxnEvent{State: utility.TxnStateExpired, BeginTimeTick: tt(0), EndTimeTick: tt(10 * time.Second)})
+	stats.finish()
+	// events after finish are dropped
+	stats.observeTxn(utility.TxnEvent{State: utility.TxnStateRolledBack})
+
+	assert.EqualValues(t, 5, stats.Messages)
+	assert.EqualValues(t, 46, stats.Bytes)
+	assert.Equal(t, tsoutil.PhysicalTime(tt(0)), stats.From)
+	assert.Equal(t, tsoutil.PhysicalTime(tt(5*time.Second)), stats.To)
+
+	// traffic is sorted by bytes
+	require.Len(t, stats.Traffic, 4)
+	assert.Equal(t, WALTrafficStats{VChannel: v0, CollectionID: 100, Type: message.MessageTypeFlush.String(), Count: 2, Bytes: 20}, *stats.Traffic[0])
+	assert.Equal(t, WALTrafficStats{VChannel: v1, CollectionID: 200, Type: message.MessageTypeFlush.String(), Count: 1, Bytes: 20}, *stats.Traffic[1])
+	assert.Equal(t, message.MessageTypeCreateSegment.String(), stats.Traffic[2].Type)
+	assert.Equal(t, message.MessageTypeTimeTick.String(), stats.Traffic[3].Type)
+
+	require.Len(t, stats.TimeTicks, 3)
+	assert.Equal(t, "", stats.TimeTicks[0].VChannel)
+	assert.EqualValues(t, 1, stats.TimeTicks[0].Messages)
+	assert.Zero(t, stats.TimeTicks[0].MaxGap)
+	tick := stats.TimeTicks[1]
+	assert.Equal(t, v0, tick.VChannel)
+	assert.EqualValues(t, 3, tick.Messages)
+	assert.Equal(t, tt(0), tick.FirstTimeTick)
+	assert.Equal(t, tt(4*time.Second), tick.LastTimeTick)
+	assert.Equal(t, 3*time.Second, tick.MaxGap)
+	assert.Equal(t, 2*time.Second, tick.AvgGap)
+
+	require.Len(t, stats.Txns, 2)
+	committed := stats.Txns[0]
+	assert.Equal(t, utility.TxnStateCommitted, committed.State)
+	assert.EqualValues(t, 2, committed.Count)
+	assert.EqualValues(t, 3, committed.Messages)
+	assert.EqualValues(t, 40, committed.Bytes)
+	assert.Equal(t, time.Second, committed.MinDuration)
+	assert.Equal(t, 3*time.Second, committed.MaxDuration)
+	assert.Equal(t, 2*time.Second, committed.AvgDuration)
+	assert.Equal(t, utility.TxnStateExpired, stats.Txns[1].State)
+}
@@ -1,6 +1,7 @@
 package adaptor
 
 import (
+	"github.com/milvus-io/birdwatcher/wal/utility"
 	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
 	"github.com/milvus-io/milvus/pkg/v2/streaming/util/options"
 )
@@ -16,4 +17,5 @@ type ReadOption struct {
 	// the default message handler will be used, and the receiver will be returned from Chan.
 	// Otherwise, Chan will panic.
 	// vaild every message will be passed to this handler before being delivered to the consumer.
+	TxnObserver func(utility.TxnEvent) // optional callback invoked from the scanner goroutine when a txn is committed, rolled back or expired.
 }
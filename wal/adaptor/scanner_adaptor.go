@@ -57,6 +57,10 @@ func newScannerAdaptor(
 		zap.String("name", name),
 		zap.String("channel", l.Channel().Name),
 	)
+	txnBuffer := utility.NewTxnBuffer(logger)
+	if readOption.TxnObserver != nil {
+		txnBuffer.SetObserver(readOption.TxnObserver)
+	}
 	s := &scannerAdaptorImpl{
 		logger:        logger,
 		recovery:      false,
@@ -65,7 +69,7 @@ func newScannerAdaptor(
 		filterFunc:    options.GetFilterFunc(readOption.MessageFilter),
 		reorderBuffer: utility.NewReOrderBuffer(),
 		pendingQueue:  utility.NewPendingQueue(),
-		txnBuffer:     utility.NewTxnBuffer(logger),
+		txnBuffer:     txnBuffer,
 		cleanup:       cleanup,
 		ScannerHelper: helper.NewScannerHelper(name),
 	}
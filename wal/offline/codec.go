@@ -0,0 +1,178 @@
+// Package offline implements a read-only WAL backed by a local dump file,
+// so WAL based commands can run against captured data without the MQ.
+package offline
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/cockroachdb/errors"
+)
+
+// dumpMagic leads every dump file, the trailing byte is the format version.
+var dumpMagic = []byte("BWWALDUMP\x01")
+
+// maxFieldSize guards against allocating huge buffers for a corrupted file.
+const maxFieldSize = 1 << 30
+
+// Header describes the source of a dump.
+type Header struct {
+	WALName  string // commonpb.WALName string of the source WAL, used to unmarshal message IDs.
+	PChannel string
+}
+
+// Record is one raw message as read from the underlying WAL implementation.
+type Record struct {
+	MessageID  string // marshaled message id of the source WAL.
+	Payload    []byte
+	Properties map[string]string
+}
+
+// Writer appends records to a dump. It is not safe for concurrent use.
+type Writer struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+// NewWriter writes the file header and returns a writer for records.
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	dw := &Writer{w: bufio.NewWriter(w)}
+	if _, err := dw.w.Write(dumpMagic); err != nil {
+		return nil, err
+	}
+	dw.putString(header.WALName)
+	dw.putString(header.PChannel)
+	if err := dw.flushBuf(); err != nil {
+		return nil, err
+	}
+	return dw, nil
+}
+
+// Write appends a record, properties are written in key order so dumps are reproducible.
+func (w *Writer) Write(r *Record) error {
+	w.putString(r.MessageID)
+	w.putBytes(r.Payload)
+	keys := make([]string, 0, len(r.Properties))
+	for k := range r.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(keys)))
+	for _, k := range keys {
+		w.putString(k)
+		w.putString(r.Properties[k])
+	}
+	return w.flushBuf()
+}
+
+// Flush writes buffered records to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+func (w *Writer) putString(s string) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *Writer) putBytes(b []byte) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *Writer) flushBuf() error {
+	_, err := w.w.Write(w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+// Reader reads records from a dump.
+type Reader struct {
+	r      *bufio.Reader
+	header Header
+}
+
+// NewReader validates the file header.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(dumpMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, errors.Wrap(err, "failed to read dump header")
+	}
+	if string(magic) != string(dumpMagic) {
+		return nil, errors.New("not a wal dump file or unsupported dump version")
+	}
+	dr := &Reader{r: br}
+	var err error
+	if dr.header.WALName, err = dr.readString(); err != nil {
+		return nil, errors.Wrap(err, "failed to read dump header")
+	}
+	if dr.header.PChannel, err = dr.readString(); err != nil {
+		return nil, errors.Wrap(err, "failed to read dump header")
+	}
+	return dr, nil
+}
+
+// Header returns the dump header.
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the next record, io.EOF at the end of the dump.
+func (r *Reader) Next() (*Record, error) {
+	id, err := r.readString()
+	if err != nil {
+		// a clean end of file can only happen at the record boundary.
+		return nil, err
+	}
+	record := &Record{MessageID: id}
+	if record.Payload, err = r.readBytes(); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	record.Properties = make(map[string]string, n)
+	for i := uint64(0); i < n; i++ {
+		k, err := r.readString()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		v, err := r.readString()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		record.Properties[k] = v
+	}
+	return record, nil
+}
+
+func (r *Reader) readString() (string, error) {
+	b, err := r.readBytes()
+	return string(b), err
+}
+
+func (r *Reader) readBytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxFieldSize {
+		return nil, errors.Newf("field size %d exceeds limit, dump may be corrupted", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return b, nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package offline
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpRoundTrip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, Header{WALName: "Pulsar", PChannel: "by-dev-rootcoord-dml_0"})
+	require.NoError(t, err)
+
+	records := []*Record{
+		{MessageID: "id-1", Payload: []byte("payload"), Properties: map[string]string{"_t": "1", "_v": "by-dev-rootcoord-dml_0_1v0"}},
+		{MessageID: "id-2", Payload: nil, Properties: map[string]string{}},
+	}
+	for _, r := range records {
+		require.NoError(t, w.Write(r))
+	}
+	require.NoError(t, w.Flush())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, "Pulsar", r.Header().WALName)
+	assert.Equal(t, "by-dev-rootcoord-dml_0", r.Header().PChannel)
+
+	for _, expected := range records {
+		record, err := r.Next()
+		require.NoError(t, err)
+		assert.Equal(t, expected.MessageID, record.MessageID)
+		assert.Equal(t, len(expected.Payload), len(record.Payload))
+		assert.Equal(t, expected.Properties, record.Properties)
+	}
+	_, err = r.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestDumpTruncated(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, Header{WALName: "Kafka", PChannel: "ch"})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(&Record{MessageID: "id", Payload: []byte("payload")}))
+	require.NoError(t, w.Flush())
+
+	data := buf.Bytes()
+	r, err := NewReader(bytes.NewReader(data[:len(data)-3]))
+	require.NoError(t, err)
+	_, err = r.Next()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	_, err = NewReader(bytes.NewReader([]byte("not a dump")))
+	assert.Error(t, err)
+}
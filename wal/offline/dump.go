@@ -0,0 +1,109 @@
+package offline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/walimpls"
+)
+
+// DumpingWAL wraps a WAL implementation and persists every raw message read from it into a dump file.
+// Messages are captured below the adaptor, so time ticks and txn begin/commit messages are kept as is.
+type DumpingWAL struct {
+	walimpls.ROWALImpls
+	mu     sync.Mutex
+	file   *os.File
+	writer *Writer
+	err    error
+}
+
+// NewDumpingWAL creates the dump file at path, walName is the commonpb.WALName string of the inner WAL.
+func NewDumpingWAL(inner walimpls.ROWALImpls, walName string, path string) (*DumpingWAL, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewWriter(f, Header{
+		WALName:  walName,
+		PChannel: inner.Channel().Name,
+	})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &DumpingWAL{
+		ROWALImpls: inner,
+		file:       f,
+		writer:     w,
+	}, nil
+}
+
+// Read wraps the inner scanner to tee messages into the dump.
+func (w *DumpingWAL) Read(ctx context.Context, opts walimpls.ReadOption) (walimpls.ScannerImpls, error) {
+	inner, err := w.ROWALImpls.Read(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	s := &dumpingScanner{
+		ScannerImpls: inner,
+		ch:           make(chan message.ImmutableMessage),
+	}
+	go func() {
+		defer close(s.ch)
+		for msg := range inner.Chan() {
+			w.write(msg)
+			select {
+			case <-inner.Done():
+				return
+			case s.ch <- msg:
+			}
+		}
+	}()
+	return s, nil
+}
+
+// Close flushes the dump and closes the inner WAL.
+func (w *DumpingWAL) Close() {
+	w.ROWALImpls.Close()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return
+	}
+	if err := w.writer.Flush(); err != nil && w.err == nil {
+		w.err = err
+	}
+	if err := w.file.Close(); err != nil && w.err == nil {
+		w.err = err
+	}
+	if w.err != nil {
+		fmt.Fprintf(os.Stderr, "wal dump %s is incomplete: %s\n", w.file.Name(), w.err.Error())
+	}
+	w.file = nil
+}
+
+// write stops dumping at the first error, consuming goes on.
+func (w *DumpingWAL) write(msg message.ImmutableMessage) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil || w.err != nil {
+		return
+	}
+	w.err = w.writer.Write(&Record{
+		MessageID:  msg.MessageID().Marshal(),
+		Payload:    msg.Payload(),
+		Properties: msg.Properties().ToRawMap(),
+	})
+}
+
+type dumpingScanner struct {
+	walimpls.ScannerImpls
+	ch chan message.ImmutableMessage
+}
+
+func (s *dumpingScanner) Chan() <-chan message.ImmutableMessage {
+	return s.ch
+}
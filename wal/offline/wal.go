@@ -0,0 +1,181 @@
+package offline
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/milvus-io/birdwatcher/wal/utility"
+	"github.com/milvus-io/milvus/pkg/v2/proto/streamingpb"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/types"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/walimpls"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/walimpls/helper"
+)
+
+// WAL is a read-only WAL implementation replaying a dump file.
+// Wrap it with adaptor.NewScanner to get the same txn assembling and time tick ordering as a live WAL.
+type WAL struct {
+	path    string
+	header  Header
+	walName message.WALName
+	eof     chan struct{}
+	eofOnce sync.Once
+}
+
+// ReadHeader reads the header of the dump file.
+func ReadHeader(path string) (Header, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Header{}, err
+	}
+	defer f.Close()
+	r, err := NewReader(f)
+	if err != nil {
+		return Header{}, errors.Wrapf(err, "failed to open dump %s", path)
+	}
+	return r.Header(), nil
+}
+
+// Open opens the dump file, pchannel is optional and checked against the dump header if provided.
+func Open(path string, pchannel string) (*WAL, error) {
+	header, err := ReadHeader(path)
+	if err != nil {
+		return nil, err
+	}
+	if pchannel != "" && pchannel != header.PChannel {
+		return nil, errors.Newf("dump %s is captured from pchannel %s, not %s", path, header.PChannel, pchannel)
+	}
+	walName, err := utility.ParseWALName(header.WALName)
+	if err != nil {
+		return nil, err
+	}
+	return &WAL{
+		path:    path,
+		header:  header,
+		walName: message.WALName(walName),
+		eof:     make(chan struct{}),
+	}, nil
+}
+
+// WALName returns the name of the wal the dump is captured from.
+func (w *WAL) WALName() message.WALName {
+	return w.walName
+}
+
+// Channel returns the pchannel the dump is captured from.
+func (w *WAL) Channel() types.PChannelInfo {
+	return types.PChannelInfo{
+		Name:       w.header.PChannel,
+		AccessMode: types.AccessModeRO,
+	}
+}
+
+// EOF is closed once a scanner has read the whole dump.
+// A dump has no tail to follow, consumers use it to stop instead of waiting forever.
+func (w *WAL) EOF() <-chan struct{} {
+	return w.eof
+}
+
+// Read creates a scanner replaying the dump from the deliver policy position.
+func (w *WAL) Read(ctx context.Context, opts walimpls.ReadOption) (walimpls.ScannerImpls, error) {
+	f, err := os.Open(w.path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// skip returns true if the message is before the deliver position.
+	var skip func(id message.MessageID) bool
+	switch policy := opts.DeliverPolicy.GetPolicy().(type) {
+	case *streamingpb.DeliverPolicy_All:
+	case *streamingpb.DeliverPolicy_Latest:
+		// nothing is appended to a dump after it's captured.
+		skip = func(message.MessageID) bool { return true }
+	case *streamingpb.DeliverPolicy_StartFrom:
+		start, err := utility.UnmarshalMessageID(w.header.WALName, policy.StartFrom.GetId())
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		skip = func(id message.MessageID) bool { return id.LT(start) }
+	case *streamingpb.DeliverPolicy_StartAfter:
+		start, err := utility.UnmarshalMessageID(w.header.WALName, policy.StartAfter.GetId())
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		skip = func(id message.MessageID) bool { return id.LTE(start) }
+	default:
+		f.Close()
+		return nil, errors.Newf("unsupported deliver policy %T for offline wal", policy)
+	}
+
+	s := &scanner{
+		ScannerHelper: helper.NewScannerHelper(opts.Name),
+		wal:           w,
+		file:          f,
+		reader:        r,
+		skip:          skip,
+		ch:            make(chan message.ImmutableMessage),
+	}
+	go s.execute()
+	return s, nil
+}
+
+// Close releases nothing, every scanner owns its file handle.
+func (w *WAL) Close() {}
+
+type scanner struct {
+	*helper.ScannerHelper
+	wal    *WAL
+	file   *os.File
+	reader *Reader
+	skip   func(id message.MessageID) bool
+	ch     chan message.ImmutableMessage
+}
+
+func (s *scanner) Chan() <-chan message.ImmutableMessage {
+	return s.ch
+}
+
+func (s *scanner) execute() {
+	defer s.file.Close()
+	defer close(s.ch)
+	for {
+		record, err := s.reader.Next()
+		if errors.Is(err, io.EOF) {
+			s.wal.eofOnce.Do(func() { close(s.wal.eof) })
+			// keep the scanner open like a drained live topic, closing it would make the adaptor reopen it.
+			<-s.Context().Done()
+			s.Finish(nil)
+			return
+		}
+		if err != nil {
+			s.Finish(err)
+			return
+		}
+		id, err := utility.UnmarshalMessageID(s.wal.header.WALName, record.MessageID)
+		if err != nil {
+			s.Finish(err)
+			return
+		}
+		if s.skip != nil && s.skip(id) {
+			continue
+		}
+		msg := message.NewImmutableMesasge(id, record.Payload, record.Properties)
+		select {
+		case <-s.Context().Done():
+			s.Finish(nil)
+			return
+		case s.ch <- msg:
+		}
+	}
+}
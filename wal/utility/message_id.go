@@ -0,0 +1,33 @@
+package utility
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
+)
+
+// ParseWALName parses the wal name case-insensitively, e.g. `pulsar` or `Pulsar`.
+func ParseWALName(walName string) (commonpb.WALName, error) {
+	for name, value := range commonpb.WALName_value {
+		if strings.EqualFold(name, walName) {
+			return commonpb.WALName(value), nil
+		}
+	}
+	return commonpb.WALName_Unknown, errors.Newf("invalid wal name: %s", walName)
+}
+
+// UnmarshalMessageID unmarshals the binary message id string of the wal,
+// the same form stored in meta checkpoints and accepted by parse-wal-message-id.
+func UnmarshalMessageID(walName string, id string) (message.MessageID, error) {
+	name, err := ParseWALName(walName)
+	if err != nil {
+		return nil, err
+	}
+	return message.UnmarshalMessageID(&commonpb.MessageID{
+		WALName: name,
+		Id:      id,
+	})
+}
@@ -20,6 +20,76 @@ type TxnBuffer struct {
 	logger   *log.MLogger
 	builders map[message.TxnID]*message.ImmutableTxnMessageBuilder
 	bytes    int
+	observer func(TxnEvent)
+	events   map[message.TxnID]*TxnEvent
+}
+
+// Txn final states reported to the txn observer.
+const (
+	TxnStateCommitted  = "committed"
+	TxnStateRolledBack = "rolled_back"
+	TxnStateExpired    = "expired"
+)
+
+// TxnEvent describes a txn once it's committed, rolled back or expired.
+type TxnEvent struct {
+	TxnID         message.TxnID
+	VChannel      string
+	State         string
+	BeginTimeTick uint64
+	EndTimeTick   uint64 // time tick of commit/rollback message, or the time tick the txn is found expired.
+	Messages      int    // body messages of the txn.
+	Bytes         int
+}
+
+// SetObserver sets a callback invoked when a txn leaves the buffer, used for txn statistics.
+func (b *TxnBuffer) SetObserver(observer func(TxnEvent)) {
+	b.observer = observer
+	b.events = make(map[message.TxnID]*TxnEvent)
+}
+
+// observe updates the tracked txn event, the event is reported and dropped once state is set.
+func (b *TxnBuffer) observe(msg message.ImmutableMessage, state string) {
+	if b.observer == nil {
+		return
+	}
+	txnID := msg.TxnContext().TxnID
+	event, ok := b.events[txnID]
+	if !ok {
+		if msg.MessageType() != message.MessageTypeBeginTxn {
+			return
+		}
+		event = &TxnEvent{
+			TxnID:         txnID,
+			VChannel:      msg.VChannel(),
+			BeginTimeTick: msg.TimeTick(),
+		}
+		b.events[txnID] = event
+	}
+	event.Bytes += msg.EstimateSize()
+	switch msg.MessageType() {
+	case message.MessageTypeBeginTxn, message.MessageTypeCommitTxn, message.MessageTypeRollbackTxn:
+	default:
+		event.Messages++
+	}
+	if state != "" {
+		event.State = state
+		event.EndTimeTick = msg.TimeTick()
+		delete(b.events, txnID)
+		b.observer(*event)
+	}
+}
+
+// observeExpired reports the tracked txn event as expired at ts.
+func (b *TxnBuffer) observeExpired(txnID message.TxnID, ts uint64) {
+	event, ok := b.events[txnID]
+	if !ok {
+		return
+	}
+	event.State = TxnStateExpired
+	event.EndTimeTick = ts
+	delete(b.events, txnID)
+	b.observer(*event)
 }
 
 func (b *TxnBuffer) Bytes() int {
@@ -45,13 +115,17 @@ func (b *TxnBuffer) HandleImmutableMessages(msgs []message.ImmutableMessage, ts
 		switch msg.MessageType() {
 		case message.MessageTypeBeginTxn:
 			b.handleBeginTxn(msg)
+			b.observe(msg, "")
 		case message.MessageTypeCommitTxn:
+			b.observe(msg, TxnStateCommitted)
 			if newTxnMsg := b.handleCommitTxn(msg); newTxnMsg != nil {
 				result = append(result, newTxnMsg)
 			}
 		case message.MessageTypeRollbackTxn:
+			b.observe(msg, TxnStateRolledBack)
 			b.handleRollbackTxn(msg)
 		default:
+			b.observe(msg, "")
 			b.handleTxnBodyMessage(msg)
 		}
 	}
@@ -167,6 +241,7 @@ func (b *TxnBuffer) clearExpiredTxn(ts uint64) {
 		if builder.ExpiredTimeTick() <= ts {
 			delete(b.builders, txnID)
 			b.bytes -= builder.EstimateSize()
+			b.observeExpired(txnID, ts)
 			if b.logger.Level().Enabled(zap.DebugLevel) {
 				b.logger.Debug(
 					"the txn is expired, so drop the txn from buffer",
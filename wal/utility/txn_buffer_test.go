@@ -0,0 +1,77 @@
+package utility
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
+)
+
+// testTxnMessage implements the message methods used by txn observer, others are not expected to be called.
+type testTxnMessage struct {
+	message.ImmutableMessage
+	txnID    message.TxnID
+	msgType  message.MessageType
+	timeTick uint64
+	size     int
+}
+
+func (m *testTxnMessage) TxnContext() *message.TxnContext  { return &message.TxnContext{TxnID: m.txnID} }
+func (m *testTxnMessage) MessageType() message.MessageType { return m.msgType }
+func (m *testTxnMessage) TimeTick() uint64                 { return m.timeTick }
+func (m *testTxnMessage) VChannel() string                 { return "v0" }
+func (m *testTxnMessage) EstimateSize() int                { return m.size }
+
+func TestTxnBufferObserve(t *testing.T) {
+	msg := func(txnID message.TxnID, msgType message.MessageType, timeTick uint64) *testTxnMessage {
+		return &testTxnMessage{txnID: txnID, msgType: msgType, timeTick: timeTick, size: 10}
+	}
+
+	b := NewTxnBuffer(nil)
+	// no observer, nothing tracked
+	b.observe(msg(1, message.MessageTypeBeginTxn, 100), "")
+	assert.Nil(t, b.events)
+
+	var events []TxnEvent
+	b.SetObserver(func(event TxnEvent) { events = append(events, event) })
+
+	// committed txn with two body messages
+	b.observe(msg(1, message.MessageTypeBeginTxn, 100), "")
+	b.observe(msg(1, message.MessageTypeInsert, 101), "")
+	b.observe(msg(1, message.MessageTypeDelete, 102), "")
+	assert.Empty(t, events)
+	b.observe(msg(1, message.MessageTypeCommitTxn, 103), TxnStateCommitted)
+	require.Len(t, events, 1)
+	assert.Equal(t, TxnEvent{
+		TxnID: 1, VChannel: "v0", State: TxnStateCommitted,
+		BeginTimeTick: 100, EndTimeTick: 103, Messages: 2, Bytes: 40,
+	}, events[0])
+
+	// body of txn without begin message is not tracked
+	b.observe(msg(2, message.MessageTypeInsert, 104), "")
+	b.observe(msg(2, message.MessageTypeCommitTxn, 105), TxnStateCommitted)
+	assert.Len(t, events, 1)
+
+	b.observe(msg(3, message.MessageTypeBeginTxn, 106), "")
+	b.observe(msg(3, message.MessageTypeInsert, 107), "")
+	b.observe(msg(3, message.MessageTypeRollbackTxn, 108), TxnStateRolledBack)
+	require.Len(t, events, 2)
+	assert.Equal(t, TxnStateRolledBack, events[1].State)
+	assert.Equal(t, uint64(108), events[1].EndTimeTick)
+	assert.Equal(t, 1, events[1].Messages)
+
+	b.observe(msg(4, message.MessageTypeBeginTxn, 109), "")
+	b.observeExpired(4, 200)
+	require.Len(t, events, 3)
+	assert.Equal(t, TxnEvent{
+		TxnID: 4, VChannel: "v0", State: TxnStateExpired,
+		BeginTimeTick: 109, EndTimeTick: 200, Bytes: 10,
+	}, events[2])
+	assert.Empty(t, b.events)
+
+	// reported txn is not reported again
+	b.observeExpired(4, 300)
+	assert.Len(t, events, 3)
+}